@@ -0,0 +1,208 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type AmendOrderParams struct {
+	OrderID string `validate:"required"`
+	// 0 keeps the current limit price
+	Price float64
+	// Total size of the order including what has already filled. 0 keeps the current size
+	Quantity float64
+}
+
+type AmendOrderResult struct {
+	OriginalOrderID string
+	// OrderID is the order that is live after the amend. It is the same as OriginalOrderID when
+	// the order was edited in place and empty when the original filled completely before it was replaced
+	OrderID string
+	// Replaced is true when the order had to be cancelled and placed again
+	Replaced bool
+	// FilledQuantity is the quantity that filled on the original order
+	FilledQuantity float64
+	Order          *cbadvmodel.Order
+}
+
+// errEditUnsupported is returned when the exchange refused the edit without touching the order
+var errEditUnsupported = errors.New("edit not supported")
+
+// editUnsupportedReasons are the edit failures that leave the order as it was. Anything else may have been applied
+var editUnsupportedReasons = map[string]bool{
+	"ONLY_LIMIT_ORDER_EDITS_SUPPORTED": true,
+	"COMMANDER_REJECTED_EDIT_ORDER":    true,
+}
+
+type editOrderRequest struct {
+	OrderID string `json:"order_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+}
+
+type editOrderResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		EditFailureReason    string `json:"edit_failure_reason"`
+		PreviewFailureReason string `json:"preview_failure_reason"`
+	} `json:"errors"`
+}
+
+// AmendOrder changes the limit price and/or size of an open limit order. The exchange edit endpoint
+// is tried first and when the exchange says the order can't be edited it's cancelled and placed again for
+// whatever has not filled yet. The kill switch and risk policy apply to an edit too with only what it adds counted
+// against the daily notional
+func (c *apiclient) AmendOrder(ctx context.Context, params *AmendOrderParams) (_ *AmendOrderResult, err error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	ctx, span := c.startSpan(ctx, "AmendOrder", orderIDAttribute.String(params.OrderID))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if params.Price <= 0 && params.Quantity <= 0 {
+		return nil, errors.New("price or quantity is required to amend an order")
+	}
+
+	order, err := c.GetOrder(ctx, params.OrderID)
+	if err != nil {
+		return nil, err
+	} else if order == nil || order.Status == nil {
+		return nil, errors.New("unknown order issue")
+	} else if *order.Status != cbadvmodel.OPEN {
+		return nil, fmt.Errorf("order '%s' is not open and cannot be amended. status: '%s'", params.OrderID, *order.Status)
+	}
+
+	limit := order.GetOrderConfiguration().LimitLimitGtc
+	if limit == nil {
+		return nil, fmt.Errorf("order '%s' is not a limit order and cannot be amended", params.OrderID)
+	}
+
+	price, quantity := params.Price, params.Quantity
+	if price <= 0 {
+		price = limit.GetLimitPrice()
+	}
+	if quantity <= 0 {
+		quantity = limit.GetBaseSize()
+	}
+
+	if quantity <= order.GetFilledSize() {
+		return nil, fmt.Errorf("new quantity %f must be greater than the filled quantity %f", quantity, order.GetFilledSize())
+	}
+
+	productID, err := ParseProductID(order.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+	side := sideType(order.GetSide())
+
+	// an edit never goes through CreateLimitMarketOrder so the policy is checked here on what the order grows by
+	release, err := c.reserveRiskFor(ctx, &CreateLimitMarketOrderParams{
+		ID:          params.OrderID,
+		BaseTicker:  productID.Base(),
+		QuoteTicker: productID.Quote(),
+		Price:       price,
+		Quantity:    quantity,
+		Side:        side,
+	}, math.Max(price*quantity-limit.GetLimitPrice()*limit.GetBaseSize(), 0), false)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AmendOrderResult{OriginalOrderID: params.OrderID}
+
+	if err = c.editOrder(ctx, params.OrderID, price, quantity); err == nil {
+		if res.Order, err = c.GetOrder(ctx, params.OrderID); err != nil {
+			return nil, err
+		}
+		res.OrderID = params.OrderID
+		res.FilledQuantity = res.Order.GetFilledSize()
+		return res, nil
+	} else if !errors.Is(err, errEditUnsupported) {
+		// the edit may have gone through so replacing could double the order
+		return nil, err
+	}
+	c.log("unable to edit order '%s' so falling back to cancel and replace. err: %s", params.OrderID, err.Error())
+	// the edit wasn't applied and the replacement is checked on its own
+	release()
+
+	if err = c.CancelOrders(ctx, params.OrderID); err != nil {
+		// the order may have filled since it was read which is also why the cancel failed
+		filled, getErr := c.GetOrder(ctx, params.OrderID)
+		if getErr != nil || filled.GetStatus() != cbadvmodel.FILLED {
+			return nil, err
+		}
+		c.log("order '%s' filled completely before it could be replaced", params.OrderID)
+		res.FilledQuantity = filled.GetFilledSize()
+		res.Order = filled
+		return res, nil
+	}
+
+	// Fills can land between reading the order and the cancel so get the final numbers
+	cancelled, err := c.GetOrder(ctx, params.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("order '%s' was cancelled but the final state could not be read: %w", params.OrderID, err)
+	}
+	res.Replaced = true
+	res.FilledQuantity = cancelled.GetFilledSize()
+
+	remaining := quantity - res.FilledQuantity
+	if remaining <= 0 {
+		c.log("order '%s' filled completely before it could be replaced", params.OrderID)
+		res.Order = cancelled
+		return res, nil
+	}
+
+	res.Order, err = c.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          params.OrderID,
+		BaseTicker:  productID.Base(),
+		QuoteTicker: productID.Quote(),
+		Price:       price,
+		Quantity:    remaining,
+		Side:        side,
+	})
+	if err != nil {
+		return res, fmt.Errorf("order '%s' was cancelled but the replacement failed: %w", params.OrderID, err)
+	}
+	res.OrderID = res.Order.GetOrderId()
+
+	return res, nil
+}
+
+func (c *apiclient) editOrder(ctx context.Context, orderID string, price, quantity float64) error {
+	var res editOrderResponse
+	if err := c.doRequest(ctx, "EditOrder", http.MethodPost, "/brokerage/orders/edit", &editOrderRequest{
+		OrderID: orderID,
+		Price:   fmt.Sprintf("%f", price),
+		Size:    fmt.Sprintf("%f", quantity),
+	}, &res); err != nil {
+		return err
+	}
+
+	if !res.Success {
+		reasons := []string{}
+		unsupported := false
+		for _, e := range res.Errors {
+			reasons = append(reasons, e.EditFailureReason+" "+e.PreviewFailureReason)
+			if e.EditFailureReason != "" {
+				c.metrics.ObserveErrorCode("EditOrder", e.EditFailureReason)
+			}
+			unsupported = unsupported || editUnsupportedReasons[e.EditFailureReason]
+		}
+		if unsupported {
+			return fmt.Errorf("%w: %s", errEditUnsupported, strings.Join(reasons, ", "))
+		}
+		return fmt.Errorf("edit order failed: %s", strings.Join(reasons, ", "))
+	}
+
+	return nil
+}
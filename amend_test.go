@@ -0,0 +1,210 @@
+package apiclient_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+}
+
+func openLimitOrder(orderID string, price, size, filled float64) *model.GetOrderResponse {
+	status := model.OPEN
+	return &model.GetOrderResponse{Order: &model.Order{
+		OrderId:   utils.StringToPtr(orderID),
+		ProductId: utils.StringToPtr("YFI-BTC"),
+		Side:      utils.StringToPtr("BUY"),
+		Status:    &status,
+		OrderConfiguration: &model.OutputOrderConfiguration{
+			LimitLimitGtc: &model.OutputOrderConfigurationLimitLimitGtc{
+				BaseSize:   utils.Float64ToFloat64Ptr(size),
+				LimitPrice: utils.Float64ToFloat64Ptr(price),
+			},
+		},
+		FilledSize: utils.Float64ToFloat64Ptr(filled),
+	}}
+}
+
+var _ = Describe("AmendOrder", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		cont     ApiClient
+		edits    int
+		editBody string
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false)
+		Expect(err).To(BeNil())
+
+		edits = 0
+		cbClient.EXPECT().CheckAuthentication(gomock.Any(), gomock.Any()).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should edit the order in place when the exchange allows it", func() {
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			edits++
+			bts, _ := io.ReadAll(req.Body)
+			editBody = string(bts)
+			Expect(req.URL.Path).To(Equal("/api/v3/brokerage/orders/edit"))
+			return jsonResponse(200, `{"success": true}`), nil
+		})})
+		gomock.InOrder(
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.5), nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.39, 2, 0.5), nil),
+		)
+
+		res, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Price: 0.39})
+		Expect(err).To(BeNil())
+		Expect(edits).To(Equal(1))
+		Expect(editBody).To(ContainSubstring(`"price":"0.390000"`))
+		Expect(editBody).To(ContainSubstring(`"size":"2.000000"`))
+		Expect(res.Replaced).To(BeFalse())
+		Expect(res.OrderID).To(Equal("order-1"))
+		Expect(res.FilledQuantity).To(Equal(0.5))
+	})
+
+	It("should cancel and replace the unfilled quantity when the edit fails", func() {
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(200, `{"success": false, "errors": [{"edit_failure_reason": "COMMANDER_REJECTED_EDIT_ORDER"}]}`), nil
+		})})
+
+		success := true
+		gomock.InOrder(
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.5), nil),
+			cbClient.EXPECT().CancelOrders(gomock.Any(), []string{"order-1"}).Return(&model.CancelOrderResponse{
+				Results: []model.CancelOrderResponseResultsInner{{Success: &success}},
+			}, nil),
+			// another fill landed before the cancel went through
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.75), nil),
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, req *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+					Expect(req.GetProductId()).To(Equal("YFI-BTC"))
+					Expect(req.GetSide()).To(Equal("BUY"))
+					Expect(req.OrderConfiguration.LimitLimitGtc.GetBaseSize()).To(Equal("1.250000"))
+					Expect(req.OrderConfiguration.LimitLimitGtc.GetLimitPrice()).To(Equal("0.390000"))
+					return &model.CreateOrderResponse{Success: &success, OrderId: utils.StringToPtr("order-2")}, nil
+				}),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-2").Return(openLimitOrder("order-2", 0.39, 1.25, 0), nil),
+		)
+
+		res, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Price: 0.39})
+		Expect(err).To(BeNil())
+		Expect(res.Replaced).To(BeTrue())
+		Expect(res.OriginalOrderID).To(Equal("order-1"))
+		Expect(res.OrderID).To(Equal("order-2"))
+		Expect(res.FilledQuantity).To(Equal(0.75))
+	})
+
+	It("should not edit the order while the kill switch is engaged", func() {
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.5), nil)
+		Expect(cont.EngageKillSwitch(ctx, false)).To(Succeed())
+
+		_, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Price: 0.39})
+		Expect(errors.Is(err, ErrKillSwitchEngaged)).To(BeTrue())
+	})
+
+	It("should check what the edit adds against the risk policy", func() {
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false,
+			WithRiskPolicy(RiskPolicy{MaxOrderNotional: 2, MaxDailyNotional: 0.5, MaxOpenOrdersPerProduct: 1}))
+		Expect(err).To(BeNil())
+
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(200, `{"success": true}`), nil
+		})})
+		gomock.InOrder(
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0), nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 3, 0), nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 3, 0), nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 3, 0), nil),
+		)
+
+		// 0.4 more notional fits under the daily max and the order itself doesn't count as another open one
+		_, err = cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Quantity: 3})
+		Expect(err).To(BeNil())
+
+		_, err = cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Quantity: 3.5})
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("daily BTC notional"))
+
+		_, err = cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Quantity: 6})
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("order notional"))
+	})
+
+	It("should not cancel and replace when the edit may have gone through", func() {
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset by peer")
+		})})
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.5), nil)
+
+		_, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Price: 0.39})
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("connection reset by peer"))
+	})
+
+	It("should return the filled order when it fills before the cancel", func() {
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(200, `{"success": false, "errors": [{"edit_failure_reason": "COMMANDER_REJECTED_EDIT_ORDER"}]}`), nil
+		})})
+
+		failed, reason := false, "UNKNOWN_CANCEL_ORDER"
+		filled := openLimitOrder("order-1", 0.4, 2, 2)
+		status := model.FILLED
+		filled.Order.Status = &status
+		gomock.InOrder(
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0.5), nil),
+			cbClient.EXPECT().CancelOrders(gomock.Any(), []string{"order-1"}).Return(&model.CancelOrderResponse{
+				Results: []model.CancelOrderResponseResultsInner{{Success: &failed, FailureReason: &reason}},
+			}, nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(filled, nil),
+		)
+
+		res, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Price: 0.39})
+		Expect(err).To(BeNil())
+		Expect(res.OrderID).To(BeEmpty())
+		Expect(res.FilledQuantity).To(Equal(2.0))
+		Expect(res.Order.GetStatus()).To(Equal(model.FILLED))
+	})
+
+	It("should not amend an order below what has already filled", func() {
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 1.5), nil)
+
+		_, err := cont.AmendOrder(ctx, &AmendOrderParams{OrderID: "order-1", Quantity: 1})
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("must be greater than the filled quantity"))
+	})
+})
@@ -2,10 +2,13 @@ package apiclient
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +22,7 @@ import (
 	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
 	"github.com/google/uuid"
 	coinbasegoclientv3 "github.com/happilymarrieddad/coinbase-go-client-v3"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type sideType string
@@ -28,6 +32,8 @@ const (
 	SellSideType sideType = "SELL"
 )
 
+var ErrOrderNotFound = errors.New("order not found")
+
 //go:generate mockgen -destination=./mocks/CoinbaseClient.go -package=mocks github.com/QuantFu-Inc/coinbase-adv/client CoinbaseClient
 
 //go:generate mockgen -destination=./mocks/ApiClient.go -package=mocks github.com/happilymarrieddad/coinbase-v3-apiclient ApiClient
@@ -36,25 +42,53 @@ type ApiClient interface {
 
 	// Helpers
 	GetCurrentWallentAmount(
-		ctx context.Context, baseTicker, quoteTicker string,
+		ctx context.Context, productID ProductID,
 	) (baseAccount, quoteAccount *cbadvmodel.Account, baseAmount, quoteAmount float64, err error)
-	GetProduct(ctx context.Context, baseTicker, quoteTicker string) (product *cbadvmodel.GetProductResponse, err error)
-	GetProductMarketData(ctx context.Context, baseTicker, quoteTicker string, pricePercentageChange24h *float64) (highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage float64, err error)
+	GetProduct(ctx context.Context, productID ProductID) (product *cbadvmodel.GetProductResponse, err error)
+	GetProductMarketData(ctx context.Context, productID ProductID, pricePercentageChange24h *float64) (highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage float64, err error)
 	CreateLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (order *cbadvmodel.Order, err error)
+	CreateStopLimitOrder(ctx context.Context, params *CreateStopLimitOrderParams) (order *cbadvmodel.Order, err error)
 	VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) error
 	GetOrder(ctx context.Context, orderID string) (*cbadvmodel.Order, error)
-	GetOpenOrdersByProductIDAndSide(ctx context.Context, productID string, side cbadvmodel.OrderSide) ([]cbadvmodel.Order, error)
-	GetOrderFills(ctx context.Context, orderID, productID string) ([]cbadvmodel.OrderFill, error)
+	GetOrderByClientOrderID(ctx context.Context, productID ProductID, clientOrderID string) (*cbadvmodel.Order, error)
+	GetOpenOrdersByProductIDAndSide(ctx context.Context, productID ProductID, side cbadvmodel.OrderSide) ([]cbadvmodel.Order, error)
+	GetOrderFills(ctx context.Context, orderID string, productID ProductID) ([]cbadvmodel.OrderFill, error)
+	GetFillsByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) ([]cbadvmodel.OrderFill, error)
+	GetOrdersByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) ([]cbadvmodel.Order, error)
+	// EachFillPage and EachOrderPage hand every page to fn as it arrives and stop at the first error fn returns
+	EachFillPage(ctx context.Context, productID ProductID, start, end time.Time, fn func(fills []cbadvmodel.OrderFill) error) error
+	EachOrderPage(ctx context.Context, productID ProductID, start, end time.Time, fn func(orders []cbadvmodel.Order) error) error
+	GetAccounts(ctx context.Context) ([]cbadvmodel.Account, error)
 	CancelOrders(ctx context.Context, orderIds ...string) (err error)
-	CancelExistingOrders(ctx context.Context, id string, productID string, orderType model.OrderType) (err error)
+	CancelExistingOrders(ctx context.Context, id string, productID ProductID, orderType model.OrderType) (err error)
+	AmendOrder(ctx context.Context, params *AmendOrderParams) (*AmendOrderResult, error)
+	Recover(ctx context.Context) ([]JournalEntry, error)
+	EngageKillSwitch(ctx context.Context, cancelOpenOrders bool) error
+	ReleaseKillSwitch()
+	GetFeeTier(ctx context.Context) (*FeeTier, error)
+	ValidateOrder(ctx context.Context, params *CreateLimitMarketOrderParams) error
+	GetProductCatalog(ctx context.Context) (*ProductCatalog, error)
+	GetBestBidAsk(ctx context.Context, productIDs ...ProductID) (map[ProductID]TopOfBook, error)
+	// Clock is what the client times everything with. Every strategy built on the client uses it too
+	Clock() Clock
 }
 
 // NewApiClient backup will not be needed once the main client supports MarketTrades and ListProducts
-func NewApiClient(client cbadvclient.CoinbaseClient, backup coinbasegoclientv3.Client, debug bool) (ApiClient, error) {
+func NewApiClient(client cbadvclient.CoinbaseClient, backup coinbasegoclientv3.Client, debug bool, opts ...Option) (ApiClient, error) {
 	// forcing debug for now
-	c := apiclient{client: client, backup: backup, mutex: &sync.RWMutex{}, debug: debug}
+	c := apiclient{client: client, backup: backup, mutex: &sync.RWMutex{}, catalogMutex: &sync.Mutex{}, debug: debug, risk: newRiskState(), clock: NewRealClock(), metrics: noopMetrics{}, tracer: newTracer(nil)}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
 
-	return &c, nil
+	interceptors := c.interceptors
+	if c.dryRun {
+		// innermost so every other interceptor still sees the call
+		interceptors = append(interceptors, DryRunInterceptor())
+	}
+
+	return Chain(&c, interceptors...), nil
 }
 
 type apiclient struct {
@@ -63,28 +97,100 @@ type apiclient struct {
 	accountUUIDsByTicker map[string]string
 	mutex                *sync.RWMutex
 	debug                bool
+	orderJournal         OrderJournal
+	riskPolicy           *RiskPolicy
+	clock                Clock
+	metrics              MetricsHook
+	tracer               trace.Tracer
+	interceptors         []Interceptor
+	dryRun               bool
+	validateProducts     bool
+	catalogMutex         *sync.Mutex
+	catalog              *ProductCatalog
+	catalogFetchedAt     time.Time
+	risk                 *riskState
 	// helper parameters
 	hasMentionedOrderWaiting bool
 }
 
 func (c *apiclient) CreateOrderAndWaitForCompletion(ctx context.Context, params *CreateLimitMarketOrderParams, timeout time.Time) (orderID string, err error) {
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	ctx, span := c.startSpan(ctx, "CreateOrderAndWaitForCompletion",
+		productIDAttribute.String(params.ProductID().String()), sideAttribute.String(string(params.Side)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// 0 - make sure we can find the order again if we die part way through
+	if err = c.recordJournalEntry(newJournalEntry(params, IntentJournalStatus)); err != nil {
+		return "", err
+	}
+
 	// 1 - create market order to buy the base ticker using quote ticker
 	order, err := c.CreateLimitMarketOrder(ctx, params)
+	setOrderAttributes(span, order)
 	if err != nil {
+		// Without an order we can't be sure it wasn't placed so the intent is left for Recover
+		if order != nil {
+			entry := newJournalEntry(params, journalStatusFromOrder(order))
+			entry.ClientOrderID, entry.OrderID, entry.Message = order.GetClientOrderId(), order.GetOrderId(), err.Error()
+			c.recordJournalEntry(entry)
+		}
 		return "", err
 	}
 
+	entry := newJournalEntry(params, SubmittedJournalStatus)
+	entry.ClientOrderID, entry.OrderID = order.GetClientOrderId(), order.GetOrderId()
+	c.recordJournalEntry(entry)
+
 	// 2 - either wait until market order completes or if timeout/error return
 	if err = c.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), timeout); err != nil {
+		c.recordFinalJournalEntry(ctx, entry, err)
 		return order.GetOrderId(), err
 	}
 
+	entry.Status = FilledJournalStatus
+	c.recordJournalEntry(entry)
+	span.SetAttributes(statusAttribute.String(string(cbadvmodel.FILLED)))
+
 	return order.GetOrderId(), nil
 }
 
+// recordFinalJournalEntry records the status of an order that stopped being waited on. Orders that are
+// still open stay unfinished so Recover will pick them up again
+func (c *apiclient) recordFinalJournalEntry(ctx context.Context, entry JournalEntry, waitErr error) {
+	if c.orderJournal == nil {
+		return
+	}
+
+	order, err := c.GetOrder(ctx, entry.OrderID)
+	if err != nil {
+		c.log("unable to get final status for order '%s': %s", entry.OrderID, err.Error())
+		return
+	}
+
+	if entry.Status = journalStatusFromOrder(order); entry.Status.IsTerminal() {
+		entry.Message = waitErr.Error()
+		c.recordJournalEntry(entry)
+	}
+}
+
 func (c *apiclient) GetCurrentWallentAmount(
-	ctx context.Context, baseTicker, quoteTicker string,
+	ctx context.Context, productID ProductID,
 ) (baseAccount, quoteAccount *cbadvmodel.Account, baseAmount, quoteAmount float64, err error) {
+	ctx, span := c.startSpan(ctx, "GetCurrentWallentAmount", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return nil, nil, 0, 0, err
+	}
+	baseTicker, quoteTicker := productID.Base(), productID.Quote()
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -92,9 +198,11 @@ func (c *apiclient) GetCurrentWallentAmount(
 		c.log("account data not available so fetching from remote service")
 		c.accountUUIDsByTicker = make(map[string]string)
 
+		start := c.clock.Now()
 		accs, err := c.client.ListAccounts(ctx, &cbadvclient.ListAccountsParams{
 			Limit: utils.Int32ToPtr(250), // TODO: this won't work when coinbase gets more than 250 coins...
 		})
+		c.observeRequest("ListAccounts", start, err)
 		if err != nil {
 			c.log("client.ListAccounts err: %s", err.Error())
 			return nil, nil, 0, 0, err
@@ -111,7 +219,9 @@ func (c *apiclient) GetCurrentWallentAmount(
 	if !exists {
 		return nil, nil, 0, 0, fmt.Errorf("baseTicker '%s' account not available", baseTicker)
 	}
+	start := c.clock.Now()
 	currentBaseAcc, err := c.client.GetAccount(ctx, baseAccUUID)
+	c.observeRequest("GetAccount", start, err)
 	if err != nil {
 		return nil, nil, 0, 0, err
 	}
@@ -120,7 +230,9 @@ func (c *apiclient) GetCurrentWallentAmount(
 	if !exists {
 		return nil, nil, 0, 0, fmt.Errorf("quoteTicker '%s' account not available", quoteTicker)
 	}
+	start = c.clock.Now()
 	currentQuoteAcc, err := c.client.GetAccount(ctx, quoteAccUUID)
+	c.observeRequest("GetAccount", start, err)
 	if err != nil {
 		return nil, nil, 0, 0, err
 	}
@@ -130,16 +242,68 @@ func (c *apiclient) GetCurrentWallentAmount(
 		utils.Float64PtrToFloat64(currentQuoteAcc.AvailableBalance.Value), nil
 }
 
-func (c *apiclient) GetProduct(ctx context.Context, baseTicker, quoteTicker string) (product *cbadvmodel.GetProductResponse, err error) {
-	return c.client.GetProduct(ctx, fmt.Sprintf("%s-%s", baseTicker, quoteTicker))
+// GetAccounts pages through every account
+func (c *apiclient) GetAccounts(ctx context.Context) (_ []cbadvmodel.Account, err error) {
+	ctx, span := c.startSpan(ctx, "GetAccounts")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	accounts := []cbadvmodel.Account{}
+	var cursor *string
+
+	for {
+		start := c.clock.Now()
+		res, err := c.client.ListAccounts(ctx, &cbadvclient.ListAccountsParams{
+			Limit:  utils.Int32ToPtr(250),
+			Cursor: cursor,
+		})
+		c.observeRequest("ListAccounts", start, err)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, res.Accounts...)
+
+		if !res.GetHasNext() || res.GetCursor() == "" {
+			return accounts, nil
+		}
+		cursor = res.Cursor
+	}
+}
+
+func (c *apiclient) GetProduct(ctx context.Context, productID ProductID) (product *cbadvmodel.GetProductResponse, err error) {
+	ctx, span := c.startSpan(ctx, "GetProduct", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	start := c.clock.Now()
+	product, err = c.client.GetProduct(ctx, productID.String())
+	c.observeRequest("GetProduct", start, err)
+
+	return product, err
 }
 
 func (c *apiclient) GetProductMarketData(
-	ctx context.Context, baseTicker, quoteTicker string, pricePercentageChange24h *float64,
+	ctx context.Context, productID ProductID, pricePercentageChange24h *float64,
 ) (highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage float64, err error) {
-	productID := fmt.Sprintf("%s-%s", baseTicker, quoteTicker)
+	ctx, span := c.startSpan(ctx, "GetProductMarketData", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
 
-	resPtr, err := c.client.GetProduct(ctx, productID)
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	start := c.clock.Now()
+	resPtr, err := c.client.GetProduct(ctx, productID.String())
+	c.observeRequest("GetProduct", start, err)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	} else if resPtr == nil {
@@ -151,7 +315,9 @@ func (c *apiclient) GetProductMarketData(
 		return 0, 0, 0, 0, fmt.Errorf("perc 24hr change less than %f%%", utils.Float64PtrToFloat64(pricePercentageChange24h))
 	}
 
+	start = c.clock.Now()
 	trades, err := c.backup.GetMarketTrades(ctx, utils.StringPtrToString(resPtr.ProductId), 1000)
+	c.observeRequest("GetMarketTrades", start, err)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
@@ -180,6 +346,8 @@ func (c *apiclient) GetProductMarketData(
 }
 
 type CreateLimitMarketOrderParams struct {
+	// ID identifies the callers intent. Creating an order again with the same ID, side, product, price and
+	// quantity will never place a second order. A uuid is generated when it's empty
 	ID          string
 	BaseTicker  string   `validate:"required"`
 	QuoteTicker string   `validate:"required"`
@@ -194,7 +362,7 @@ type CreateLimitMarketOrderParams struct {
 // CreateLimitMarketOrder
 //
 //	side - BUY or SELL
-func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (*cbadvmodel.Order, error) {
+func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (order *cbadvmodel.Order, err error) {
 	if err := utils.Validate(params); err != nil {
 		return nil, err
 	}
@@ -203,14 +371,44 @@ func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLi
 		params.ID = uuid.New().String()
 	}
 
-	params.ID += fmt.Sprintf("-%d", time.Now().UnixMilli())
+	ctx, span := c.startSpan(ctx, "CreateLimitMarketOrder",
+		productIDAttribute.String(params.ProductID().String()), sideAttribute.String(string(params.Side)))
+	defer func() {
+		span.SetAttributes(attemptAttribute.Int(params.NumOfTries+1), clientOrderIDAttribute.String(params.ClientOrderID()))
+		setOrderAttributes(span, order)
+		endSpan(span, err)
+	}()
 
-	coid := fmt.Sprintf("create-market-order-%s-%s-%s-%s", params.Side, params.BaseTicker, params.QuoteTicker, params.ID)
-	productID := fmt.Sprintf("%s-%s", params.BaseTicker, params.QuoteTicker)
+	// retries happen below this so the risk policy sees every order exactly once
+	release, err := c.reserveRisk(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err = c.createLimitMarketOrder(ctx, params, c.clock.Now().Add(-createdOrderLookback))
+	if isRejectedOrder(err) {
+		release()
+	}
+
+	return order, err
+}
 
+// createLimitMarketOrder calls itself for every retry. Each attempt is an event on the CreateLimitMarketOrder span.
+// since is just before the first attempt and bounds the lookup after a failed create
+func (c *apiclient) createLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams, since time.Time) (*cbadvmodel.Order, error) {
+	coid := params.ClientOrderID()
+	productID := params.ProductID()
+	if err := c.checkTradableProductID(ctx, productID); err != nil {
+		return nil, rejectOrder(err)
+	}
+	trace.SpanFromContext(ctx).AddEvent("attempt", trace.WithAttributes(
+		attemptAttribute.Int(params.NumOfTries+1), clientOrderIDAttribute.String(coid),
+	))
+
+	start := c.clock.Now()
 	req, err := c.client.CreateOrder(ctx, &cbadvmodel.CreateOrderRequest{
 		ClientOrderId: utils.StringToPtr(coid),
-		ProductId:     utils.StringToPtr(productID),
+		ProductId:     utils.StringToPtr(productID.String()),
 		Side:          utils.StringToPtr(string(params.Side)),
 		OrderConfiguration: &cbadvmodel.CreateOrderRequestOrderConfiguration{
 			LimitLimitGtc: &cbadvmodel.CreateOrderRequestOrderConfigurationLimitLimitGtc{
@@ -219,14 +417,34 @@ func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLi
 			},
 		},
 	})
+	c.observeRequest("CreateOrder", start, err)
 	if err != nil {
-		return nil, err
+		// We don't know if coinbase placed the order so look for it before trying again
+		existing, lookupErr := c.findOrderByClientOrderID(ctx, productID, coid, since)
+		if lookupErr == nil {
+			c.log("order '%s' was placed even though create failed with err: %s", coid, err.Error())
+			return c.verifyCreatedOrder(params, existing)
+		}
+
+		if params.NumOfTries > 5 {
+			c.log("Num of retries has exceeded the allowed amount so just returning the error")
+			return nil, err
+		}
+
+		// The client order id doesn't change so coinbase will return the existing order instead of placing another one
+		c.log("create order '%s' failed with err: %s. retrying", coid, err.Error())
+		c.metrics.ObserveRetry("request")
+		c.clock.Sleep(time.Millisecond * 150) // just delay slightly
+		params.NumOfTries++
+		return c.createLimitMarketOrder(ctx, params, since)
 	} else if req == nil {
 		return nil, errors.New("create order request is nil")
 	} else if !*req.Success {
+		c.metrics.ObserveErrorCode("CreateOrder", req.ErrorResponse.GetError())
+
 		if params.NumOfTries > 5 {
 			c.log("Num of retries has exceeded the allowed amount so just returning the error")
-			return nil, errors.New(req.ErrorResponse.GetMessage())
+			return nil, rejectOrder(errors.New(req.ErrorResponse.GetMessage()))
 		}
 
 		// This is the most dodgy place in the app...
@@ -235,18 +453,20 @@ func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLi
 			newPrice := utils.TrimFloatToRight(params.Price, 1)
 			c.log("invalid price precision. current price: %f, new price: %f", params.Price, newPrice)
 			params.Price = newPrice
-			time.Sleep(time.Millisecond * 50) // just delay slightly
+			c.metrics.ObserveRetry(req.ErrorResponse.GetError())
+			c.clock.Sleep(time.Millisecond * 50) // just delay slightly
 			params.NumOfTries++
-			return c.CreateLimitMarketOrder(ctx, params)
+			return c.createLimitMarketOrder(ctx, params, since)
 		}
 
 		if req.ErrorResponse.GetError() == "INSUFFICIENT_FUND" {
 			newQuantity := utils.TrimFloatToRight(params.Quantity, 1)
 			c.log("invalid quantity. current quantity: %f, new quantity: %f", params.Quantity, newQuantity)
 			params.Quantity = newQuantity
-			time.Sleep(time.Millisecond * 150) // just delay slightly
+			c.metrics.ObserveRetry(req.ErrorResponse.GetError())
+			c.clock.Sleep(time.Millisecond * 150) // just delay slightly
 			params.NumOfTries++
-			return c.CreateLimitMarketOrder(ctx, params)
+			return c.createLimitMarketOrder(ctx, params, since)
 		}
 
 		if req.ErrorResponse.GetError() == "INVALID_SIZE_PRECISION" {
@@ -254,22 +474,49 @@ func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLi
 				newQuantity := utils.TrimFloatToRight(params.Quantity, 1)
 				c.log("invalid quantity. current quantity: %f, new quantity: %f", params.Quantity, newQuantity)
 				params.Quantity = newQuantity
-				time.Sleep(time.Millisecond * 150) // just delay slightly
+				c.metrics.ObserveRetry(req.ErrorResponse.GetError())
+				c.clock.Sleep(time.Millisecond * 150) // just delay slightly
 				params.NumOfTries++
-				return c.CreateLimitMarketOrder(ctx, params)
+				return c.createLimitMarketOrder(ctx, params, since)
 			}
 		}
 
 		// This is just so I can add error handling as I go
 		fmt.Printf("limit market order failed with err: %s\n", req.ErrorResponse.GetError())
 		spew.Dump(req.ErrorResponse)
-		return nil, errors.New(req.ErrorResponse.GetMessage())
+		return nil, rejectOrder(errors.New(req.ErrorResponse.GetMessage()))
 	}
 
 	order, err := c.GetOrder(ctx, req.GetOrderId())
 	if err != nil {
 		return nil, err
-	} else if order.Status == nil {
+	}
+
+	return c.verifyCreatedOrder(params, order)
+}
+
+func (p *CreateLimitMarketOrderParams) ProductID() ProductID {
+	return NewProductID(p.BaseTicker, p.QuoteTicker)
+}
+
+// ClientOrderID is derived from the params so the same intent always maps to the same coinbase client order id.
+// ID must be set
+func (p *CreateLimitMarketOrderParams) ClientOrderID() string {
+	intent := sha256.Sum256([]byte(fmt.Sprintf("%f-%f", p.Price, p.Quantity)))
+
+	return p.clientOrderIDPrefix() + hex.EncodeToString(intent[:clientOrderIDHashBytes])
+}
+
+// clientOrderIDHashBytes is how much of the price and quantity hash ends every client order id
+const clientOrderIDHashBytes = 4
+
+// clientOrderIDPrefix is shared by every attempt for the same ID even when the price or quantity get adjusted
+func (p *CreateLimitMarketOrderParams) clientOrderIDPrefix() string {
+	return fmt.Sprintf("create-market-order-%s-%s-%s-%s-", p.Side, p.BaseTicker, p.QuoteTicker, p.ID)
+}
+
+func (c *apiclient) verifyCreatedOrder(params *CreateLimitMarketOrderParams, order *cbadvmodel.Order) (*cbadvmodel.Order, error) {
+	if order.Status == nil {
 		return order, errors.New("unknown order issue")
 	}
 
@@ -282,14 +529,26 @@ func (c *apiclient) CreateLimitMarketOrder(ctx context.Context, params *CreateLi
 	return order, fmt.Errorf("order failed with status: '%s' and msg: '%s'", status, utils.StringPtrToString(order.CancelMessage)+" "+utils.StringPtrToString(order.RejectMessage))
 }
 
-func (c *apiclient) VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) error {
-	if time.Now().After(timeout) {
+func (c *apiclient) VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) (err error) {
+	ctx, span := c.startSpan(ctx, "VerifyMarketOrderCompletion", orderIDAttribute.String(orderID))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return c.verifyMarketOrderCompletion(ctx, orderID, timeout, 1)
+}
+
+// verifyMarketOrderCompletion calls itself for every poll. Each poll is an event on the VerifyMarketOrderCompletion span
+func (c *apiclient) verifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time, attempt int) error {
+	if c.clock.Now().After(timeout) {
 		err := fmt.Errorf("market order '%s' has timed out", orderID)
 		c.log("%s", err.Error())
 		return err
 	}
 
+	start := c.clock.Now()
 	orderRes, err := c.client.GetOrder(ctx, orderID)
+	c.observeRequest("GetOrder", start, err)
 	if err != nil {
 		log.Printf("\n")
 		return err
@@ -299,6 +558,10 @@ func (c *apiclient) VerifyMarketOrderCompletion(ctx context.Context, orderID str
 
 	status := string(*order.Status)
 
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("poll", trace.WithAttributes(attemptAttribute.Int(attempt), statusAttribute.String(status)))
+	span.SetAttributes(attemptAttribute.Int(attempt), statusAttribute.String(status))
+
 	/* Coinbase order status'
 	OPEN OrderStatus = "OPEN"
 	FILLED OrderStatus = "FILLED"
@@ -313,11 +576,14 @@ func (c *apiclient) VerifyMarketOrderCompletion(ctx context.Context, orderID str
 			c.log("sleeping because market order has not completed yet...")
 			c.hasMentionedOrderWaiting = true
 		}
-		time.Sleep(time.Second * 5)
-		return c.VerifyMarketOrderCompletion(ctx, orderID, timeout)
+		c.clock.Sleep(time.Second * 5)
+		return c.verifyMarketOrderCompletion(ctx, orderID, timeout, attempt+1)
 	case "FILLED":
 		c.hasMentionedOrderWaiting = false
 		c.log("market order '%s' has completed", orderID)
+		if created, parseErr := time.Parse(time.RFC3339Nano, order.GetCreatedTime()); parseErr == nil {
+			c.metrics.ObserveTimeToFill(order.GetProductId(), order.GetSide(), c.clock.Now().Sub(created))
+		}
 		return nil
 	/* These probably will never happen */
 	case "CANCELLED", "FAILED":
@@ -339,8 +605,15 @@ func (c *apiclient) VerifyMarketOrderCompletion(ctx context.Context, orderID str
 	return errors.New("unknown issue with the order: " + status)
 }
 
-func (c *apiclient) GetOrder(ctx context.Context, orderID string) (*cbadvmodel.Order, error) {
+func (c *apiclient) GetOrder(ctx context.Context, orderID string) (_ *cbadvmodel.Order, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrder", orderIDAttribute.String(orderID))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	start := c.clock.Now()
 	res, err := c.client.GetOrder(ctx, orderID)
+	c.observeRequest("GetOrder", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -348,28 +621,181 @@ func (c *apiclient) GetOrder(ctx context.Context, orderID string) (*cbadvmodel.O
 	return res.Order, nil
 }
 
-func (c *apiclient) GetOpenOrdersByProductIDAndSide(ctx context.Context, productID string, side cbadvmodel.OrderSide) ([]cbadvmodel.Order, error) {
-	res, err := c.client.ListOrders(ctx, &cbadvclient.ListOrdersParams{
-		ProductId:   productID,
-		Limit:       250, // This should never even come close to being reached
-		OrderStatus: []string{"OPEN"},
-		OrderSide:   side,
+// GetOrderByClientOrderID returns ErrOrderNotFound when none of the orders for the product match
+func (c *apiclient) GetOrderByClientOrderID(ctx context.Context, productID ProductID, clientOrderID string) (_ *cbadvmodel.Order, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrderByClientOrderID", productIDAttribute.String(productID.String()), clientOrderIDAttribute.String(clientOrderID))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return c.findOrderByClientOrderID(ctx, productID, clientOrderID, time.Time{})
+}
+
+// createdOrderLookback leaves room for our clock being ahead of coinbase's when looking for an order we just created
+const createdOrderLookback = time.Minute
+
+// findOrderByClientOrderID only looks at orders created after since. A zero since looks through every order
+func (c *apiclient) findOrderByClientOrderID(ctx context.Context, productID ProductID, clientOrderID string, since time.Time) (*cbadvmodel.Order, error) {
+	return c.findOrder(ctx, productID, since, func(order *cbadvmodel.Order) bool {
+		return order.GetClientOrderId() == clientOrderID
 	})
-	if err != nil {
+}
+
+// findOrder pages through the product's orders created after since newest first until one matches
+func (c *apiclient) findOrder(ctx context.Context, productID ProductID, since time.Time, match func(order *cbadvmodel.Order) bool) (*cbadvmodel.Order, error) {
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	var cursor *string
+
+	for {
+		start := c.clock.Now()
+		res, err := c.client.ListOrders(ctx, &cbadvclient.ListOrdersParams{
+			ProductId: productID.String(),
+			Limit:     250,
+			StartDate: since,
+			OrderSide: cbadvmodel.UNKNOWN_ORDER_SIDE,
+			Cursor:    cursor,
+		})
+		c.observeRequest("ListOrders", start, err)
+		if err != nil {
+			return nil, err
+		}
+
+		for idx := range res.Orders {
+			if match(&res.Orders[idx]) {
+				return &res.Orders[idx], nil
+			}
+		}
+
+		if !res.GetHasNext() || res.GetCursor() == "" {
+			return nil, ErrOrderNotFound
+		}
+		cursor = res.Cursor
+	}
+}
+
+// GetOrdersByTimeRange pages through every order for the product created between start and end. An empty
+// productID returns orders for every product
+func (c *apiclient) GetOrdersByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) (_ []cbadvmodel.Order, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrdersByTimeRange", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	orders := []cbadvmodel.Order{}
+	if err = c.eachOrderPage(ctx, productID, start, end, func(page []cbadvmodel.Order) error {
+		orders = append(orders, page...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (c *apiclient) EachOrderPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(orders []cbadvmodel.Order) error,
+) (err error) {
+	ctx, span := c.startSpan(ctx, "EachOrderPage", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return c.eachOrderPage(ctx, productID, start, end, fn)
+}
+
+func (c *apiclient) eachOrderPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(orders []cbadvmodel.Order) error,
+) error {
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return err
+	}
+
+	var cursor *string
+
+	for {
+		requestStart := c.clock.Now()
+		res, err := c.client.ListOrders(ctx, &cbadvclient.ListOrdersParams{
+			ProductId: productID.String(),
+			Limit:     250,
+			StartDate: start,
+			EndDate:   end,
+			OrderSide: cbadvmodel.UNKNOWN_ORDER_SIDE,
+			Cursor:    cursor,
+		})
+		c.observeRequest("ListOrders", requestStart, err)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(res.Orders); err != nil {
+			return err
+		}
+
+		if !res.GetHasNext() || res.GetCursor() == "" {
+			return nil
+		}
+		cursor = res.Cursor
+	}
+}
+
+func (c *apiclient) GetOpenOrdersByProductIDAndSide(ctx context.Context, productID ProductID, side cbadvmodel.OrderSide) (_ []cbadvmodel.Order, err error) {
+	ctx, span := c.startSpan(ctx, "GetOpenOrdersByProductIDAndSide", productIDAttribute.String(productID.String()), sideAttribute.String(string(side)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkProductID(ctx, productID); err != nil {
 		return nil, err
 	}
 
-	return res.Orders, nil
+	orders := []cbadvmodel.Order{}
+	var cursor *string
+
+	for {
+		start := c.clock.Now()
+		res, err := c.client.ListOrders(ctx, &cbadvclient.ListOrdersParams{
+			ProductId:   productID.String(),
+			Limit:       250,
+			OrderStatus: []string{"OPEN"},
+			OrderSide:   side,
+			Cursor:      cursor,
+		})
+		c.observeRequest("ListOrders", start, err)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, res.Orders...)
+
+		if !res.GetHasNext() || res.GetCursor() == "" {
+			return orders, nil
+		}
+		cursor = res.Cursor
+	}
 }
 
-func (c *apiclient) GetOrderFills(ctx context.Context, orderID, productID string) ([]cbadvmodel.OrderFill, error) {
+func (c *apiclient) GetOrderFills(ctx context.Context, orderID string, productID ProductID) (_ []cbadvmodel.OrderFill, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrderFills", orderIDAttribute.String(orderID), productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	start := c.clock.Now()
 	res, err := c.client.ListFills(ctx, &cbadvclient.ListFillsParams{
 		OrderId:                orderID,
-		ProductId:              productID,
+		ProductId:              productID.String(),
 		Limit:                  250,
-		StartSequenceTimestamp: time.Now().Add(time.Minute * 5),
-		EndSequenceTimestamp:   time.Now(),
+		StartSequenceTimestamp: c.clock.Now().Add(time.Minute * 5),
+		EndSequenceTimestamp:   c.clock.Now(),
 	})
+	c.observeRequest("ListFills", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -377,8 +803,79 @@ func (c *apiclient) GetOrderFills(ctx context.Context, orderID, productID string
 	return res.Fills, nil
 }
 
+// GetFillsByTimeRange pages through every fill for the product between start and end. An empty productID returns
+// fills for every product
+func (c *apiclient) GetFillsByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) (_ []cbadvmodel.OrderFill, err error) {
+	ctx, span := c.startSpan(ctx, "GetFillsByTimeRange", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	fills := []cbadvmodel.OrderFill{}
+	if err = c.eachFillPage(ctx, productID, start, end, func(page []cbadvmodel.OrderFill) error {
+		fills = append(fills, page...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return fills, nil
+}
+
+func (c *apiclient) EachFillPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(fills []cbadvmodel.OrderFill) error,
+) (err error) {
+	ctx, span := c.startSpan(ctx, "EachFillPage", productIDAttribute.String(productID.String()))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return c.eachFillPage(ctx, productID, start, end, fn)
+}
+
+func (c *apiclient) eachFillPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(fills []cbadvmodel.OrderFill) error,
+) error {
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return err
+	}
+
+	var cursor *string
+
+	for {
+		requestStart := c.clock.Now()
+		res, err := c.client.ListFills(ctx, &cbadvclient.ListFillsParams{
+			ProductId:              productID.String(),
+			Limit:                  250,
+			StartSequenceTimestamp: start,
+			EndSequenceTimestamp:   end,
+			Cursor:                 cursor,
+		})
+		c.observeRequest("ListFills", requestStart, err)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(res.Fills); err != nil {
+			return err
+		}
+
+		if res.GetCursor() == "" || len(res.Fills) == 0 {
+			return nil
+		}
+		cursor = res.Cursor
+	}
+}
+
 func (c *apiclient) CancelOrders(ctx context.Context, orderIds ...string) (err error) {
+	ctx, span := c.startSpan(ctx, "CancelOrders", orderIDAttribute.StringSlice(orderIds))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	start := c.clock.Now()
 	res, err := c.client.CancelOrders(ctx, orderIds)
+	c.observeRequest("CancelOrders", start, err)
 	if err != nil {
 		return err
 	}
@@ -388,26 +885,70 @@ func (c *apiclient) CancelOrders(ctx context.Context, orderIds ...string) (err e
 		return errors.New("no results returned from server for cancel order")
 	}
 
-	result := results[0]
+	failures := map[string]string{}
+	for idx := range results {
+		if results[idx].GetSuccess() {
+			continue
+		}
+		c.metrics.ObserveErrorCode("CancelOrders", results[idx].GetFailureReason())
 
-	if result.GetSuccess() {
-		return nil
+		orderID := results[idx].GetOrderId()
+		if orderID == "" && idx < len(orderIds) {
+			orderID = orderIds[idx]
+		}
+		failures[orderID] = results[idx].GetFailureReason()
+	}
+
+	if len(failures) > 0 {
+		return &CancelOrdersError{Failures: failures}
+	}
+
+	return nil
+}
+
+// CancelOrdersError is returned when coinbase refuses to cancel some of the orders. Failures holds the reason
+// for each order id that is still open
+type CancelOrdersError struct {
+	Failures map[string]string
+}
+
+func (e *CancelOrdersError) Error() string {
+	orderIDs := make([]string, 0, len(e.Failures))
+	for orderID := range e.Failures {
+		orderIDs = append(orderIDs, orderID)
 	}
+	sort.Strings(orderIDs)
 
-	return errors.New(result.GetFailureReason())
+	msgs := make([]string, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		msgs = append(msgs, fmt.Sprintf("order '%s': %s", orderID, e.Failures[orderID]))
+	}
+
+	return "unable to cancel " + strings.Join(msgs, ", ")
 }
 
 func (c *apiclient) CancelExistingOrders(
-	ctx context.Context, id string, productID string, orderType model.OrderType,
+	ctx context.Context, id string, productID ProductID, orderType model.OrderType,
 ) (err error) {
+	ctx, span := c.startSpan(ctx, "CancelExistingOrders", productIDAttribute.String(productID.String()), clientOrderIDAttribute.String(id))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkProductID(ctx, productID); err != nil {
+		return err
+	}
+
+	start := c.clock.Now()
 	ordersRes, err := c.client.ListOrders(ctx, &cbadvclient.ListOrdersParams{
-		ProductId:          productID,
-		StartDate:          time.Now().Add(time.Hour * -24),
-		EndDate:            time.Now(),
+		ProductId:          productID.String(),
+		StartDate:          c.clock.Now().Add(time.Hour * -24),
+		EndDate:            c.clock.Now(),
 		UserNativeCurrency: "USD",
 		OrderType:          orderType,
 		OrderSide:          model.UNKNOWN_ORDER_SIDE,
 	})
+	c.observeRequest("ListOrders", start, err)
 	if err != nil {
 		return err
 	}
@@ -423,6 +964,10 @@ func (c *apiclient) CancelExistingOrders(
 	return nil
 }
 
+func (c *apiclient) Clock() Clock {
+	return c.clock
+}
+
 // log essentially force a new line
 func (c *apiclient) log(format string, v ...any) {
 	if c.debug {
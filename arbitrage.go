@@ -0,0 +1,224 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type ArbitrageParams struct {
+	// Currency starts and ends every triangle ex. USD for USD -> BTC -> YFI -> USD
+	Currency string `validate:"required"`
+	// MinNetEdge leaves out anything thinner once fees are paid. 0.1% will be 0.001. 0 keeps every profitable triangle
+	MinNetEdge float64
+	// PollInterval defaults to 5 seconds
+	PollInterval time.Duration
+	// OnError gets every scan that failed and is called from Run's goroutine
+	OnError func(err error)
+}
+
+type ArbitrageLeg struct {
+	ProductID ProductID
+	Side      sideType
+	From      string
+	To        string
+	// Price is the top of book the leg trades against. Buys lift the ask and sells hit the bid
+	Price float64
+	// Size is the base size resting at Price
+	Size float64
+}
+
+type ArbitrageOpportunity struct {
+	Currency string
+	Legs     []ArbitrageLeg
+	// Rate is how much Currency one Currency turns into after every leg and the taker fee
+	Rate float64
+	// NetEdge is the return after fees. 0.1% will be 0.001
+	NetEdge float64
+	// MaxSize is the most Currency that goes in before a leg runs past the size at the top of book
+	MaxSize float64
+	// ExpectedProfit is NetEdge on MaxSize in Currency
+	ExpectedProfit float64
+	Time           time.Time
+}
+
+// triangle is a cycle of three legs before any prices are known
+type triangle [3]ArbitrageLeg
+
+type ArbitrageScanner struct {
+	client ApiClient
+	params ArbitrageParams
+	clock  Clock
+}
+
+func NewArbitrageScanner(client ApiClient, params ArbitrageParams) (*ArbitrageScanner, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	if params.MinNetEdge < 0 {
+		return nil, fmt.Errorf("min net edge %f can't be negative", params.MinNetEdge)
+	}
+	if params.PollInterval <= 0 {
+		params.PollInterval = time.Second * 5
+	}
+
+	return &ArbitrageScanner{client: client, params: params, clock: clockOf(client)}, nil
+}
+
+// Run scans every poll interval and sends each opportunity to out until ctx is cancelled. A failed scan goes to
+// OnError and is tried again on the next tick
+func (s *ArbitrageScanner) Run(ctx context.Context, out chan<- ArbitrageOpportunity) error {
+	ticker := s.clock.NewTicker(s.params.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		opportunities, err := s.Scan(ctx)
+		if err != nil && ctx.Err() == nil && s.params.OnError != nil {
+			s.params.OnError(err)
+		}
+
+		for _, opportunity := range opportunities {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- opportunity:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
+
+// Scan prices every triangle reachable from Currency against the top of book and returns the ones that clear
+// MinNetEdge, best edge first. Every leg pays the taker fee since it crosses the spread
+func (s *ArbitrageScanner) Scan(ctx context.Context) ([]ArbitrageOpportunity, error) {
+	catalog, err := s.client.GetProductCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	triangles := findTriangles(catalog, s.params.Currency)
+	if len(triangles) == 0 {
+		return []ArbitrageOpportunity{}, nil
+	}
+
+	feeTier, err := s.client.GetFeeTier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// one request covers every leg so the prices are as close to the same moment as possible
+	seen := map[ProductID]bool{}
+	productIDs := []ProductID{}
+	for _, tri := range triangles {
+		for _, leg := range tri {
+			if !seen[leg.ProductID] {
+				seen[leg.ProductID] = true
+				productIDs = append(productIDs, leg.ProductID)
+			}
+		}
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	books, err := s.client.GetBestBidAsk(ctx, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	opportunities := []ArbitrageOpportunity{}
+	for _, tri := range triangles {
+		opportunity, ok := evaluateTriangle(tri, books, feeTier.TakerFeeRate)
+		if !ok || opportunity.NetEdge <= 0 || opportunity.NetEdge < s.params.MinNetEdge {
+			continue
+		}
+		opportunity.Currency = s.params.Currency
+		opportunity.Time = now
+		opportunities = append(opportunities, opportunity)
+	}
+
+	sort.SliceStable(opportunities, func(i, j int) bool { return opportunities[i].NetEdge > opportunities[j].NetEdge })
+
+	return opportunities, nil
+}
+
+// findTriangles walks currency -> a -> b -> currency over every enabled product. Each cycle shows up once in
+// each direction since they trade against different sides of the book
+func findTriangles(catalog *ProductCatalog, currency string) []triangle {
+	edges := map[string][]ArbitrageLeg{}
+	for _, product := range catalog.Products() {
+		if product.Disabled {
+			continue
+		}
+
+		base, quote := product.ID.Base(), product.ID.Quote()
+		edges[quote] = append(edges[quote], ArbitrageLeg{ProductID: product.ID, Side: BuySideType, From: quote, To: base})
+		edges[base] = append(edges[base], ArbitrageLeg{ProductID: product.ID, Side: SellSideType, From: base, To: quote})
+	}
+
+	triangles := []triangle{}
+	for _, first := range edges[currency] {
+		for _, second := range edges[first.To] {
+			if second.To == currency {
+				continue
+			}
+			for _, third := range edges[second.To] {
+				if third.To == currency {
+					triangles = append(triangles, triangle{first, second, third})
+				}
+			}
+		}
+	}
+
+	return triangles
+}
+
+func evaluateTriangle(tri triangle, books map[ProductID]TopOfBook, feeRate float64) (ArbitrageOpportunity, bool) {
+	opportunity := ArbitrageOpportunity{Legs: make([]ArbitrageLeg, 0, len(tri)), MaxSize: math.MaxFloat64}
+
+	// rate is how much of the leg's From one unit of Currency has turned into so far
+	rate := 1.0
+	for _, leg := range tri {
+		book, ok := books[leg.ProductID]
+		if !ok {
+			return opportunity, false
+		}
+
+		// capacity is the most of From the top of book takes
+		var capacity float64
+		if leg.Side == BuySideType {
+			leg.Price, leg.Size = book.AskPrice, book.AskSize
+			capacity = leg.Price * leg.Size * (1 + feeRate)
+		} else {
+			leg.Price, leg.Size = book.BidPrice, book.BidSize
+			capacity = leg.Size
+		}
+		if leg.Price <= 0 || leg.Size <= 0 {
+			return opportunity, false
+		}
+
+		opportunity.MaxSize = math.Min(opportunity.MaxSize, capacity/rate)
+
+		if leg.Side == BuySideType {
+			rate /= leg.Price * (1 + feeRate)
+		} else {
+			rate *= leg.Price * (1 - feeRate)
+		}
+		opportunity.Legs = append(opportunity.Legs, leg)
+	}
+
+	opportunity.Rate = rate
+	opportunity.NetEdge = rate - 1
+	opportunity.ExpectedProfit = opportunity.NetEdge * opportunity.MaxSize
+
+	return opportunity, true
+}
@@ -0,0 +1,192 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetBestBidAsk", func() {
+	var ctrl *gomock.Controller
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should read the top of book for every product in one request", func() {
+		cbClient := mocks.NewMockCoinbaseClient(ctrl)
+		cbClient.EXPECT().CheckAuthentication(gomock.Any(), gomock.Any())
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			Expect(req.URL.Path).To(Equal("/api/v3/brokerage/best_bid_ask"))
+			Expect(req.URL.Query()["product_ids"]).To(Equal([]string{"BTC-USD", "YFI-BTC"}))
+			return jsonResponse(200, `{"pricebooks": [
+				{"product_id": "BTC-USD", "bids": [{"price": "29990", "size": "1.5"}], "asks": [{"price": "30000", "size": "2"}], "time": "2023-06-01T00:00:00Z"},
+				{"product_id": "YFI-BTC", "bids": [{"price": "0.199", "size": "4"}], "asks": []}
+			]}`), nil
+		})})
+
+		cont, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false)
+		Expect(err).To(BeNil())
+
+		books, err := cont.GetBestBidAsk(ctx, "BTC-USD", "YFI-BTC")
+		Expect(err).To(BeNil())
+		Expect(books).To(HaveLen(2))
+		Expect(books["BTC-USD"]).To(Equal(TopOfBook{
+			ProductID: "BTC-USD",
+			BidPrice:  29990,
+			BidSize:   1.5,
+			AskPrice:  30000,
+			AskSize:   2,
+			Time:      time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		}))
+		Expect(books["YFI-BTC"].BidPrice).To(Equal(0.199))
+		Expect(books["YFI-BTC"].AskPrice).To(BeZero())
+	})
+
+	It("should reject a malformed product id before making a request", func() {
+		cont, err := NewApiClient(mocks.NewMockCoinbaseClient(ctrl), mock_client.NewMockClient(ctrl), false)
+		Expect(err).To(BeNil())
+
+		_, err = cont.GetBestBidAsk(ctx, "BTCUSD")
+		Expect(errors.Is(err, ErrInvalidProductID)).To(BeTrue())
+	})
+})
+
+var _ = Describe("ArbitrageScanner", func() {
+	var (
+		ctrl    *gomock.Controller
+		client  *mocks.MockApiClient
+		clock   *FakeClock
+		scanner *ArbitrageScanner
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		clock = NewFakeClock(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+		client.EXPECT().Clock().Return(clock).AnyTimes()
+
+		var err error
+		scanner, err = NewArbitrageScanner(client, ArbitrageParams{Currency: "USD", PollInterval: time.Minute})
+		Expect(err).To(BeNil())
+
+		client.EXPECT().GetProductCatalog(gomock.Any()).Return(NewProductCatalog(
+			CatalogProduct{ID: "BTC-USD"},
+			CatalogProduct{ID: "YFI-BTC"},
+			CatalogProduct{ID: "YFI-USD"},
+			CatalogProduct{ID: "ETH-USD"},
+			CatalogProduct{ID: "ETH-BTC", Disabled: true},
+		), nil).AnyTimes()
+		client.EXPECT().GetFeeTier(gomock.Any()).Return(&FeeTier{MakerFeeRate: 0.0005, TakerFeeRate: 0.001}, nil).AnyTimes()
+		client.EXPECT().GetBestBidAsk(gomock.Any(), ProductID("BTC-USD"), ProductID("YFI-BTC"), ProductID("YFI-USD")).Return(map[ProductID]TopOfBook{
+			"BTC-USD": {BidPrice: 29990, BidSize: 5, AskPrice: 30000, AskSize: 1},
+			"YFI-BTC": {BidPrice: 0.199, BidSize: 10, AskPrice: 0.2, AskSize: 10},
+			"YFI-USD": {BidPrice: 6200, BidSize: 3, AskPrice: 6210, AskSize: 3},
+		}, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should price each leg against the right side of the book less fees", func() {
+		opportunities, err := scanner.Scan(ctx)
+		Expect(err).To(BeNil())
+		Expect(opportunities).To(HaveLen(1))
+
+		opportunity := opportunities[0]
+		Expect(opportunity.Currency).To(Equal("USD"))
+		Expect(opportunity.Time).To(Equal(clock.Now()))
+		Expect(opportunity.Legs).To(Equal([]ArbitrageLeg{
+			{ProductID: "BTC-USD", Side: BuySideType, From: "USD", To: "BTC", Price: 30000, Size: 1},
+			{ProductID: "YFI-BTC", Side: BuySideType, From: "BTC", To: "YFI", Price: 0.2, Size: 10},
+			{ProductID: "YFI-USD", Side: SellSideType, From: "YFI", To: "USD", Price: 6200, Size: 3},
+		}))
+
+		rate := 6200 * 0.999 / (30000 * 1.001) / (0.2 * 1.001)
+		Expect(opportunity.Rate).To(BeNumerically("~", rate, 1e-12))
+		Expect(opportunity.NetEdge).To(BeNumerically("~", rate-1, 1e-12))
+
+		// selling 3 YFI is the tightest leg
+		Expect(opportunity.MaxSize).To(BeNumerically("~", 3*30000*1.001*0.2*1.001, 1e-6))
+		Expect(opportunity.ExpectedProfit).To(BeNumerically("~", opportunity.MaxSize*(rate-1), 1e-6))
+	})
+
+	It("should leave out anything below the min net edge", func() {
+		var err error
+		scanner, err = NewArbitrageScanner(client, ArbitrageParams{Currency: "USD", MinNetEdge: 0.05})
+		Expect(err).To(BeNil())
+
+		opportunities, err := scanner.Scan(ctx)
+		Expect(err).To(BeNil())
+		Expect(opportunities).To(BeEmpty())
+	})
+
+	It("should skip the book when no triangle reaches the currency", func() {
+		var err error
+		scanner, err = NewArbitrageScanner(client, ArbitrageParams{Currency: "ETH"})
+		Expect(err).To(BeNil())
+
+		opportunities, err := scanner.Scan(ctx)
+		Expect(err).To(BeNil())
+		Expect(opportunities).To(BeEmpty())
+	})
+
+	It("should emit opportunities every poll interval until cancelled", func() {
+		runCtx, cancel := context.WithCancel(ctx)
+		out := make(chan ArbitrageOpportunity)
+		done := make(chan error, 1)
+		go func() {
+			done <- scanner.Run(runCtx, out)
+		}()
+
+		Eventually(out).Should(Receive())
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		Eventually(out).Should(Receive())
+
+		cancel()
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+	})
+
+	It("should pass a failed scan to OnError and keep running", func() {
+		failing := mocks.NewMockApiClient(ctrl)
+		failing.EXPECT().Clock().Return(clock).AnyTimes()
+		failing.EXPECT().GetProductCatalog(gomock.Any()).Return(nil, errors.New("i/o timeout")).Times(2)
+
+		errs := make(chan error, 2)
+		var err error
+		scanner, err = NewArbitrageScanner(failing, ArbitrageParams{
+			Currency: "USD", PollInterval: time.Minute, OnError: func(err error) { errs <- err },
+		})
+		Expect(err).To(BeNil())
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- scanner.Run(runCtx, make(chan ArbitrageOpportunity))
+		}()
+
+		Eventually(errs).Should(Receive(MatchError("i/o timeout")))
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		Eventually(errs).Should(Receive(MatchError("i/o timeout")))
+
+		cancel()
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+	})
+})
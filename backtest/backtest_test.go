@@ -0,0 +1,233 @@
+package backtest_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/backtest"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backtest", func() {
+	var (
+		start time.Time
+		cfg   backtest.Config
+	)
+
+	ticksFor := func(prices ...float64) []backtest.Tick {
+		ticks := []backtest.Tick{}
+		for idx, price := range prices {
+			ticks = append(ticks, backtest.Tick{Time: start.Add(time.Duration(idx) * time.Minute), Price: price})
+		}
+		return ticks
+	}
+
+	BeforeEach(func() {
+		start = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		cfg = backtest.Config{
+			BaseTicker:   "BTC",
+			QuoteTicker:  "USD",
+			Balances:     map[string]float64{"USD": 1000},
+			MakerFeeRate: 0.001,
+			TakerFeeRate: 0.002,
+		}
+	})
+
+	Context("data", func() {
+		It("should read candles from csv and walk them in order", func() {
+			candles, err := backtest.ReadCandlesCSV(strings.NewReader(
+				"start,open,high,low,close,volume\n" +
+					"1677628860,110,120,100,105,1\n" +
+					"1677628800,100,110,90,105,2\n",
+			))
+			Expect(err).To(BeNil())
+			Expect(candles).To(HaveLen(2))
+
+			ticks := backtest.CandleTicks(candles, time.Minute)
+			Expect(ticks).To(HaveLen(8))
+			Expect([]float64{ticks[0].Price, ticks[1].Price, ticks[2].Price, ticks[3].Price}).To(Equal([]float64{100, 90, 110, 105}))
+			Expect([]float64{ticks[4].Price, ticks[5].Price, ticks[6].Price, ticks[7].Price}).To(Equal([]float64{110, 120, 100, 105}))
+			Expect(ticks[1].Time).To(Equal(time.Unix(1677628800, 0).UTC().Add(15 * time.Second)))
+		})
+
+		It("should read trades from json", func() {
+			trades, err := backtest.ReadTradesJSON(strings.NewReader(
+				`[{"trade_id":"2","product_id":"BTC-USD","price":"101","size":"0.5","time":"2023-03-01T00:00:02Z","side":"SELL"},` +
+					`{"trade_id":"1","product_id":"BTC-USD","price":"100","size":"1","time":"2023-03-01T00:00:01Z","side":"BUY"}]`,
+			))
+			Expect(err).To(BeNil())
+
+			ticks := backtest.TradeTicks(trades)
+			Expect(ticks).To(HaveLen(2))
+			Expect(ticks[0].Price).To(Equal(100.0))
+			Expect(ticks[1].Price).To(Equal(101.0))
+		})
+	})
+
+	Context("exchange", func() {
+		It("should fill a resting buy as a maker when the price reaches it", func() {
+			ex, err := backtest.NewExchange(cfg, ticksFor(100, 95, 90))
+			Expect(err).To(BeNil())
+
+			order, err := ex.CreateLimitMarketOrder(ctx, &apiclient.CreateLimitMarketOrderParams{
+				ID: "buy", BaseTicker: "BTC", QuoteTicker: "USD", Price: 95, Quantity: 1, Side: apiclient.BuySideType,
+			})
+			Expect(err).To(BeNil())
+			Expect(order.GetStatus()).To(Equal(model.OPEN))
+
+			_, _, _, quote, err := ex.GetCurrentWallentAmount(ctx, apiclient.ProductID("BTC-USD"))
+			Expect(err).To(BeNil())
+			Expect(quote).To(BeNumerically("~", 1000-95*1.002, 1e-9))
+
+			Expect(ex.Advance()).To(BeTrue())
+
+			filled, err := ex.GetOrder(ctx, order.GetOrderId())
+			Expect(err).To(BeNil())
+			Expect(filled.GetStatus()).To(Equal(model.FILLED))
+			Expect(filled.GetAverageFilledPrice()).To(Equal(95.0))
+
+			fills, err := ex.GetOrderFills(ctx, order.GetOrderId(), "BTC-USD")
+			Expect(err).To(BeNil())
+			Expect(fills).To(HaveLen(1))
+			Expect(fills[0].GetLiquidityIndicator()).To(Equal("M"))
+			Expect(fills[0].GetCommission()).To(BeNumerically("~", 0.095, 1e-9))
+
+			_, _, base, quote, err := ex.GetCurrentWallentAmount(ctx, apiclient.ProductID("BTC-USD"))
+			Expect(err).To(BeNil())
+			Expect(base).To(Equal(1.0))
+			Expect(quote).To(BeNumerically("~", 1000-95-0.095, 1e-9))
+		})
+
+		It("should not place the same intent twice", func() {
+			ex, err := backtest.NewExchange(cfg, ticksFor(100))
+			Expect(err).To(BeNil())
+
+			params := &apiclient.CreateLimitMarketOrderParams{
+				ID: "dup", BaseTicker: "BTC", QuoteTicker: "USD", Price: 90, Quantity: 1, Side: apiclient.BuySideType,
+			}
+			first, err := ex.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			second, err := ex.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(second.GetOrderId()).To(Equal(first.GetOrderId()))
+		})
+
+		It("should reject orders it cannot fund", func() {
+			ex, err := backtest.NewExchange(cfg, ticksFor(100))
+			Expect(err).To(BeNil())
+
+			_, err = ex.CreateLimitMarketOrder(ctx, &apiclient.CreateLimitMarketOrderParams{
+				ID: "big", BaseTicker: "BTC", QuoteTicker: "USD", Price: 100, Quantity: 20, Side: apiclient.BuySideType,
+			})
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("insufficient USD"))
+		})
+
+		It("should trigger a stop limit when the price crosses the stop", func() {
+			cfg.Balances = map[string]float64{"BTC": 1}
+			ex, err := backtest.NewExchange(cfg, ticksFor(100, 98, 94))
+			Expect(err).To(BeNil())
+
+			order, err := ex.CreateStopLimitOrder(ctx, &apiclient.CreateStopLimitOrderParams{
+				ID: "stop", BaseTicker: "BTC", QuoteTicker: "USD", StopPrice: 95, LimitPrice: 94, Quantity: 1, Side: apiclient.SellSideType,
+			})
+			Expect(err).To(BeNil())
+
+			Expect(ex.Advance()).To(BeTrue())
+			open, err := ex.GetOrder(ctx, order.GetOrderId())
+			Expect(err).To(BeNil())
+			Expect(open.GetStatus()).To(Equal(model.OPEN))
+
+			Expect(ex.Advance()).To(BeTrue())
+			filled, err := ex.GetOrder(ctx, order.GetOrderId())
+			Expect(err).To(BeNil())
+			Expect(filled.GetStatus()).To(Equal(model.FILLED))
+			Expect(filled.GetAverageFilledPrice()).To(Equal(94.0))
+		})
+
+		It("should wait on the virtual clock instead of real time", func() {
+			ex, err := backtest.NewExchange(cfg, ticksFor(100, 99, 98, 97, 96))
+			Expect(err).To(BeNil())
+
+			order, err := ex.CreateLimitMarketOrder(ctx, &apiclient.CreateLimitMarketOrderParams{
+				ID: "wait", BaseTicker: "BTC", QuoteTicker: "USD", Price: 97, Quantity: 1, Side: apiclient.BuySideType,
+			})
+			Expect(err).To(BeNil())
+
+			began := time.Now()
+			Expect(ex.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), start.Add(time.Hour))).To(Succeed())
+			Expect(time.Since(began)).To(BeNumerically("<", time.Second))
+			Expect(ex.Clock().Now()).To(Equal(start.Add(3 * time.Minute)))
+
+			order, err = ex.CreateLimitMarketOrder(ctx, &apiclient.CreateLimitMarketOrderParams{
+				ID: "never", BaseTicker: "BTC", QuoteTicker: "USD", Price: 50, Quantity: 1, Side: apiclient.BuySideType,
+			})
+			Expect(err).To(BeNil())
+
+			err = ex.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), start.Add(3*time.Minute+30*time.Second))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("has timed out"))
+		})
+
+		It("should refuse orders while the kill switch is engaged", func() {
+			ex, err := backtest.NewExchange(cfg, ticksFor(100))
+			Expect(err).To(BeNil())
+			Expect(ex.EngageKillSwitch(ctx, true)).To(Succeed())
+
+			_, err = ex.CreateLimitMarketOrder(ctx, &apiclient.CreateLimitMarketOrderParams{
+				ID: "blocked", BaseTicker: "BTC", QuoteTicker: "USD", Price: 100, Quantity: 1, Side: apiclient.BuySideType,
+			})
+			Expect(err).To(Equal(apiclient.ErrKillSwitchEngaged))
+		})
+	})
+
+	Context("Run", func() {
+		It("should report the equity curve, drawdown and trade statistics", func() {
+			cfg.MakerFeeRate, cfg.TakerFeeRate = 0, 0
+			ex, err := backtest.NewExchange(cfg, ticksFor(100, 80, 90, 120, 110))
+			Expect(err).To(BeNil())
+
+			holding := false
+			report, err := backtest.Run(ctx, ex, func(ctx context.Context, client apiclient.ApiClient) error {
+				_, _, price, _, err := client.GetProductMarketData(ctx, apiclient.ProductID("BTC-USD"), nil)
+				if err != nil {
+					return err
+				}
+
+				side := apiclient.BuySideType
+				if holding {
+					side = apiclient.SellSideType
+				}
+				if (!holding && price != 100) || (holding && price < 120) {
+					return nil
+				}
+
+				_, err = client.CreateOrderAndWaitForCompletion(ctx, &apiclient.CreateLimitMarketOrderParams{
+					BaseTicker: "BTC", QuoteTicker: "USD", Price: price, Quantity: 1, Side: side,
+				}, ex.Clock().Now().Add(time.Minute))
+				holding = !holding
+
+				return err
+			})
+			Expect(err).To(BeNil())
+
+			Expect(report.EquityCurve).To(HaveLen(5))
+			Expect(report.StartEquity).To(Equal(1000.0))
+			Expect(report.EndEquity).To(Equal(1020.0))
+			Expect(report.TotalReturnPercentage).To(BeNumerically("~", 2, 1e-9))
+			Expect(report.MaxDrawdown).To(Equal(20.0))
+			Expect(report.MaxDrawdownPercentage).To(BeNumerically("~", 2, 1e-9))
+			Expect(report.Orders).To(Equal(2))
+			Expect(report.Buys).To(Equal(1))
+			Expect(report.Sells).To(Equal(1))
+			Expect(report.RealizedPnL).To(Equal(20.0))
+			Expect(report.Wins).To(Equal(1))
+			Expect(report.WinRate).To(Equal(100.0))
+		})
+	})
+})
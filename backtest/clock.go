@@ -0,0 +1,21 @@
+package backtest
+
+import (
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+)
+
+// VirtualClock only moves when the exchange moves to the next tick. Sleepers, After channels and tickers fire
+// as the ticks pass them so strategies run against the exchange keep time with the data
+type VirtualClock struct {
+	*apiclient.FakeClock
+}
+
+func NewVirtualClock(now time.Time) *VirtualClock {
+	return &VirtualClock{FakeClock: apiclient.NewFakeClock(now)}
+}
+
+func (c *VirtualClock) set(now time.Time) {
+	c.Advance(now.Sub(c.Now()))
+}
@@ -0,0 +1,270 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	coinbasegoclientv3 "github.com/happilymarrieddad/coinbase-go-client-v3"
+)
+
+// Tick is one price the simulated exchange moves to
+type Tick struct {
+	Time  time.Time
+	Price float64
+}
+
+type Candle struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+type Trade struct {
+	Time  time.Time
+	Price float64
+	Size  float64
+	Side  string
+}
+
+// CandleTicks walks every candle open -> low -> high -> close for green candles and open -> high -> low -> close
+// for red ones, which is the usual assumption when only candles are available
+func CandleTicks(candles []Candle, interval time.Duration) []Tick {
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Start.Before(candles[j].Start) })
+
+	ticks := make([]Tick, 0, len(candles)*4)
+	step := interval / 4
+
+	for _, candle := range candles {
+		prices := []float64{candle.Open, candle.High, candle.Low, candle.Close}
+		if candle.Close >= candle.Open {
+			prices[1], prices[2] = candle.Low, candle.High
+		}
+
+		for idx, price := range prices {
+			ticks = append(ticks, Tick{Time: candle.Start.Add(step * time.Duration(idx)), Price: price})
+		}
+	}
+
+	return ticks
+}
+
+func TradeTicks(trades []Trade) []Tick {
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Time.Before(trades[j].Time) })
+
+	ticks := make([]Tick, len(trades))
+	for idx, trade := range trades {
+		ticks[idx] = Tick{Time: trade.Time, Price: trade.Price}
+	}
+
+	return ticks
+}
+
+// LoadCandleFile reads .csv files with ReadCandlesCSV and .json files with ReadCandlesJSON
+func LoadCandleFile(path string) ([]Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCandlesCSV(file)
+	case ".json":
+		return ReadCandlesJSON(file)
+	}
+
+	return nil, fmt.Errorf("unknown candle file type '%s'", path)
+}
+
+// LoadTradeFile reads .csv files with ReadTradesCSV and .json files with ReadTradesJSON
+func LoadTradeFile(path string) ([]Trade, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadTradesCSV(file)
+	case ".json":
+		return ReadTradesJSON(file)
+	}
+
+	return nil, fmt.Errorf("unknown trade file type '%s'", path)
+}
+
+// ReadCandlesCSV expects a header of start,open,high,low,close,volume. start is unix seconds or RFC3339
+func ReadCandlesCSV(r io.Reader) ([]Candle, error) {
+	records, err := readCSV(r, "start", "open", "high", "low", "close", "volume")
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, 0, len(records))
+	for _, record := range records {
+		var candle Candle
+		if candle.Start, err = parseTime(record["start"]); err != nil {
+			return nil, err
+		}
+		if candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, err = parseCandle(
+			record["open"], record["high"], record["low"], record["close"], record["volume"],
+		); err != nil {
+			return nil, err
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// ReadCandlesJSON reads a json array of candles as returned by GetProductCandles
+func ReadCandlesJSON(r io.Reader) ([]Candle, error) {
+	raw := []coinbasegoclientv3.ProductCandle{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, 0, len(raw))
+	for _, rc := range raw {
+		var (
+			candle Candle
+			err    error
+		)
+		if candle.Start, err = parseTime(rc.Start); err != nil {
+			return nil, err
+		}
+		if candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, err = parseCandle(
+			rc.Open, rc.High, rc.Low, rc.Close, rc.Volume,
+		); err != nil {
+			return nil, err
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// ReadTradesCSV expects a header of time,price,size,side. time is unix seconds or RFC3339
+func ReadTradesCSV(r io.Reader) ([]Trade, error) {
+	records, err := readCSV(r, "time", "price", "size", "side")
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(records))
+	for _, record := range records {
+		trade := Trade{Side: record["side"]}
+		if trade.Time, err = parseTime(record["time"]); err != nil {
+			return nil, err
+		}
+		if trade.Price, err = strconv.ParseFloat(record["price"], 64); err != nil {
+			return nil, err
+		}
+		if trade.Size, err = strconv.ParseFloat(record["size"], 64); err != nil {
+			return nil, err
+		}
+
+		trades = append(trades, trade)
+	}
+
+	return trades, nil
+}
+
+// ReadTradesJSON reads a json array of trades as returned by GetMarketTrades
+func ReadTradesJSON(r io.Reader) ([]Trade, error) {
+	raw := []coinbasegoclientv3.MarketTrade{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(raw))
+	for _, rt := range raw {
+		trade := Trade{Side: string(rt.Side)}
+
+		var err error
+		if trade.Time, err = parseTime(rt.Time); err != nil {
+			return nil, err
+		}
+		if trade.Price, err = strconv.ParseFloat(rt.Price, 64); err != nil {
+			return nil, err
+		}
+		if trade.Size, err = strconv.ParseFloat(rt.Size, 64); err != nil {
+			return nil, err
+		}
+
+		trades = append(trades, trade)
+	}
+
+	return trades, nil
+}
+
+func readCSV(r io.Reader, columns ...string) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]int)
+	for idx, name := range header {
+		indexes[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	for _, column := range columns {
+		if _, exists := indexes[column]; !exists {
+			return nil, fmt.Errorf("missing column '%s'", column)
+		}
+	}
+
+	records := []map[string]string{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]string, len(columns))
+		for _, column := range columns {
+			record[column] = row[indexes[column]]
+		}
+		records = append(records, record)
+	}
+}
+
+func parseCandle(open, high, low, close, volume string) (o, h, l, c, v float64, err error) {
+	values := []*float64{&o, &h, &l, &c, &v}
+	for idx, str := range []string{open, high, low, close, volume} {
+		if *values[idx], err = strconv.ParseFloat(str, 64); err != nil {
+			return 0, 0, 0, 0, 0, err
+		}
+	}
+
+	return o, h, l, c, v, nil
+}
+
+func parseTime(str string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, str)
+}
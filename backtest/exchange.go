@@ -0,0 +1,876 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+)
+
+var ErrEndOfData = errors.New("backtest has no more data")
+
+type Config struct {
+	BaseTicker  string `validate:"required"`
+	QuoteTicker string `validate:"required"`
+	// Balances are the starting available balances by currency
+	Balances     map[string]float64
+	MakerFeeRate float64
+	TakerFeeRate float64
+	// BaseIncrement and QuoteIncrement round sizes and prices down. 0 leaves them alone
+	BaseIncrement  float64
+	QuoteIncrement float64
+	BaseMinSize    float64
+}
+
+type EquityPoint struct {
+	Time  time.Time
+	Price float64
+	// Equity is the quote balance plus the base balance at Price, holds included
+	Equity float64
+}
+
+type simOrder struct {
+	order     cbadvmodel.Order
+	side      apiclient.CreateLimitMarketOrderParams
+	price     float64
+	stopPrice float64
+	size      float64
+	// triggered is always true for plain limit orders
+	triggered bool
+	hold      float64
+}
+
+// Exchange is a single product exchange that implements apiclient.ApiClient against historical ticks. Orders
+// fill completely once the price reaches them. Resting orders pay the maker fee and orders that cross on arrival
+// or on a stop trigger pay the taker fee
+type Exchange struct {
+	cfg    Config
+	clock  *VirtualClock
+	ticks  []Tick
+	cursor int
+
+	mutex      *sync.Mutex
+	available  map[string]float64
+	hold       map[string]float64
+	orders     []*simOrder
+	fills      []cbadvmodel.OrderFill
+	equity     []EquityPoint
+	killSwitch bool
+	sequence   int
+
+	// average cost of the base position for trade statistics
+	position     float64
+	averageCost  float64
+	realizedPnL  float64
+	wins, losses int
+	fees, volume float64
+}
+
+var _ apiclient.ApiClient = (*Exchange)(nil)
+
+// NewExchange starts on the first tick
+func NewExchange(cfg Config, ticks []Tick) (*Exchange, error) {
+	if err := utils.Validate(&cfg); err != nil {
+		return nil, err
+	} else if len(ticks) == 0 {
+		return nil, errors.New("at least one tick is required")
+	}
+
+	e := &Exchange{
+		cfg:       cfg,
+		clock:     NewVirtualClock(ticks[0].Time),
+		ticks:     ticks,
+		mutex:     &sync.Mutex{},
+		available: make(map[string]float64),
+		hold:      make(map[string]float64),
+	}
+	for currency, amount := range cfg.Balances {
+		e.available[currency] = amount
+	}
+	e.recordEquity()
+
+	return e, nil
+}
+
+func (e *Exchange) Clock() apiclient.Clock {
+	return e.clock
+}
+
+func (e *Exchange) Price() float64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.price()
+}
+
+// Advance moves to the next tick and fills every order the price reached. It returns false once the data runs out
+func (e *Exchange) Advance() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.advance()
+}
+
+func (e *Exchange) EquityCurve() []EquityPoint {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	curve := make([]EquityPoint, len(e.equity))
+	copy(curve, e.equity)
+
+	return curve
+}
+
+func (e *Exchange) CreateOrderAndWaitForCompletion(
+	ctx context.Context, params *apiclient.CreateLimitMarketOrderParams, timeout time.Time,
+) (string, error) {
+	order, err := e.CreateLimitMarketOrder(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	return order.GetOrderId(), e.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), timeout)
+}
+
+func (e *Exchange) GetCurrentWallentAmount(
+	ctx context.Context, productID apiclient.ProductID,
+) (baseAccount, quoteAccount *cbadvmodel.Account, baseAmount, quoteAmount float64, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	baseAccount, quoteAccount = e.account(productID.Base()), e.account(productID.Quote())
+	return baseAccount, quoteAccount, e.available[productID.Base()], e.available[productID.Quote()], nil
+}
+
+func (e *Exchange) GetProduct(ctx context.Context, productID apiclient.ProductID) (*cbadvmodel.GetProductResponse, error) {
+	if err := e.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	_, _, change := e.window()
+
+	return &cbadvmodel.GetProductResponse{
+		ProductId:                utils.StringToPtr(e.productID().String()),
+		Price:                    utils.Float64ToFloat64Ptr(e.price()),
+		PricePercentageChange24h: utils.Float64ToFloat64Ptr(change),
+		BaseIncrement:            utils.Float64ToFloat64Ptr(e.cfg.BaseIncrement),
+		QuoteIncrement:           utils.Float64ToFloat64Ptr(e.cfg.QuoteIncrement),
+		BaseMinSize:              utils.Float64ToFloat64Ptr(e.cfg.BaseMinSize),
+		Status:                   utils.StringToPtr("online"),
+	}, nil
+}
+
+// GetProductMarketData uses the ticks from the last 24 hours of virtual time
+func (e *Exchange) GetProductMarketData(
+	ctx context.Context, productID apiclient.ProductID, pricePercentageChange24h *float64,
+) (highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage float64, err error) {
+	if err = e.checkProduct(productID); err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	high, low, change := e.window()
+	if pricePercentageChange24h != nil && math.Abs(change) <= *pricePercentageChange24h {
+		return 0, 0, 0, 0, fmt.Errorf("perc 24hr change less than %f%%", *pricePercentageChange24h)
+	}
+
+	return high, low, e.price(), change, nil
+}
+
+func (e *Exchange) CreateLimitMarketOrder(ctx context.Context, params *apiclient.CreateLimitMarketOrderParams) (*cbadvmodel.Order, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	return e.create(params, params.Price, 0)
+}
+
+func (e *Exchange) CreateStopLimitOrder(ctx context.Context, params *apiclient.CreateStopLimitOrderParams) (*cbadvmodel.Order, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	return e.create(&apiclient.CreateLimitMarketOrderParams{
+		ID:          params.ID,
+		BaseTicker:  params.BaseTicker,
+		QuoteTicker: params.QuoteTicker,
+		Price:       params.LimitPrice,
+		Quantity:    params.Quantity,
+		Side:        params.Side,
+	}, params.LimitPrice, params.StopPrice)
+}
+
+// ValidateOrder runs the same checks as CreateLimitMarketOrder without placing anything
+func (e *Exchange) ValidateOrder(ctx context.Context, params *apiclient.CreateLimitMarketOrderParams) error {
+	if err := utils.Validate(params); err != nil {
+		return err
+	} else if err := e.checkProduct(params.ProductID()); err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.killSwitch {
+		return apiclient.ErrKillSwitchEngaged
+	}
+
+	_, _, _, _, err := e.validate(params, params.Price)
+	return err
+}
+
+// GetProductCatalog only lists the product being backtested
+func (e *Exchange) GetProductCatalog(ctx context.Context) (*apiclient.ProductCatalog, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return apiclient.NewProductCatalog(apiclient.CatalogProduct{
+		ID:             e.productID(),
+		Price:          e.price(),
+		BaseIncrement:  e.cfg.BaseIncrement,
+		QuoteIncrement: e.cfg.QuoteIncrement,
+		BaseMinSize:    e.cfg.BaseMinSize,
+	}), nil
+}
+
+// GetBestBidAsk quotes both sides at the current price with unlimited size since every order fills completely
+func (e *Exchange) GetBestBidAsk(ctx context.Context, productIDs ...apiclient.ProductID) (map[apiclient.ProductID]apiclient.TopOfBook, error) {
+	for _, productID := range productIDs {
+		if err := e.checkProduct(productID); err != nil {
+			return nil, err
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	books := make(map[apiclient.ProductID]apiclient.TopOfBook, len(productIDs))
+	for _, productID := range productIDs {
+		books[productID] = apiclient.TopOfBook{
+			ProductID: productID,
+			BidPrice:  e.price(),
+			BidSize:   math.MaxFloat64,
+			AskPrice:  e.price(),
+			AskSize:   math.MaxFloat64,
+			Time:      e.clock.Now(),
+		}
+	}
+
+	return books, nil
+}
+
+// VerifyMarketOrderCompletion moves through the data until the order fills or the virtual clock passes timeout
+func (e *Exchange) VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for {
+		sim, err := e.find(orderID)
+		if err != nil {
+			return err
+		}
+
+		switch sim.order.GetStatus() {
+		case cbadvmodel.FILLED:
+			return nil
+		case cbadvmodel.CANCELLED:
+			return errors.New("order cancelled")
+		}
+
+		if e.clock.Now().After(timeout) {
+			return fmt.Errorf("market order '%s' has timed out", orderID)
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		} else if !e.advance() {
+			return ErrEndOfData
+		}
+	}
+}
+
+func (e *Exchange) GetOrder(ctx context.Context, orderID string) (*cbadvmodel.Order, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	sim, err := e.find(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := sim.order
+	return &order, nil
+}
+
+func (e *Exchange) GetOrderByClientOrderID(ctx context.Context, productID apiclient.ProductID, clientOrderID string) (*cbadvmodel.Order, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, sim := range e.orders {
+		if sim.order.GetClientOrderId() == clientOrderID {
+			order := sim.order
+			return &order, nil
+		}
+	}
+
+	return nil, apiclient.ErrOrderNotFound
+}
+
+func (e *Exchange) GetOpenOrdersByProductIDAndSide(ctx context.Context, productID apiclient.ProductID, side cbadvmodel.OrderSide) ([]cbadvmodel.Order, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	orders := []cbadvmodel.Order{}
+	for _, sim := range e.orders {
+		if sim.order.GetStatus() != cbadvmodel.OPEN {
+			continue
+		} else if side != cbadvmodel.UNKNOWN_ORDER_SIDE && sim.order.GetSide() != string(side) {
+			continue
+		} else if productID != "" && productID != e.productID() {
+			continue
+		}
+
+		orders = append(orders, sim.order)
+	}
+
+	return orders, nil
+}
+
+func (e *Exchange) GetOrderFills(ctx context.Context, orderID string, productID apiclient.ProductID) ([]cbadvmodel.OrderFill, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	fills := []cbadvmodel.OrderFill{}
+	for _, fill := range e.fills {
+		if fill.GetOrderId() == orderID {
+			fills = append(fills, fill)
+		}
+	}
+
+	return fills, nil
+}
+
+func (e *Exchange) GetFillsByTimeRange(ctx context.Context, productID apiclient.ProductID, start, end time.Time) ([]cbadvmodel.OrderFill, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	fills := []cbadvmodel.OrderFill{}
+	for _, fill := range e.fills {
+		if tm, _ := time.Parse(time.RFC3339Nano, fill.GetTradeTime()); inRange(tm, start, end) {
+			fills = append(fills, fill)
+		}
+	}
+
+	return fills, nil
+}
+
+func (e *Exchange) GetOrdersByTimeRange(ctx context.Context, productID apiclient.ProductID, start, end time.Time) ([]cbadvmodel.Order, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	orders := []cbadvmodel.Order{}
+	for _, sim := range e.orders {
+		if tm, _ := time.Parse(time.RFC3339Nano, sim.order.GetCreatedTime()); inRange(tm, start, end) {
+			orders = append(orders, sim.order)
+		}
+	}
+
+	return orders, nil
+}
+
+// EachFillPage hands every fill to fn as one page
+func (e *Exchange) EachFillPage(
+	ctx context.Context, productID apiclient.ProductID, start, end time.Time, fn func(fills []cbadvmodel.OrderFill) error,
+) error {
+	fills, err := e.GetFillsByTimeRange(ctx, productID, start, end)
+	if err != nil {
+		return err
+	}
+
+	return fn(fills)
+}
+
+// EachOrderPage hands every order to fn as one page
+func (e *Exchange) EachOrderPage(
+	ctx context.Context, productID apiclient.ProductID, start, end time.Time, fn func(orders []cbadvmodel.Order) error,
+) error {
+	orders, err := e.GetOrdersByTimeRange(ctx, productID, start, end)
+	if err != nil {
+		return err
+	}
+
+	return fn(orders)
+}
+
+func (e *Exchange) GetAccounts(ctx context.Context) ([]cbadvmodel.Account, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	currencies := []string{}
+	for currency := range e.available {
+		currencies = append(currencies, currency)
+	}
+	for currency := range e.hold {
+		if _, exists := e.available[currency]; !exists {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	accounts := []cbadvmodel.Account{}
+	for _, currency := range currencies {
+		accounts = append(accounts, *e.account(currency))
+	}
+
+	return accounts, nil
+}
+
+func (e *Exchange) CancelOrders(ctx context.Context, orderIds ...string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, orderID := range orderIds {
+		sim, err := e.find(orderID)
+		if err != nil {
+			return err
+		} else if sim.order.GetStatus() != cbadvmodel.OPEN {
+			return fmt.Errorf("order '%s' is %s", orderID, sim.order.GetStatus())
+		}
+
+		e.cancel(sim)
+	}
+
+	return nil
+}
+
+func (e *Exchange) CancelExistingOrders(ctx context.Context, id string, productID apiclient.ProductID, orderType cbadvmodel.OrderType) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, sim := range e.orders {
+		if sim.order.GetStatus() == cbadvmodel.OPEN && strings.Contains(sim.order.GetClientOrderId(), id) {
+			e.cancel(sim)
+		}
+	}
+
+	return nil
+}
+
+// AmendOrder edits the order in place
+func (e *Exchange) AmendOrder(ctx context.Context, params *apiclient.AmendOrderParams) (*apiclient.AmendOrderResult, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	sim, err := e.find(params.OrderID)
+	if err != nil {
+		return nil, err
+	} else if sim.order.GetStatus() != cbadvmodel.OPEN {
+		return nil, fmt.Errorf("order '%s' is %s", params.OrderID, sim.order.GetStatus())
+	}
+
+	price, size := sim.price, sim.size
+	if params.Price > 0 {
+		price = params.Price
+	}
+	if params.Quantity > 0 {
+		size = params.Quantity
+	}
+
+	currency, hold := e.holdFor(sim.side.Side, price, size)
+	if e.available[currency]+sim.hold < hold {
+		return nil, fmt.Errorf("insufficient %s to amend order '%s'", currency, params.OrderID)
+	}
+
+	e.release(sim)
+	sim.price, sim.size = price, size
+	e.reserve(sim, currency, hold)
+	sim.order.OrderConfiguration = orderConfiguration(sim)
+
+	if sim.triggered && e.crosses(sim, e.price()) {
+		e.fill(sim, e.price(), false)
+	}
+
+	order := sim.order
+	return &apiclient.AmendOrderResult{
+		OriginalOrderID: params.OrderID,
+		OrderID:         params.OrderID,
+		FilledQuantity:  order.GetFilledSize(),
+		Order:           &order,
+	}, nil
+}
+
+func (e *Exchange) Recover(ctx context.Context) ([]apiclient.JournalEntry, error) {
+	return []apiclient.JournalEntry{}, nil
+}
+
+func (e *Exchange) EngageKillSwitch(ctx context.Context, cancelOpenOrders bool) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.killSwitch = true
+	if cancelOpenOrders {
+		for _, sim := range e.orders {
+			if sim.order.GetStatus() == cbadvmodel.OPEN {
+				e.cancel(sim)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Exchange) ReleaseKillSwitch() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.killSwitch = false
+}
+
+func (e *Exchange) GetFeeTier(ctx context.Context) (*apiclient.FeeTier, error) {
+	return &apiclient.FeeTier{PricingTier: "backtest", MakerFeeRate: e.cfg.MakerFeeRate, TakerFeeRate: e.cfg.TakerFeeRate}, nil
+}
+
+func (e *Exchange) create(params *apiclient.CreateLimitMarketOrderParams, price, stopPrice float64) (*cbadvmodel.Order, error) {
+	if err := e.checkProduct(params.ProductID()); err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.killSwitch {
+		return nil, apiclient.ErrKillSwitchEngaged
+	}
+
+	if params.ID == "" {
+		params.ID = fmt.Sprintf("backtest-%d", e.sequence)
+	}
+
+	// the same intent never places twice just like coinbase
+	coid := params.ClientOrderID()
+	for _, sim := range e.orders {
+		if sim.order.GetClientOrderId() == coid {
+			order := sim.order
+			return &order, nil
+		}
+	}
+
+	size, price, currency, hold, err := e.validate(params, price)
+	if err != nil {
+		return nil, err
+	}
+
+	e.sequence++
+	sim := &simOrder{side: *params, price: price, stopPrice: stopPrice, size: size, triggered: stopPrice == 0}
+
+	status, orderType := cbadvmodel.OPEN, "LIMIT"
+	if stopPrice > 0 {
+		orderType = "STOP_LIMIT"
+	}
+	sim.order = cbadvmodel.Order{
+		OrderId:            utils.StringToPtr(fmt.Sprintf("order-%d", e.sequence)),
+		ClientOrderId:      utils.StringToPtr(coid),
+		ProductId:          utils.StringToPtr(e.productID().String()),
+		Side:               utils.StringToPtr(string(params.Side)),
+		Status:             &status,
+		OrderType:          utils.StringToPtr(orderType),
+		CreatedTime:        utils.StringToPtr(e.clock.Now().Format(time.RFC3339Nano)),
+		FilledSize:         utils.Float64ToFloat64Ptr(0),
+		AverageFilledPrice: utils.Float64ToFloat64Ptr(0),
+		FilledValue:        utils.Float64ToFloat64Ptr(0),
+		TotalFees:          utils.Float64ToFloat64Ptr(0),
+	}
+	sim.order.OrderConfiguration = orderConfiguration(sim)
+
+	e.reserve(sim, currency, hold)
+	e.orders = append(e.orders, sim)
+
+	// marketable on arrival so it takes liquidity
+	if sim.triggered && e.crosses(sim, e.price()) {
+		e.fill(sim, e.price(), false)
+	}
+
+	order := sim.order
+	return &order, nil
+}
+
+// validate rounds to the configured increments and checks the balance. It must be called with the mutex held
+func (e *Exchange) validate(
+	params *apiclient.CreateLimitMarketOrderParams, price float64,
+) (size, rounded float64, currency string, hold float64, err error) {
+	size = params.Quantity
+	if e.cfg.BaseIncrement > 0 {
+		size = utils.FloorToIncrement(size, e.cfg.BaseIncrement)
+	}
+	if e.cfg.QuoteIncrement > 0 {
+		price = utils.FloorToIncrement(price, e.cfg.QuoteIncrement)
+	}
+	if size <= 0 || size < e.cfg.BaseMinSize {
+		return 0, 0, "", 0, fmt.Errorf("size %f is below the minimum %f", size, e.cfg.BaseMinSize)
+	} else if price <= 0 {
+		return 0, 0, "", 0, fmt.Errorf("invalid price %f", price)
+	}
+
+	currency, hold = e.holdFor(params.Side, price, size)
+	if e.available[currency] < hold {
+		return 0, 0, "", 0, fmt.Errorf("insufficient %s: need %f but only %f is available", currency, hold, e.available[currency])
+	}
+
+	return size, price, currency, hold, nil
+}
+
+// advance must be called with the mutex held
+func (e *Exchange) advance() bool {
+	if e.cursor+1 >= len(e.ticks) {
+		return false
+	}
+
+	e.cursor++
+	e.clock.set(e.ticks[e.cursor].Time)
+	price := e.price()
+
+	for _, sim := range e.orders {
+		if sim.order.GetStatus() != cbadvmodel.OPEN {
+			continue
+		}
+
+		if !sim.triggered {
+			sell := sim.side.Side == apiclient.SellSideType
+			if (sell && price <= sim.stopPrice) || (!sell && price >= sim.stopPrice) {
+				sim.triggered = true
+				if e.crosses(sim, price) {
+					e.fill(sim, price, false)
+				}
+			}
+			continue
+		}
+
+		if e.crosses(sim, price) {
+			e.fill(sim, sim.price, true)
+		}
+	}
+
+	e.recordEquity()
+
+	return true
+}
+
+func (e *Exchange) crosses(sim *simOrder, price float64) bool {
+	if sim.side.Side == apiclient.BuySideType {
+		return price <= sim.price
+	}
+
+	return price >= sim.price
+}
+
+func (e *Exchange) fill(sim *simOrder, price float64, maker bool) {
+	feeRate := e.cfg.TakerFeeRate
+	liquidity := "T"
+	if maker {
+		feeRate, liquidity = e.cfg.MakerFeeRate, "M"
+	}
+
+	notional := price * sim.size
+	fee := notional * feeRate
+
+	e.release(sim)
+	if sim.side.Side == apiclient.BuySideType {
+		e.available[e.cfg.QuoteTicker] -= notional + fee
+		e.available[e.cfg.BaseTicker] += sim.size
+		e.track(sim.size, price)
+	} else {
+		e.available[e.cfg.BaseTicker] -= sim.size
+		e.available[e.cfg.QuoteTicker] += notional - fee
+		e.track(-sim.size, price)
+	}
+	e.fees += fee
+	e.volume += notional
+
+	status := cbadvmodel.FILLED
+	sim.order.Status = &status
+	sim.order.FilledSize = utils.Float64ToFloat64Ptr(sim.size)
+	sim.order.AverageFilledPrice = utils.Float64ToFloat64Ptr(price)
+	sim.order.FilledValue = utils.Float64ToFloat64Ptr(notional)
+	sim.order.TotalFees = utils.Float64ToFloat64Ptr(fee)
+	sim.order.CompletionPercentage = utils.Float64ToFloat64Ptr(100)
+
+	e.fills = append(e.fills, cbadvmodel.OrderFill{
+		EntryId:            utils.StringToPtr(fmt.Sprintf("fill-%d", len(e.fills)+1)),
+		TradeId:            utils.StringToPtr(fmt.Sprintf("trade-%d", len(e.fills)+1)),
+		OrderId:            sim.order.OrderId,
+		ProductId:          sim.order.ProductId,
+		Side:               sim.order.Side,
+		TradeTime:          utils.StringToPtr(e.clock.Now().Format(time.RFC3339Nano)),
+		Price:              utils.Float64ToFloat64Ptr(price),
+		Size:               utils.Float64ToFloat64Ptr(sim.size),
+		Commission:         utils.Float64ToFloat64Ptr(fee),
+		LiquidityIndicator: utils.StringToPtr(liquidity),
+	})
+}
+
+// track keeps an average cost for the base position so closing trades can be scored
+func (e *Exchange) track(size, price float64) {
+	if e.position == 0 || math.Signbit(e.position) == math.Signbit(size) {
+		e.averageCost = (e.averageCost*math.Abs(e.position) + price*math.Abs(size)) / (math.Abs(e.position) + math.Abs(size))
+		e.position += size
+		return
+	}
+
+	closed := math.Min(math.Abs(size), math.Abs(e.position))
+	pnl := closed * (price - e.averageCost)
+	if e.position < 0 {
+		pnl = -pnl
+	}
+
+	e.realizedPnL += pnl
+	if pnl > 0 {
+		e.wins++
+	} else {
+		e.losses++
+	}
+
+	remaining := e.position + size
+	if math.Abs(remaining) < 1e-12 {
+		e.position, e.averageCost = 0, 0
+	} else if math.Signbit(remaining) != math.Signbit(e.position) {
+		e.position, e.averageCost = remaining, price
+	} else {
+		e.position = remaining
+	}
+}
+
+func (e *Exchange) cancel(sim *simOrder) {
+	e.release(sim)
+
+	status := cbadvmodel.CANCELLED
+	sim.order.Status = &status
+}
+
+// holdFor is what an order locks up. Buys hold enough to pay the taker fee in case they cross
+func (e *Exchange) holdFor(side interface{}, price, size float64) (string, float64) {
+	if side == apiclient.BuySideType {
+		return e.cfg.QuoteTicker, price * size * (1 + e.cfg.TakerFeeRate)
+	}
+
+	return e.cfg.BaseTicker, size
+}
+
+func (e *Exchange) reserve(sim *simOrder, currency string, amount float64) {
+	e.available[currency] -= amount
+	e.hold[currency] += amount
+	sim.hold = amount
+}
+
+func (e *Exchange) release(sim *simOrder) {
+	currency, _ := e.holdFor(sim.side.Side, sim.price, sim.size)
+	e.available[currency] += sim.hold
+	e.hold[currency] -= sim.hold
+	sim.hold = 0
+}
+
+func (e *Exchange) find(orderID string) (*simOrder, error) {
+	for _, sim := range e.orders {
+		if sim.order.GetOrderId() == orderID {
+			return sim, nil
+		}
+	}
+
+	return nil, apiclient.ErrOrderNotFound
+}
+
+func (e *Exchange) account(currency string) *cbadvmodel.Account {
+	return &cbadvmodel.Account{
+		Uuid:             utils.StringToPtr("backtest-" + strings.ToLower(currency)),
+		Name:             utils.StringToPtr(currency + " Wallet"),
+		Currency:         utils.StringToPtr(currency),
+		AvailableBalance: &cbadvmodel.AccountAvailableBalance{Value: utils.Float64ToFloat64Ptr(e.available[currency])},
+		Hold:             &cbadvmodel.AccountAvailableBalance{Value: utils.Float64ToFloat64Ptr(e.hold[currency])},
+	}
+}
+
+func (e *Exchange) recordEquity() {
+	price := e.price()
+	base := e.available[e.cfg.BaseTicker] + e.hold[e.cfg.BaseTicker]
+	quote := e.available[e.cfg.QuoteTicker] + e.hold[e.cfg.QuoteTicker]
+
+	e.equity = append(e.equity, EquityPoint{Time: e.clock.Now(), Price: price, Equity: quote + base*price})
+}
+
+// window returns the high, low and percentage change over the last 24 hours of ticks
+func (e *Exchange) window() (high, low, change float64) {
+	now := e.ticks[e.cursor].Time
+	high, low = e.ticks[e.cursor].Price, e.ticks[e.cursor].Price
+	first := e.ticks[e.cursor].Price
+
+	for idx := e.cursor; idx >= 0 && now.Sub(e.ticks[idx].Time) <= 24*time.Hour; idx-- {
+		price := e.ticks[idx].Price
+		high, low, first = math.Max(high, price), math.Min(low, price), price
+	}
+
+	if first > 0 {
+		change = (e.price() - first) / first * 100
+	}
+
+	return high, low, change
+}
+
+func (e *Exchange) price() float64 {
+	return e.ticks[e.cursor].Price
+}
+
+func (e *Exchange) productID() apiclient.ProductID {
+	return apiclient.NewProductID(e.cfg.BaseTicker, e.cfg.QuoteTicker)
+}
+
+func (e *Exchange) checkProduct(productID apiclient.ProductID) error {
+	if productID != e.productID() {
+		return fmt.Errorf("backtest only trades %s", e.productID())
+	}
+
+	return nil
+}
+
+func orderConfiguration(sim *simOrder) *cbadvmodel.OutputOrderConfiguration {
+	if sim.stopPrice > 0 {
+		direction := cbadvmodel.STOP_DIRECTION_STOP_DOWN
+		if sim.side.Side == apiclient.BuySideType {
+			direction = cbadvmodel.STOP_DIRECTION_STOP_UP
+		}
+
+		return &cbadvmodel.OutputOrderConfiguration{
+			StopLimitStopLimitGtc: &cbadvmodel.OutputOrderConfigurationStopLimitStopLimitGtc{
+				BaseSize:      utils.Float64ToFloat64Ptr(sim.size),
+				LimitPrice:    utils.Float64ToFloat64Ptr(sim.price),
+				StopPrice:     utils.Float64ToFloat64Ptr(sim.stopPrice),
+				StopDirection: utils.StringToPtr(string(direction)),
+			},
+		}
+	}
+
+	return &cbadvmodel.OutputOrderConfiguration{
+		LimitLimitGtc: &cbadvmodel.OutputOrderConfigurationLimitLimitGtc{
+			BaseSize:   utils.Float64ToFloat64Ptr(sim.size),
+			LimitPrice: utils.Float64ToFloat64Ptr(sim.price),
+		},
+	}
+}
+
+func inRange(tm, start, end time.Time) bool {
+	return (start.IsZero() || !tm.Before(start)) && (end.IsZero() || !tm.After(end))
+}
@@ -0,0 +1,109 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+)
+
+// Strategy is called once per tick with the simulated exchange as its client
+type Strategy func(ctx context.Context, client apiclient.ApiClient) error
+
+type Report struct {
+	EquityCurve           []EquityPoint
+	StartEquity           float64
+	EndEquity             float64
+	TotalReturnPercentage float64
+	MaxDrawdown           float64
+	MaxDrawdownPercentage float64
+	Orders                int
+	Fills                 int
+	Buys                  int
+	Sells                 int
+	Volume                float64
+	Fees                  float64
+	// RealizedPnL, Wins and Losses score closing trades against the average cost of the position. Fees are
+	// not included so they can be compared against Fees directly
+	RealizedPnL float64
+	Wins        int
+	Losses      int
+	WinRate     float64
+}
+
+// Run replays every tick through strategy and builds the report. A strategy error stops the run and is returned
+// with the report so far
+func Run(ctx context.Context, ex *Exchange, strategy Strategy) (*Report, error) {
+	if ex == nil || strategy == nil {
+		return nil, errors.New("exchange and strategy are required")
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return ex.Report(), err
+		}
+
+		if err := strategy(ctx, ex); err != nil {
+			if errors.Is(err, ErrEndOfData) {
+				return ex.Report(), nil
+			}
+			return ex.Report(), err
+		}
+
+		if !ex.Advance() {
+			return ex.Report(), nil
+		}
+	}
+}
+
+// Report summarizes the run up to the current tick
+func (e *Exchange) Report() *Report {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	report := &Report{
+		EquityCurve: make([]EquityPoint, len(e.equity)),
+		Orders:      len(e.orders),
+		Fills:       len(e.fills),
+		Volume:      e.volume,
+		Fees:        e.fees,
+		RealizedPnL: e.realizedPnL,
+		Wins:        e.wins,
+		Losses:      e.losses,
+	}
+	copy(report.EquityCurve, e.equity)
+
+	for _, fill := range e.fills {
+		if fill.GetSide() == "BUY" {
+			report.Buys++
+		} else {
+			report.Sells++
+		}
+	}
+
+	if e.wins+e.losses > 0 {
+		report.WinRate = float64(e.wins) / float64(e.wins+e.losses) * 100
+	}
+
+	if len(e.equity) > 0 {
+		report.StartEquity = e.equity[0].Equity
+		report.EndEquity = e.equity[len(e.equity)-1].Equity
+		if report.StartEquity != 0 {
+			report.TotalReturnPercentage = (report.EndEquity - report.StartEquity) / report.StartEquity * 100
+		}
+	}
+
+	peak := math.Inf(-1)
+	for _, point := range e.equity {
+		peak = math.Max(peak, point.Equity)
+		if drawdown := peak - point.Equity; drawdown > report.MaxDrawdown {
+			report.MaxDrawdown = drawdown
+			if peak > 0 {
+				report.MaxDrawdownPercentage = drawdown / peak * 100
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,71 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TopOfBook is the best resting price and size on each side of a product. Size is in the base currency
+type TopOfBook struct {
+	ProductID ProductID
+	BidPrice  float64
+	BidSize   float64
+	AskPrice  float64
+	AskSize   float64
+	Time      time.Time
+}
+
+type bookLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+type bestBidAskResponse struct {
+	Pricebooks []struct {
+		ProductID string      `json:"product_id"`
+		Bids      []bookLevel `json:"bids"`
+		Asks      []bookLevel `json:"asks"`
+		Time      time.Time   `json:"time"`
+	} `json:"pricebooks"`
+}
+
+// GetBestBidAsk reads the top of book for every product in one request. A side with nothing resting is left at 0
+func (c *apiclient) GetBestBidAsk(ctx context.Context, productIDs ...ProductID) (_ map[ProductID]TopOfBook, err error) {
+	ctx, span := c.startSpan(ctx, "GetBestBidAsk")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	query := url.Values{}
+	for _, productID := range productIDs {
+		if err := c.checkProductID(ctx, productID); err != nil {
+			return nil, err
+		}
+		query.Add("product_ids", productID.String())
+	}
+
+	var res bestBidAskResponse
+	if err := c.doRequest(ctx, "GetBestBidAsk", http.MethodGet, "/brokerage/best_bid_ask?"+query.Encode(), nil, &res); err != nil {
+		return nil, err
+	}
+
+	books := make(map[ProductID]TopOfBook, len(res.Pricebooks))
+	for _, pricebook := range res.Pricebooks {
+		book := TopOfBook{ProductID: ProductID(pricebook.ProductID), Time: pricebook.Time}
+		if len(pricebook.Bids) > 0 {
+			book.BidPrice, book.BidSize = parseBookLevel(pricebook.Bids[0])
+		}
+		if len(pricebook.Asks) > 0 {
+			book.AskPrice, book.AskSize = parseBookLevel(pricebook.Asks[0])
+		}
+		books[book.ProductID] = book
+	}
+
+	return books, nil
+}
+
+func parseBookLevel(level bookLevel) (price, size float64) {
+	return parseCatalogFloat(level.Price), parseCatalogFloat(level.Size)
+}
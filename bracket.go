@@ -0,0 +1,450 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type BracketState string
+
+const (
+	EntryOpenBracketState BracketState = "ENTRY_OPEN"
+	// EntryFilledBracketState means the exits still need to be placed
+	EntryFilledBracketState BracketState = "ENTRY_FILLED"
+	// ProtectedBracketState has the stop loss resting while the take profit price is watched
+	ProtectedBracketState BracketState = "PROTECTED"
+	// TakingProfitBracketState means the take profit price traded. The stop loss is pulled before the take
+	// profit is placed
+	TakingProfitBracketState     BracketState = "TAKING_PROFIT"
+	TakeProfitFilledBracketState BracketState = "TAKE_PROFIT_FILLED"
+	StoppedOutBracketState       BracketState = "STOPPED_OUT"
+	CancelledBracketState        BracketState = "CANCELLED"
+)
+
+func (s BracketState) IsTerminal() bool {
+	return s == TakeProfitFilledBracketState || s == StoppedOutBracketState || s == CancelledBracketState
+}
+
+type BracketParams struct {
+	// ID is used for every order in the bracket. A uuid is generated when it's empty
+	ID          string `json:"id"`
+	BaseTicker  string `json:"base_ticker" validate:"required"`
+	QuoteTicker string `json:"quote_ticker" validate:"required"`
+	// Side is the entry side. The exits use the other side
+	Side     sideType `json:"side" validate:"required"`
+	Quantity float64  `json:"quantity" validate:"required"`
+	// EntryPrice of 0 skips the entry and places the exits right away which is a plain OCO for a position
+	// that is already held
+	EntryPrice      float64 `json:"entry_price"`
+	TakeProfitPrice float64 `json:"take_profit_price" validate:"required"`
+	StopPrice       float64 `json:"stop_price" validate:"required"`
+	// StopLimitPrice defaults to StopPrice
+	StopLimitPrice float64 `json:"stop_limit_price"`
+}
+
+type Bracket struct {
+	BracketParams
+	State             BracketState `json:"state"`
+	EntryOrderID      string       `json:"entry_order_id,omitempty"`
+	TakeProfitOrderID string       `json:"take_profit_order_id,omitempty"`
+	StopLossOrderID   string       `json:"stop_loss_order_id,omitempty"`
+	Message           string       `json:"message,omitempty"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+type BracketStore interface {
+	Save(brackets []Bracket) error
+	Load() ([]Bracket, error)
+}
+
+// NewFileBracketStore keeps every bracket in a json file. The file is replaced atomically on every save
+func NewFileBracketStore(path string) BracketStore {
+	return &fileBracketStore{path: path}
+}
+
+type fileBracketStore struct {
+	path string
+}
+
+func (s *fileBracketStore) Save(brackets []Bracket) error {
+	bts, err := json.MarshalIndent(brackets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(bts); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *fileBracketStore) Load() ([]Bracket, error) {
+	bts, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Bracket{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	brackets := []Bracket{}
+	if err = json.Unmarshal(bts, &brackets); err != nil {
+		return nil, err
+	}
+
+	return brackets, nil
+}
+
+// BracketManager emulates OCO and bracket orders on the client. Only the stop loss rests on the book so the
+// position is never held twice. The take profit is watched on the client and once its price trades the stop
+// loss is cancelled before the take profit is sold
+type BracketManager struct {
+	client   ApiClient
+	store    BracketStore
+	mutex    *sync.Mutex
+	brackets map[string]*Bracket
+	clock    Clock
+}
+
+// NewBracketManager loads every bracket from the store. Call Sync or Run to pick up where it left off
+func NewBracketManager(client ApiClient, store BracketStore) (*BracketManager, error) {
+	brackets, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &BracketManager{client: client, store: store, mutex: &sync.Mutex{}, brackets: make(map[string]*Bracket), clock: clockOf(client)}
+	for idx := range brackets {
+		m.brackets[brackets[idx].ID] = &brackets[idx]
+	}
+
+	return m, nil
+}
+
+func (m *BracketManager) Brackets() []Bracket {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	brackets := []Bracket{}
+	for _, bracket := range m.brackets {
+		brackets = append(brackets, *bracket)
+	}
+	sort.Slice(brackets, func(i, j int) bool { return brackets[i].ID < brackets[j].ID })
+
+	return brackets
+}
+
+// Open places the entry or the exits when there is no entry price
+func (m *BracketManager) Open(ctx context.Context, params BracketParams) (*Bracket, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+	if params.StopLimitPrice == 0 {
+		params.StopLimitPrice = params.StopPrice
+	}
+
+	long := params.Side == BuySideType
+	if (long && params.TakeProfitPrice <= params.StopPrice) || (!long && params.TakeProfitPrice >= params.StopPrice) {
+		return nil, fmt.Errorf("take profit %f and stop %f are on the wrong sides for a %s entry", params.TakeProfitPrice, params.StopPrice, params.Side)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.brackets[params.ID]; exists {
+		return nil, fmt.Errorf("bracket '%s' already exists", params.ID)
+	}
+
+	bracket := &Bracket{BracketParams: params, State: EntryFilledBracketState}
+	if params.EntryPrice > 0 {
+		bracket.State = EntryOpenBracketState
+	}
+
+	// saved before anything is placed so a crash part way through is picked up by Sync
+	m.brackets[params.ID] = bracket
+	if err := m.save(bracket); err != nil {
+		delete(m.brackets, params.ID)
+		return nil, err
+	}
+
+	if err := m.advance(ctx, bracket); err != nil {
+		return bracket, err
+	}
+
+	return bracket, nil
+}
+
+// Cancel pulls every open order in the bracket
+func (m *BracketManager) Cancel(ctx context.Context, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	bracket, exists := m.brackets[id]
+	if !exists {
+		return fmt.Errorf("bracket '%s' does not exist", id)
+	} else if bracket.State.IsTerminal() {
+		return nil
+	}
+
+	for _, orderID := range []string{bracket.EntryOrderID, bracket.TakeProfitOrderID, bracket.StopLossOrderID} {
+		if orderID == "" {
+			continue
+		}
+		if _, err := settleOrder(ctx, m.client, orderID); err != nil {
+			return err
+		}
+	}
+
+	bracket.State = CancelledBracketState
+	bracket.Message = "cancelled"
+	return m.save(bracket)
+}
+
+// Sync checks every bracket that isn't finished and moves it along
+func (m *BracketManager) Sync(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// one bad bracket shouldn't hold up the rest so the first error is returned once they have all been tried
+	var firstErr error
+	for _, bracket := range m.brackets {
+		if bracket.State.IsTerminal() {
+			continue
+		}
+
+		if err := m.advance(ctx, bracket); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("bracket '%s': %w", bracket.ID, err)
+		}
+	}
+
+	return firstErr
+}
+
+// Run calls Sync every interval until ctx is cancelled. A failed sync is passed to onError when it's set and
+// tried again on the next tick. onError is called from Run's goroutine
+func (m *BracketManager) Run(ctx context.Context, interval time.Duration, onError func(err error)) error {
+	ticker := m.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Sync(ctx); err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
+
+// advance must be called with the mutex held
+func (m *BracketManager) advance(ctx context.Context, bracket *Bracket) error {
+	switch bracket.State {
+	case EntryOpenBracketState:
+		if bracket.EntryOrderID == "" {
+			order, err := m.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+				ID:          bracket.ID + "-entry",
+				BaseTicker:  bracket.BaseTicker,
+				QuoteTicker: bracket.QuoteTicker,
+				Price:       bracket.EntryPrice,
+				Quantity:    bracket.Quantity,
+				Side:        bracket.Side,
+			})
+			if err != nil {
+				return err
+			}
+
+			bracket.EntryOrderID = order.GetOrderId()
+			if err = m.save(bracket); err != nil {
+				return err
+			}
+		}
+
+		order, err := m.client.GetOrder(ctx, bracket.EntryOrderID)
+		if err != nil {
+			return err
+		}
+
+		switch order.GetStatus() {
+		case cbadvmodel.OPEN:
+			return nil
+		case cbadvmodel.FILLED:
+			bracket.State = EntryFilledBracketState
+			if err = m.save(bracket); err != nil {
+				return err
+			}
+			return m.advance(ctx, bracket)
+		default:
+			bracket.State = CancelledBracketState
+			bracket.Message = fmt.Sprintf("entry ended with status '%s'", order.GetStatus())
+			return m.save(bracket)
+		}
+	case EntryFilledBracketState:
+		return m.placeExits(ctx, bracket)
+	case ProtectedBracketState:
+		return m.checkStopLoss(ctx, bracket)
+	case TakingProfitBracketState:
+		return m.takeProfit(ctx, bracket)
+	}
+
+	return nil
+}
+
+func (m *BracketManager) exitSide(bracket *Bracket) sideType {
+	if bracket.Side == SellSideType {
+		return BuySideType
+	}
+
+	return SellSideType
+}
+
+// placeExits only rests the stop loss. The take profit is placed by takeProfit once its price trades
+func (m *BracketManager) placeExits(ctx context.Context, bracket *Bracket) error {
+	if bracket.StopLossOrderID == "" {
+		order, err := m.client.CreateStopLimitOrder(ctx, &CreateStopLimitOrderParams{
+			ID:          bracket.ID + "-stop-loss",
+			BaseTicker:  bracket.BaseTicker,
+			QuoteTicker: bracket.QuoteTicker,
+			StopPrice:   bracket.StopPrice,
+			LimitPrice:  bracket.StopLimitPrice,
+			Quantity:    bracket.Quantity,
+			Side:        m.exitSide(bracket),
+		})
+		if err != nil {
+			return err
+		}
+
+		bracket.StopLossOrderID = order.GetOrderId()
+	}
+
+	bracket.State = ProtectedBracketState
+	return m.save(bracket)
+}
+
+func (m *BracketManager) checkStopLoss(ctx context.Context, bracket *Bracket) error {
+	stopLoss, err := m.client.GetOrder(ctx, bracket.StopLossOrderID)
+	if err != nil {
+		return err
+	}
+
+	switch stopLoss.GetStatus() {
+	case cbadvmodel.OPEN:
+	case cbadvmodel.FILLED:
+		bracket.State = StoppedOutBracketState
+		return m.save(bracket)
+	default:
+		bracket.State = CancelledBracketState
+		bracket.Message = fmt.Sprintf("stop loss ended with status '%s'", stopLoss.GetStatus())
+		return m.save(bracket)
+	}
+
+	_, _, price, _, err := m.client.GetProductMarketData(ctx, NewProductID(bracket.BaseTicker, bracket.QuoteTicker), nil)
+	if err != nil {
+		return err
+	}
+
+	if (bracket.Side == BuySideType && price < bracket.TakeProfitPrice) || (bracket.Side == SellSideType && price > bracket.TakeProfitPrice) {
+		return nil
+	}
+
+	// saved before the stop loss is pulled so a crash part way through still takes the profit
+	bracket.State = TakingProfitBracketState
+	if err = m.save(bracket); err != nil {
+		return err
+	}
+
+	return m.takeProfit(ctx, bracket)
+}
+
+func (m *BracketManager) takeProfit(ctx context.Context, bracket *Bracket) error {
+	if bracket.TakeProfitOrderID == "" {
+		stopLoss, err := settleOrder(ctx, m.client, bracket.StopLossOrderID)
+		if err != nil {
+			return err
+		}
+
+		quantity := bracket.Quantity - stopLoss.GetFilledSize()
+		if stopLoss.GetStatus() == cbadvmodel.FILLED || quantity <= 0 {
+			bracket.State = StoppedOutBracketState
+			return m.save(bracket)
+		}
+
+		order, err := m.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			ID:          bracket.ID + "-take-profit",
+			BaseTicker:  bracket.BaseTicker,
+			QuoteTicker: bracket.QuoteTicker,
+			Price:       bracket.TakeProfitPrice,
+			Quantity:    quantity,
+			Side:        m.exitSide(bracket),
+		})
+		if err != nil {
+			return err
+		}
+
+		bracket.TakeProfitOrderID = order.GetOrderId()
+		if err = m.save(bracket); err != nil {
+			return err
+		}
+	}
+
+	takeProfit, err := m.client.GetOrder(ctx, bracket.TakeProfitOrderID)
+	if err != nil {
+		return err
+	}
+
+	switch takeProfit.GetStatus() {
+	case cbadvmodel.OPEN:
+		return nil
+	case cbadvmodel.FILLED:
+		bracket.State = TakeProfitFilledBracketState
+	default:
+		bracket.State = CancelledBracketState
+		bracket.Message = fmt.Sprintf("take profit ended with status '%s'", takeProfit.GetStatus())
+	}
+
+	return m.save(bracket)
+}
+
+// save must be called with the mutex held
+func (m *BracketManager) save(bracket *Bracket) error {
+	bracket.UpdatedAt = m.clock.Now().UTC()
+
+	brackets := make([]Bracket, 0, len(m.brackets))
+	for _, b := range m.brackets {
+		brackets = append(brackets, *b)
+	}
+	sort.Slice(brackets, func(i, j int) bool { return brackets[i].ID < brackets[j].ID })
+
+	return m.store.Save(brackets)
+}
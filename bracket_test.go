@@ -0,0 +1,196 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BracketManager", func() {
+	var (
+		ctrl   *gomock.Controller
+		client *mocks.MockApiClient
+		store  BracketStore
+		params BracketParams
+
+		// the mocked exchange holds the base currency for every resting sell the way coinbase does
+		available float64
+		held      map[string]float64
+		statuses  map[string]model.OrderStatus
+		placed    []string
+		price     float64
+	)
+
+	place := func(id string, side string, quantity float64) (*model.Order, error) {
+		if side == string(SellSideType) {
+			if quantity > available+1e-9 {
+				return nil, fmt.Errorf("insufficient BTC. need %f but only %f is available", quantity, available)
+			}
+			available -= quantity
+			held[id] = quantity
+		}
+
+		statuses[id] = model.OPEN
+		placed = append(placed, id)
+		return &model.Order{OrderId: utils.StringToPtr(id)}, nil
+	}
+
+	fill := func(id string) {
+		statuses[id] = model.FILLED
+		delete(held, id)
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(NewRealClock()).AnyTimes()
+		store = NewFileBracketStore(filepath.Join(GinkgoT().TempDir(), "brackets.json"))
+
+		available, held, statuses, placed, price = 0, map[string]float64{}, map[string]model.OrderStatus{}, nil, 100
+
+		params = BracketParams{
+			ID:              "bracket",
+			BaseTicker:      "BTC",
+			QuoteTicker:     "USD",
+			Side:            BuySideType,
+			Quantity:        1,
+			EntryPrice:      100,
+			TakeProfitPrice: 110,
+			StopPrice:       95,
+			StopLimitPrice:  94,
+		}
+
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateLimitMarketOrderParams) (*model.Order, error) {
+				return place(p.ID, string(p.Side), p.Quantity)
+			}).AnyTimes()
+		client.EXPECT().CreateStopLimitOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateStopLimitOrderParams) (*model.Order, error) {
+				Expect(p.StopPrice).To(Equal(95.0))
+				Expect(p.LimitPrice).To(Equal(94.0))
+				return place(p.ID, string(p.Side), p.Quantity)
+			}).AnyTimes()
+		client.EXPECT().CancelOrders(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ids ...string) error {
+			for _, id := range ids {
+				if statuses[id] == model.OPEN {
+					statuses[id] = model.CANCELLED
+					available += held[id]
+					delete(held, id)
+				}
+			}
+			return nil
+		}).AnyTimes()
+		client.EXPECT().GetOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (*model.Order, error) {
+			var filled float64
+			if statuses[id] == model.FILLED {
+				filled = 1
+			}
+			return newOrder(id, statuses[id], filled, 0), nil
+		}).AnyTimes()
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).DoAndReturn(
+			func(context.Context, ProductID, *float64) (float64, float64, float64, float64, error) {
+				return 0, 0, price, 0, nil
+			}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should reject exits on the wrong side of the entry", func() {
+		manager, err := NewBracketManager(client, store)
+		Expect(err).To(BeNil())
+
+		params.TakeProfitPrice, params.StopPrice = 90, 95
+		_, err = manager.Open(ctx, params)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should only rest the stop loss and pull it before taking profit after a restart", func() {
+		manager, err := NewBracketManager(client, store)
+		Expect(err).To(BeNil())
+
+		bracket, err := manager.Open(ctx, params)
+		Expect(err).To(BeNil())
+		Expect(bracket.State).To(Equal(EntryOpenBracketState))
+
+		fill("bracket-entry")
+		available = 1
+		Expect(manager.Sync(ctx)).To(Succeed())
+		Expect(manager.Brackets()[0].State).To(Equal(ProtectedBracketState))
+
+		price = 105
+		Expect(manager.Sync(ctx)).To(Succeed())
+		Expect(placed).To(Equal([]string{"bracket-entry", "bracket-stop-loss"}))
+
+		// a new manager picks the bracket back up from the store
+		restarted, err := NewBracketManager(client, store)
+		Expect(err).To(BeNil())
+		Expect(restarted.Brackets()[0].StopLossOrderID).To(Equal("bracket-stop-loss"))
+
+		price = 110
+		Expect(restarted.Sync(ctx)).To(Succeed())
+		Expect(statuses["bracket-stop-loss"]).To(Equal(model.CANCELLED))
+		Expect(placed).To(Equal([]string{"bracket-entry", "bracket-stop-loss", "bracket-take-profit"}))
+		Expect(restarted.Brackets()[0].State).To(Equal(TakingProfitBracketState))
+
+		fill("bracket-take-profit")
+		Expect(restarted.Sync(ctx)).To(Succeed())
+		Expect(restarted.Brackets()[0].State).To(Equal(TakeProfitFilledBracketState))
+		Expect(available).To(BeNumerically("~", 0))
+
+		saved, err := store.Load()
+		Expect(err).To(BeNil())
+		Expect(saved[0].State).To(Equal(TakeProfitFilledBracketState))
+	})
+
+	It("should pass a failed sync to onError and keep running", func() {
+		params.EntryPrice = 0
+		manager, err := NewBracketManager(client, store)
+		Expect(err).To(BeNil())
+
+		// nothing is available so the stop loss can't be placed
+		_, err = manager.Open(ctx, params)
+		Expect(err).NotTo(BeNil())
+
+		cctx, cancel := context.WithCancel(ctx)
+		var errs []error
+		err = manager.Run(cctx, time.Hour, func(err error) {
+			errs = append(errs, err)
+			cancel()
+		})
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(ContainSubstring("insufficient BTC")))
+	})
+
+	It("should only place the stop loss right away for an OCO", func() {
+		params.EntryPrice = 0
+		available = 1
+
+		manager, err := NewBracketManager(client, store)
+		Expect(err).To(BeNil())
+
+		bracket, err := manager.Open(ctx, params)
+		Expect(err).To(BeNil())
+		Expect(bracket.EntryOrderID).To(BeEmpty())
+		Expect(bracket.State).To(Equal(ProtectedBracketState))
+
+		fill("bracket-stop-loss")
+		price = 94
+		Expect(manager.Sync(ctx)).To(Succeed())
+		Expect(manager.Brackets()[0].State).To(Equal(StoppedOutBracketState))
+		Expect(placed).To(Equal([]string{"bracket-stop-loss"}))
+	})
+})
@@ -0,0 +1,301 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Mode string
+
+const (
+	// RecordMode sends every request to the real transport and keeps a scrubbed copy of the exchange
+	RecordMode Mode = "record"
+	// ReplayMode never touches the network and serves the recorded responses back
+	ReplayMode Mode = "replay"
+)
+
+const redacted = "REDACTED"
+
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// ScrubbedHeaders never make it to a cassette. Coinbase signs with the key, a timestamp and an hmac
+var ScrubbedHeaders = []string{"CB-ACCESS-KEY", "CB-ACCESS-SIGN", "CB-ACCESS-TIMESTAMP", "Authorization", "Cookie", "Set-Cookie"}
+
+// VolatileQueryParams change on every run because the client derives them from the current time so they are
+// left out when a request is matched
+var VolatileQueryParams = []string{"start_date", "end_date", "start_sequence_timestamp", "end_sequence_timestamp", "start", "end"}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is an http.RoundTripper. Requests are matched on method, path and query and each recorded interaction
+// is served once in the order it was recorded so polling the same endpoint plays back the same sequence
+type Cassette struct {
+	path         string
+	mode         Mode
+	transport    http.RoundTripper
+	mutex        *sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New loads path in ReplayMode. transport is only used in RecordMode and defaults to http.DefaultTransport
+func New(path string, mode Mode, transport http.RoundTripper) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, transport: transport, mutex: &sync.Mutex{}, interactions: []Interaction{}}
+	if c.transport == nil {
+		c.transport = http.DefaultTransport
+	}
+
+	switch mode {
+	case RecordMode:
+	case ReplayMode:
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(bts, &c.interactions); err != nil {
+			return nil, fmt.Errorf("unable to read cassette '%s': %w", path, err)
+		}
+		c.used = make([]bool, len(c.interactions))
+	default:
+		return nil, fmt.Errorf("invalid cassette mode '%s'", mode)
+	}
+
+	return c, nil
+}
+
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+func (c *Cassette) Interactions() []Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	interactions := make([]Interaction, len(c.interactions))
+	copy(interactions, c.interactions)
+
+	return interactions
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.mode == ReplayMode {
+		return c.replay(req)
+	}
+
+	return c.record(req)
+}
+
+// Save writes every recorded interaction. It does nothing in ReplayMode
+func (c *Cassette) Save() error {
+	if c.mode != RecordMode {
+		return nil
+	}
+
+	c.mutex.Lock()
+	bts, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(append(bts, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func (c *Cassette) record(req *http.Request) (*http.Response, error) {
+	// the caller owns req so the body is read into a clone that's sent instead
+	req = req.Clone(req.Context())
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key is scrubbed from anywhere it might be echoed back
+	secrets := []string{}
+	for _, header := range []string{"CB-ACCESS-KEY", "CB-ACCESS-SIGN"} {
+		if value := req.Header.Get(header); value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+
+	res, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := readBody(&res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     scrub(req.URL.String(), secrets),
+			Headers: scrubHeaders(req.Header),
+			Body:    scrub(string(reqBody), secrets),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Headers:    scrubHeaders(res.Header),
+			Body:       scrub(string(resBody), secrets),
+		},
+	}
+
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mutex.Unlock()
+
+	return res, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := matchKey(req.Method, req.URL)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for idx, interaction := range c.interactions {
+		if c.used[idx] {
+			continue
+		}
+
+		recorded, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return nil, err
+		} else if matchKey(interaction.Request.Method, recorded) != key {
+			continue
+		}
+
+		c.used[idx] = true
+
+		headers := http.Header{}
+		for name, values := range interaction.Response.Headers {
+			headers[name] = append([]string{}, values...)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        headers,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoInteraction, key)
+}
+
+// readBody returns the body and puts a fresh reader back so the caller can still consume it
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	bts, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(bts))
+
+	return bts, nil
+}
+
+func matchKey(method string, u *url.URL) string {
+	query := u.Query()
+	for _, param := range VolatileQueryParams {
+		query.Del(param)
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, key+"="+value)
+		}
+	}
+
+	return method + " " + u.Path + "?" + strings.Join(parts, "&")
+}
+
+func scrubHeaders(headers http.Header) http.Header {
+	scrubbed := http.Header{}
+	for name, values := range headers {
+		scrubbed[name] = append([]string{}, values...)
+	}
+
+	for _, name := range ScrubbedHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, redacted)
+		}
+	}
+
+	return scrubbed
+}
+
+func scrub(str string, secrets []string) string {
+	for _, secret := range secrets {
+		str = strings.ReplaceAll(str, secret, redacted)
+	}
+
+	return str
+}
@@ -0,0 +1,145 @@
+package cassette_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient/cassette"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+var _ = Describe("Cassette", func() {
+	var (
+		path  string
+		calls int
+		live  roundTripFunc
+	)
+
+	get := func(client *http.Client, url string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("CB-ACCESS-KEY", "my-key")
+		req.Header.Set("CB-ACCESS-SIGN", "my-signature")
+		req.Header.Set("CB-ACCESS-TIMESTAMP", "1677679200")
+
+		res, err := client.Do(req)
+		Expect(err).To(BeNil())
+		defer res.Body.Close()
+
+		bts, err := io.ReadAll(res.Body)
+		Expect(err).To(BeNil())
+
+		return res.StatusCode, string(bts)
+	}
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "cassettes", "session.json")
+		calls = 0
+		live = func(req *http.Request) (*http.Response, error) {
+			calls++
+			body := `{"order":{"order_id":"order-1","status":"OPEN","echo":"` + req.Header.Get("CB-ACCESS-KEY") + `"}}`
+			if calls > 1 {
+				body = `{"order":{"order_id":"order-1","status":"FILLED"}}`
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"application/json"}, "Set-Cookie": []string{"session=abc"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, nil
+		}
+	})
+
+	It("should record a scrubbed session and replay it in order without the network", func() {
+		rec, err := New(path, RecordMode, live)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: rec}
+
+		status, body := get(client, "https://api.coinbase.com/api/v3/brokerage/orders/historical/order-1")
+		Expect(status).To(Equal(200))
+		Expect(body).To(ContainSubstring("my-key"))
+		_, body = get(client, "https://api.coinbase.com/api/v3/brokerage/orders/historical/order-1")
+		Expect(body).To(ContainSubstring("FILLED"))
+		Expect(rec.Save()).To(Succeed())
+
+		bts, err := os.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).NotTo(ContainSubstring("my-key"))
+		Expect(string(bts)).NotTo(ContainSubstring("my-signature"))
+		Expect(string(bts)).NotTo(ContainSubstring("1677679200"))
+		Expect(string(bts)).NotTo(ContainSubstring("session=abc"))
+
+		replay, err := New(path, ReplayMode, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			Fail("replay should never use the network")
+			return nil, nil
+		}))
+		Expect(err).To(BeNil())
+		client = &http.Client{Transport: replay}
+
+		_, body = get(client, "https://api.coinbase.com/api/v3/brokerage/orders/historical/order-1")
+		Expect(body).To(ContainSubstring("OPEN"))
+		Expect(body).To(ContainSubstring("REDACTED"))
+		_, body = get(client, "https://api.coinbase.com/api/v3/brokerage/orders/historical/order-1")
+		Expect(body).To(ContainSubstring("FILLED"))
+
+		_, err = client.Get("https://api.coinbase.com/api/v3/brokerage/orders/historical/order-1")
+		Expect(errors.Is(err, ErrNoInteraction)).To(BeTrue())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should ignore time derived query params when matching", func() {
+		rec, err := New(path, RecordMode, live)
+		Expect(err).To(BeNil())
+		get(&http.Client{Transport: rec}, "https://api.coinbase.com/api/v3/brokerage/orders/historical/batch?product_id=BTC-USD&start_date=2023-03-01T00:00:00Z")
+		Expect(rec.Save()).To(Succeed())
+
+		replay, err := New(path, ReplayMode, nil)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: replay}
+
+		_, err = client.Get("https://api.coinbase.com/api/v3/brokerage/orders/historical/batch?product_id=ETH-USD")
+		Expect(errors.Is(err, ErrNoInteraction)).To(BeTrue())
+
+		status, _ := get(client, "https://api.coinbase.com/api/v3/brokerage/orders/historical/batch?start_date=2023-03-02T00:00:00Z&product_id=BTC-USD")
+		Expect(status).To(Equal(200))
+	})
+
+	It("should record a request body without changing the caller's request", func() {
+		sent := ""
+		rec, err := New(path, RecordMode, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			bts, err := io.ReadAll(req.Body)
+			Expect(err).To(BeNil())
+			sent = string(bts)
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+		}))
+		Expect(err).To(BeNil())
+
+		req, err := http.NewRequest(http.MethodPost, "https://api.coinbase.com/api/v3/brokerage/orders", strings.NewReader(`{"side":"BUY"}`))
+		Expect(err).To(BeNil())
+		body := req.Body
+
+		res, err := rec.RoundTrip(req)
+		Expect(err).To(BeNil())
+		res.Body.Close()
+
+		Expect(req.Body).To(BeIdenticalTo(body))
+		Expect(sent).To(Equal(`{"side":"BUY"}`))
+		Expect(rec.Interactions()[0].Request.Body).To(Equal(`{"side":"BUY"}`))
+	})
+
+	It("should fail to replay a cassette that was never recorded", func() {
+		_, err := New(path, ReplayMode, nil)
+		Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+	})
+})
@@ -0,0 +1,197 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	cbadvclient "github.com/QuantFu-Inc/coinbase-adv/client"
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("client order ids", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		cont     ApiClient
+		params   *CreateLimitMarketOrderParams
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false)
+		Expect(err).To(BeNil())
+
+		params = &CreateLimitMarketOrderParams{
+			ID:          "my-intent",
+			BaseTicker:  "YFI",
+			QuoteTicker: "BTC",
+			Price:       0.4,
+			Quantity:    2,
+			Side:        BuySideType,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("ClientOrderID", func() {
+		It("should be the same for the same intent", func() {
+			other := *params
+			Expect(params.ClientOrderID()).To(Equal(other.ClientOrderID()))
+			Expect(params.ClientOrderID()).To(HavePrefix("create-market-order-BUY-YFI-BTC-my-intent-"))
+		})
+
+		It("should change when the price or quantity changes", func() {
+			other := *params
+			other.Price = 0.39
+			Expect(params.ClientOrderID()).NotTo(Equal(other.ClientOrderID()))
+		})
+	})
+
+	Context("CreateLimitMarketOrder", func() {
+		It("should not place the order again when it made it to coinbase before the failure", func() {
+			status := model.OPEN
+			coid := params.ClientOrderID()
+
+			gomock.InOrder(
+				cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("i/o timeout")),
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+					Orders: []model.Order{{
+						OrderId:       utils.StringToPtr("order-1"),
+						ClientOrderId: utils.StringToPtr(coid),
+						Status:        &status,
+					}},
+				}, nil),
+			)
+
+			order, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("order-1"))
+		})
+
+		It("should find the order on a later page of the product's orders", func() {
+			status := model.OPEN
+			coid := params.ClientOrderID()
+			hasNext := true
+
+			gomock.InOrder(
+				cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("i/o timeout")),
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, p *cbadvclient.ListOrdersParams) (*model.ListOrdersResponse, error) {
+						Expect(p.Cursor).To(BeNil())
+						return &model.ListOrdersResponse{
+							Orders:  []model.Order{{OrderId: utils.StringToPtr("order-2"), ClientOrderId: utils.StringToPtr("other")}},
+							HasNext: &hasNext,
+							Cursor:  utils.StringToPtr("page-2"),
+						}, nil
+					}),
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, p *cbadvclient.ListOrdersParams) (*model.ListOrdersResponse, error) {
+						Expect(utils.StringPtrToString(p.Cursor)).To(Equal("page-2"))
+						return &model.ListOrdersResponse{Orders: []model.Order{{
+							OrderId:       utils.StringToPtr("order-1"),
+							ClientOrderId: utils.StringToPtr(coid),
+							Status:        &status,
+						}}}, nil
+					}),
+			)
+
+			order, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("order-1"))
+		})
+
+		It("should only look through orders created since just before the first attempt", func() {
+			now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+			var err error
+			cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithClock(NewFakeClock(now)))
+			Expect(err).To(BeNil())
+
+			status := model.OPEN
+			coid := params.ClientOrderID()
+
+			gomock.InOrder(
+				cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("i/o timeout")),
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, p *cbadvclient.ListOrdersParams) (*model.ListOrdersResponse, error) {
+						Expect(p.StartDate).To(Equal(now.Add(-time.Minute)))
+						return &model.ListOrdersResponse{Orders: []model.Order{{
+							OrderId:       utils.StringToPtr("order-1"),
+							ClientOrderId: utils.StringToPtr(coid),
+							Status:        &status,
+						}}}, nil
+					}),
+			)
+
+			order, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("order-1"))
+		})
+
+		It("should resubmit with the same client order id when the lookup fails too", func() {
+			status := model.OPEN
+			success := true
+			coids := []string{}
+
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, req *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+					coids = append(coids, req.GetClientOrderId())
+					if len(coids) == 1 {
+						return nil, errors.New("i/o timeout")
+					}
+					return &model.CreateOrderResponse{Success: &success, OrderId: utils.StringToPtr("order-1")}, nil
+				}).Times(2)
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(nil, errors.New("i/o timeout"))
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(&model.GetOrderResponse{Order: &model.Order{
+				OrderId: utils.StringToPtr("order-1"),
+				Status:  &status,
+			}}, nil)
+
+			order, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("order-1"))
+			Expect(coids).To(HaveLen(2))
+			Expect(coids[0]).To(Equal(coids[1]))
+		})
+
+		It("should resubmit with the same client order id when the order was not found", func() {
+			status := model.OPEN
+			success := true
+			coids := []string{}
+
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, req *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+					coids = append(coids, req.GetClientOrderId())
+					if len(coids) == 1 {
+						return nil, errors.New("i/o timeout")
+					}
+					return &model.CreateOrderResponse{Success: &success, OrderId: utils.StringToPtr("order-1")}, nil
+				}).Times(2)
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{}, nil)
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(&model.GetOrderResponse{Order: &model.Order{
+				OrderId: utils.StringToPtr("order-1"),
+				Status:  &status,
+			}}, nil)
+
+			order, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("order-1"))
+			Expect(coids).To(HaveLen(2))
+			Expect(coids[0]).To(Equal(coids[1]))
+			Expect(params.ID).To(Equal("my-intent"))
+		})
+	})
+})
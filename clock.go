@@ -0,0 +1,175 @@
+package apiclient
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is every timing decision the client and the strategy helpers make. RealClock is the default and
+// FakeClock only moves when it is told to
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return &realTicker{ticker: time.NewTicker(d)} }
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t *realTicker) Stop()               { t.ticker.Stop() }
+
+// clockOrReal lets helpers treat a nil Clock in their params as the real one
+func clockOrReal(clock Clock) Clock {
+	if clock == nil {
+		return NewRealClock()
+	}
+
+	return clock
+}
+
+// clockOf is the client's clock so every strategy keeps the same time as the client it trades through
+func clockOf(client ApiClient) Clock {
+	return clockOrReal(client.Clock())
+}
+
+// FakeClock is a Clock for tests. Sleepers, After channels and tickers fire when Advance moves past them
+type FakeClock struct {
+	mutex   *sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration
+	ch     chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{mutex: &sync.Mutex{}, now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, &fakeWaiter{at: c.now.Add(d), ch: ch})
+
+	return ch
+}
+
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// NewTicker drops ticks for a slow receiver just like time.Ticker
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	waiter := &fakeWaiter{at: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, waiter)
+
+	return &fakeTicker{clock: c, waiter: waiter}
+}
+
+// Advance moves the clock forward and fires everything that came due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+
+	remaining := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(c.now) {
+			remaining = append(remaining, waiter)
+			continue
+		}
+
+		select {
+		case waiter.ch <- waiter.at:
+		default:
+		}
+
+		if waiter.period > 0 {
+			for !waiter.at.After(c.now) {
+				waiter.at = waiter.at.Add(waiter.period)
+			}
+			remaining = append(remaining, waiter)
+		}
+	}
+	c.waiters = remaining
+}
+
+// Waiters is how many sleepers, After channels and tickers are waiting on the clock
+func (c *FakeClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
+
+// BlockUntil waits in real time until at least n waiters are on the clock so a test can advance it without
+// racing the goroutine it is driving
+func (c *FakeClock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *FakeClock) remove(waiter *fakeWaiter) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for idx, existing := range c.waiters {
+		if existing == waiter {
+			c.waiters = append(c.waiters[:idx], c.waiters[idx+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.waiter.ch }
+func (t *fakeTicker) Stop()               { t.clock.remove(t.waiter) }
@@ -0,0 +1,154 @@
+package apiclient_test
+
+import (
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clock", func() {
+	var (
+		start time.Time
+		clock *FakeClock
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		clock = NewFakeClock(start)
+	})
+
+	Context("FakeClock", func() {
+		It("should only fire After once the clock passes it", func() {
+			ch := clock.After(time.Minute)
+
+			clock.Advance(59 * time.Second)
+			Consistently(ch, "10ms").ShouldNot(Receive())
+
+			clock.Advance(time.Second)
+			Eventually(ch).Should(Receive(Equal(start.Add(time.Minute))))
+			Expect(clock.Waiters()).To(Equal(0))
+		})
+
+		It("should wake a sleeper", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				clock.Sleep(time.Second)
+				close(done)
+			}()
+
+			clock.BlockUntil(1)
+			Expect(done).NotTo(BeClosed())
+			clock.Advance(time.Second)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("should tick every interval and drop ticks nobody read", func() {
+			ticker := clock.NewTicker(time.Second)
+
+			clock.Advance(time.Second)
+			Eventually(ticker.C()).Should(Receive(Equal(start.Add(time.Second))))
+
+			clock.Advance(3 * time.Second)
+			Eventually(ticker.C()).Should(Receive(Equal(start.Add(2 * time.Second))))
+			Consistently(ticker.C(), "10ms").ShouldNot(Receive())
+
+			ticker.Stop()
+			Expect(clock.Waiters()).To(Equal(0))
+			clock.Advance(time.Minute)
+			Consistently(ticker.C(), "10ms").ShouldNot(Receive())
+		})
+	})
+
+	Context("client", func() {
+		var (
+			ctrl     *gomock.Controller
+			cbClient *mocks.MockCoinbaseClient
+			cont     ApiClient
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			cbClient = mocks.NewMockCoinbaseClient(ctrl)
+
+			var err error
+			cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithClock(clock))
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should hand its clock to everything built on it", func() {
+			Expect(cont.Clock()).To(BeIdenticalTo(clock))
+
+			wrapped := Chain(cont, DryRunInterceptor())
+			Expect(wrapped.Clock()).To(BeIdenticalTo(clock))
+		})
+
+		It("should use the real clock when it's given a nil one", func() {
+			cont, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithClock(nil))
+			Expect(err).To(BeNil())
+			Expect(cont.Clock()).NotTo(BeNil())
+			Expect(cont.Clock().Now()).To(BeTemporally("~", time.Now(), time.Second))
+		})
+
+		It("should poll VerifyMarketOrderCompletion on the clock until it times out", func() {
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0), nil).Times(3)
+
+			errCh := make(chan error)
+			go func() {
+				defer GinkgoRecover()
+				errCh <- cont.VerifyMarketOrderCompletion(ctx, "order-1", start.Add(12*time.Second))
+			}()
+
+			for idx := 0; idx < 3; idx++ {
+				clock.BlockUntil(1)
+				clock.Advance(5 * time.Second)
+			}
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("market order 'order-1' has timed out"))
+			Expect(clock.Now()).To(Equal(start.Add(15 * time.Second)))
+		})
+
+		It("should delay create retries on the clock", func() {
+			gomock.InOrder(
+				cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+					Success:       utils.BoolToBoolPtr(false),
+					ErrorResponse: &model.CreateOrderResponseErrorResponse{Error: utils.StringToPtr("INVALID_PRICE_PRECISION")},
+				}, nil),
+				cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+					Success: utils.BoolToBoolPtr(true),
+					OrderId: utils.StringToPtr("order-1"),
+				}, nil),
+				cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0), nil),
+			)
+
+			errCh := make(chan error)
+			go func() {
+				defer GinkgoRecover()
+				_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+					ID: "retry", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.45, Quantity: 2, Side: BuySideType,
+				})
+				errCh <- err
+			}()
+
+			clock.BlockUntil(1)
+			Consistently(errCh, "10ms").ShouldNot(Receive())
+			clock.Advance(50 * time.Millisecond)
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+	})
+})
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+var _ = BeforeSuite(func() {
+	ctx = context.Background()
+})
+
+func TestCbtrade(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cbtrade Suite")
+}
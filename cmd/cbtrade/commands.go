@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+)
+
+type balance struct {
+	Currency  string  `json:"currency"`
+	Available float64 `json:"available"`
+	Hold      float64 `json:"hold"`
+}
+
+func balancesCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("balances", flag.ContinueOnError)
+	all := flags.Bool("all", false, "include empty accounts")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []balance{}
+	res := &result{headers: []string{"CURRENCY", "AVAILABLE", "HOLD"}}
+	for idx := range accounts {
+		bal := balance{Currency: accounts[idx].GetCurrency()}
+		if accounts[idx].AvailableBalance != nil {
+			bal.Available = accounts[idx].AvailableBalance.GetValue()
+		}
+		if accounts[idx].Hold != nil {
+			bal.Hold = accounts[idx].Hold.GetValue()
+		}
+
+		if !*all && bal.Available == 0 && bal.Hold == 0 {
+			continue
+		}
+
+		balances = append(balances, bal)
+		res.rows = append(res.rows, []string{bal.Currency, formatFloat(bal.Available), formatFloat(bal.Hold)})
+	}
+	res.value = balances
+
+	return res, nil
+}
+
+func productCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("product", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	productID, err := productArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := client.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{
+		headers: []string{"FIELD", "VALUE"},
+		rows: [][]string{
+			{"product_id", product.GetProductId()},
+			{"status", product.GetStatus()},
+			{"price", formatFloat(product.GetPrice())},
+			{"base_increment", formatFloat(product.GetBaseIncrement())},
+			{"quote_increment", formatFloat(product.GetQuoteIncrement())},
+			{"base_min_size", formatFloat(product.GetBaseMinSize())},
+			{"base_max_size", formatFloat(product.GetBaseMaxSize())},
+			{"quote_min_size", formatFloat(product.GetQuoteMinSize())},
+			{"quote_max_size", formatFloat(product.GetQuoteMaxSize())},
+			{"trading_disabled", fmt.Sprint(product.GetTradingDisabled())},
+		},
+		value: product,
+	}, nil
+}
+
+type marketData struct {
+	ProductID             string  `json:"product_id"`
+	Price                 float64 `json:"price"`
+	High24h               float64 `json:"high_24h"`
+	Low24h                float64 `json:"low_24h"`
+	PriceChangePercentage float64 `json:"price_change_percentage_24h"`
+}
+
+func marketCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("market", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	productID, err := productArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	high, low, price, change, err := client.GetProductMarketData(ctx, productID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data := marketData{
+		ProductID:             productID.String(),
+		Price:                 price,
+		High24h:               high,
+		Low24h:                low,
+		PriceChangePercentage: change,
+	}
+
+	return &result{
+		headers: []string{"PRODUCT", "PRICE", "HIGH 24H", "LOW 24H", "CHANGE 24H %"},
+		rows: [][]string{{
+			data.ProductID, formatFloat(price), formatFloat(high), formatFloat(low), formatFloat(change),
+		}},
+		value: data,
+	}, nil
+}
+
+func limitCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("limit", flag.ContinueOnError)
+	side := flags.String("side", "", "buy or sell")
+	price := flags.Float64("price", 0, "limit price in the quote currency")
+	size := flags.Float64("size", 0, "size in the base currency")
+	wait := flags.Duration("wait", 0, "wait this long for the order to fill")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	productID, err := productArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &apiclient.CreateLimitMarketOrderParams{
+		BaseTicker:  productID.Base(),
+		QuoteTicker: productID.Quote(),
+		Price:       *price,
+		Quantity:    *size,
+	}
+	if err = setSide(params, *side); err != nil {
+		return nil, err
+	}
+
+	return placeOrder(ctx, client, params, *wait)
+}
+
+// marketOrderCommand places a limit order at the current price which is how this client treats market orders
+func marketOrderCommand(side string) func(context.Context, apiclient.ApiClient, []string) (*result, error) {
+	return func(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+		flags := flag.NewFlagSet(side, flag.ContinueOnError)
+		size := flags.Float64("size", 0, "size in the base currency")
+		wait := flags.Duration("wait", time.Minute, "wait this long for the order to fill")
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		productID, err := productArg(flags)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _, price, _, err := client.GetProductMarketData(ctx, productID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		params := &apiclient.CreateLimitMarketOrderParams{
+			BaseTicker:  productID.Base(),
+			QuoteTicker: productID.Quote(),
+			Price:       price,
+			Quantity:    *size,
+		}
+		if err = setSide(params, side); err != nil {
+			return nil, err
+		}
+
+		return placeOrder(ctx, client, params, *wait)
+	}
+}
+
+func waitCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("wait", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", time.Minute, "give up after this long")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != 1 {
+		return nil, errors.New("an order id is required")
+	}
+
+	if err := client.VerifyMarketOrderCompletion(ctx, flags.Arg(0), client.Clock().Now().Add(*timeout)); err != nil {
+		return nil, err
+	}
+
+	order, err := client.GetOrder(ctx, flags.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return ordersResult([]cbadvmodel.Order{*order}), nil
+}
+
+func ordersCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("orders", flag.ContinueOnError)
+	side := flags.String("side", "", "only show buy or sell orders")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	orderSide := cbadvmodel.UNKNOWN_ORDER_SIDE
+	if *side != "" {
+		orderSide = cbadvmodel.OrderSide(strings.ToUpper(*side))
+		if orderSide != cbadvmodel.BUY && orderSide != cbadvmodel.SELL {
+			return nil, fmt.Errorf("unknown side '%s'", *side)
+		}
+	}
+
+	productID, err := optionalProductArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := client.GetOpenOrdersByProductIDAndSide(ctx, productID, orderSide)
+	if err != nil {
+		return nil, err
+	}
+
+	return ordersResult(orders), nil
+}
+
+func fillsCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("fills", flag.ContinueOnError)
+	since := flags.Duration("since", 24*time.Hour, "how far back to look")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	productID, err := optionalProductArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	end := client.Clock().Now()
+	fills, err := client.GetFillsByTimeRange(ctx, productID, end.Add(-*since), end)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		headers: []string{"TRADE TIME", "ORDER ID", "PRODUCT", "SIDE", "PRICE", "SIZE", "COMMISSION"},
+		value:   fills,
+	}
+	for idx := range fills {
+		res.rows = append(res.rows, []string{
+			fills[idx].GetTradeTime(),
+			fills[idx].GetOrderId(),
+			fills[idx].GetProductId(),
+			fills[idx].GetSide(),
+			formatFloat(fills[idx].GetPrice()),
+			formatFloat(fills[idx].GetSize()),
+			formatFloat(fills[idx].GetCommission()),
+		})
+	}
+
+	return res, nil
+}
+
+type cancelResult struct {
+	OrderID   string `json:"order_id"`
+	Cancelled bool   `json:"cancelled"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// cancelAllCommand reports every order it tried to cancel. Orders coinbase refused are listed with the reason
+// and the command still fails
+func cancelAllCommand(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error) {
+	flags := flag.NewFlagSet("cancel-all", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	productID, err := optionalProductArg(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := client.GetOpenOrdersByProductIDAndSide(ctx, productID, cbadvmodel.UNKNOWN_ORDER_SIDE)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := []string{}
+	for idx := range orders {
+		orderIDs = append(orderIDs, orders[idx].GetOrderId())
+	}
+
+	var cancelErr *apiclient.CancelOrdersError
+	if len(orderIDs) > 0 {
+		if err = client.CancelOrders(ctx, orderIDs...); err != nil && !errors.As(err, &cancelErr) {
+			return nil, err
+		}
+	}
+
+	results := []cancelResult{}
+	res := &result{headers: []string{"ORDER ID", "CANCELLED", "REASON"}}
+	for _, orderID := range orderIDs {
+		cancelled := cancelResult{OrderID: orderID, Cancelled: true}
+		if cancelErr != nil {
+			if reason, failed := cancelErr.Failures[orderID]; failed {
+				cancelled.Cancelled, cancelled.Reason = false, reason
+			}
+		}
+
+		results = append(results, cancelled)
+		res.rows = append(res.rows, []string{orderID, fmt.Sprint(cancelled.Cancelled), cancelled.Reason})
+	}
+	res.value = results
+
+	return res, err
+}
+
+// placeOrder waits for the order to fill when wait is set
+func placeOrder(ctx context.Context, client apiclient.ApiClient, params *apiclient.CreateLimitMarketOrderParams, wait time.Duration) (*result, error) {
+	var (
+		orderID string
+		err     error
+	)
+
+	if wait > 0 {
+		orderID, err = client.CreateOrderAndWaitForCompletion(ctx, params, client.Clock().Now().Add(wait))
+		if err != nil && orderID == "" {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("order '%s' was placed but did not complete: %w", orderID, err)
+		}
+	} else {
+		order, err := client.CreateLimitMarketOrder(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		orderID = order.GetOrderId()
+	}
+
+	order, err := client.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ordersResult([]cbadvmodel.Order{*order}), nil
+}
+
+func ordersResult(orders []cbadvmodel.Order) *result {
+	res := &result{
+		headers: []string{"ORDER ID", "PRODUCT", "SIDE", "STATUS", "PRICE", "SIZE", "FILLED", "CREATED"},
+		value:   orders,
+	}
+
+	for idx := range orders {
+		var price, size float64
+		if limit := orders[idx].GetOrderConfiguration().LimitLimitGtc; limit != nil {
+			price, size = limit.GetLimitPrice(), limit.GetBaseSize()
+		}
+
+		res.rows = append(res.rows, []string{
+			orders[idx].GetOrderId(),
+			orders[idx].GetProductId(),
+			orders[idx].GetSide(),
+			string(orders[idx].GetStatus()),
+			formatFloat(price),
+			formatFloat(size),
+			formatFloat(orders[idx].GetFilledSize()),
+			orders[idx].GetCreatedTime(),
+		})
+	}
+
+	return res
+}
+
+func setSide(params *apiclient.CreateLimitMarketOrderParams, side string) error {
+	switch strings.ToLower(side) {
+	case "buy":
+		params.Side = apiclient.BuySideType
+	case "sell":
+		params.Side = apiclient.SellSideType
+	default:
+		return fmt.Errorf("side must be buy or sell but got '%s'", side)
+	}
+
+	return nil
+}
+
+// productArg reads a product like BTC-USD from the first positional argument
+func productArg(flags *flag.FlagSet) (apiclient.ProductID, error) {
+	if flags.NArg() != 1 {
+		return "", errors.New("a product like BTC-USD is required")
+	}
+
+	return apiclient.ParseProductID(strings.ToUpper(flags.Arg(0)))
+}
+
+// optionalProductArg is empty when no product was given
+func optionalProductArg(flags *flag.FlagSet) (apiclient.ProductID, error) {
+	if flags.NArg() == 0 {
+		return "", nil
+	} else if flags.NArg() > 1 {
+		return "", errors.New("only one product like BTC-USD can be given")
+	}
+
+	return apiclient.ParseProductID(strings.ToUpper(flags.Arg(0)))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	apiKeyEnv    = "COINBASE_API_KEY"
+	apiSecretEnv = "COINBASE_API_SECRET"
+)
+
+type Credentials struct {
+	ApiKey    string `json:"api_key"`
+	ApiSecret string `json:"api_secret"`
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".cbtrade.json")
+}
+
+// loadCredentials prefers the environment and falls back to the json config file
+func loadCredentials(path string) (*Credentials, error) {
+	creds := &Credentials{ApiKey: os.Getenv(apiKeyEnv), ApiSecret: os.Getenv(apiSecretEnv)}
+	if creds.ApiKey != "" && creds.ApiSecret != "" {
+		return creds, nil
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("set %s and %s or pass -config", apiKeyEnv, apiSecretEnv)
+	}
+
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("set %s and %s or create %s", apiKeyEnv, apiSecretEnv, path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	creds = &Credentials{}
+	if err = json.Unmarshal(bts, creds); err != nil {
+		return nil, fmt.Errorf("unable to read config '%s': %w", path, err)
+	}
+
+	if creds.ApiKey == "" || creds.ApiSecret == "" {
+		return nil, fmt.Errorf("config '%s' must set api_key and api_secret", path)
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,132 @@
+// cbtrade runs everyday coinbase advanced trade operations from the command line
+//
+//	cbtrade [-config path] [-output table|json] [-debug] <command> [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+
+	cbadvclient "github.com/QuantFu-Inc/coinbase-adv/client"
+	coinbasegoclientv3 "github.com/happilymarrieddad/coinbase-go-client-v3"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, client apiclient.ApiClient, args []string) (*result, error)
+}
+
+var commands = map[string]command{
+	"balances":   {usage: "list every account balance", run: balancesCommand},
+	"product":    {usage: "show a product's details ex. product BTC-USD", run: productCommand},
+	"market":     {usage: "show 24 hour market data ex. market BTC-USD", run: marketCommand},
+	"limit":      {usage: "place a limit order ex. limit -side buy -price 20000 -size 0.01 BTC-USD", run: limitCommand},
+	"buy":        {usage: "buy at the current price ex. buy -size 0.01 BTC-USD", run: marketOrderCommand("buy")},
+	"sell":       {usage: "sell at the current price ex. sell -size 0.01 BTC-USD", run: marketOrderCommand("sell")},
+	"wait":       {usage: "wait for an order to fill ex. wait -timeout 1m <order id>", run: waitCommand},
+	"orders":     {usage: "list open orders ex. orders [-side buy] [BTC-USD]", run: ordersCommand},
+	"fills":      {usage: "list fills ex. fills [-since 24h] [BTC-USD]", run: fillsCommand},
+	"cancel-all": {usage: "cancel every open order ex. cancel-all [BTC-USD]", run: cancelAllCommand},
+}
+
+type clientFactory func(creds *Credentials, debug bool) (apiclient.ApiClient, error)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, newClient); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, factory clientFactory) error {
+	flags := flag.NewFlagSet("cbtrade", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath(), "credentials file used when the environment variables are not set")
+	output := flags.String("output", "table", "table or json")
+	debug := flags.Bool("debug", false, "log every request")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: cbtrade [flags] <command> [command flags]\n\ncommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(flags.Output(), "  %-11s %s\n", name, commands[name].usage)
+		}
+		fmt.Fprintf(flags.Output(), "\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("unknown output '%s'", *output)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("a command is required")
+	}
+
+	cmd, exists := commands[flags.Arg(0)]
+	if !exists {
+		flags.Usage()
+		return fmt.Errorf("unknown command '%s'", flags.Arg(0))
+	}
+
+	creds, err := loadCredentials(*configPath)
+	if err != nil {
+		return err
+	}
+
+	client, err := factory(creds, *debug)
+	if err != nil {
+		return err
+	}
+
+	// a command that fails part way can still have a result worth showing
+	res, err := cmd.run(ctx, client, flags.Args()[1:])
+	if res == nil {
+		return err
+	}
+
+	var writeErr error
+	if *output == "json" {
+		writeErr = res.writeJSON(stdout)
+	} else {
+		writeErr = res.writeTable(stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeErr
+}
+
+func newClient(creds *Credentials, debug bool) (apiclient.ApiClient, error) {
+	backup, err := coinbasegoclientv3.NewClient(&http.Client{Timeout: time.Second * 30}, creds.ApiKey, creds.ApiSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// a mistyped or swapped product fails before anything is sent
+	return apiclient.NewApiClient(
+		cbadvclient.NewClient(&cbadvclient.Credentials{ApiKey: creds.ApiKey, ApiSKey: creds.ApiSecret}), backup, debug,
+		apiclient.WithProductValidation(),
+	)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("cbtrade", func() {
+	var (
+		ctrl   *gomock.Controller
+		client *mocks.MockApiClient
+		stdout *bytes.Buffer
+		config string
+	)
+
+	factory := func(creds *Credentials, debug bool) (apiclient.ApiClient, error) {
+		Expect(*creds).To(Equal(Credentials{ApiKey: "key", ApiSecret: "secret"}))
+		return client, nil
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		stdout = &bytes.Buffer{}
+
+		config = filepath.Join(GinkgoT().TempDir(), "config.json")
+		Expect(os.WriteFile(config, []byte(`{"api_key": "key", "api_secret": "secret"}`), 0600)).To(Succeed())
+
+		GinkgoT().Setenv(apiKeyEnv, "")
+		GinkgoT().Setenv(apiSecretEnv, "")
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should prefer credentials from the environment", func() {
+		GinkgoT().Setenv(apiKeyEnv, "key")
+		GinkgoT().Setenv(apiSecretEnv, "secret")
+		client.EXPECT().GetAccounts(gomock.Any()).Return([]model.Account{}, nil)
+
+		Expect(run(ctx, []string{"-config", "does-not-exist.json", "balances"}, stdout, factory)).To(Succeed())
+	})
+
+	It("should print non empty balances as a table", func() {
+		client.EXPECT().GetAccounts(gomock.Any()).Return([]model.Account{
+			{Currency: utils.StringToPtr("BTC"), AvailableBalance: &model.AccountAvailableBalance{Value: utils.Float64ToFloat64Ptr(1.5)}},
+			{Currency: utils.StringToPtr("ETH")},
+		}, nil)
+
+		Expect(run(ctx, []string{"-config", config, "balances"}, stdout, factory)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"BTC", "1.5", "0"}))
+	})
+
+	It("should place a limit order and print it as json", func() {
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), &apiclient.CreateLimitMarketOrderParams{
+			BaseTicker: "BTC", QuoteTicker: "USD", Price: 20000, Quantity: 0.01, Side: apiclient.BuySideType,
+		}).Return(&model.Order{OrderId: utils.StringToPtr("order-1")}, nil)
+		client.EXPECT().GetOrder(gomock.Any(), "order-1").Return(&model.Order{
+			OrderId: utils.StringToPtr("order-1"), ProductId: utils.StringToPtr("BTC-USD"),
+		}, nil)
+
+		Expect(run(ctx, []string{
+			"-config", config, "-output", "json", "limit", "-side", "buy", "-price", "20000", "-size", "0.01", "btc-usd",
+		}, stdout, factory)).To(Succeed())
+
+		var orders []model.Order
+		Expect(json.Unmarshal(stdout.Bytes(), &orders)).To(Succeed())
+		Expect(orders).To(HaveLen(1))
+		Expect(orders[0].GetOrderId()).To(Equal("order-1"))
+	})
+
+	It("should cancel every open order for the product", func() {
+		client.EXPECT().GetOpenOrdersByProductIDAndSide(gomock.Any(), apiclient.ProductID("BTC-USD"), model.UNKNOWN_ORDER_SIDE).Return([]model.Order{
+			{OrderId: utils.StringToPtr("order-1")}, {OrderId: utils.StringToPtr("order-2")},
+		}, nil)
+		client.EXPECT().CancelOrders(gomock.Any(), "order-1", "order-2")
+
+		Expect(run(ctx, []string{"-config", config, "cancel-all", "BTC-USD"}, stdout, factory)).To(Succeed())
+		Expect(stdout.String()).To(ContainSubstring("order-2"))
+	})
+
+	It("should list every order coinbase refused to cancel and fail", func() {
+		client.EXPECT().GetOpenOrdersByProductIDAndSide(gomock.Any(), apiclient.ProductID(""), model.UNKNOWN_ORDER_SIDE).Return([]model.Order{
+			{OrderId: utils.StringToPtr("order-1")}, {OrderId: utils.StringToPtr("order-2")},
+		}, nil)
+		client.EXPECT().CancelOrders(gomock.Any(), "order-1", "order-2").Return(&apiclient.CancelOrdersError{
+			Failures: map[string]string{"order-2": "UNKNOWN_CANCEL_ORDER"},
+		})
+
+		err := run(ctx, []string{"-config", config, "cancel-all"}, stdout, factory)
+		Expect(err).To(MatchError(ContainSubstring("order-2")))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"order-1", "true"}))
+		Expect(strings.Fields(lines[2])).To(Equal([]string{"order-2", "false", "UNKNOWN_CANCEL_ORDER"}))
+	})
+
+	It("should reject a malformed product", func() {
+		Expect(run(ctx, []string{"-config", config, "cancel-all", "BTCUSD"}, stdout, factory)).To(MatchError(apiclient.ErrInvalidProductID))
+	})
+
+	It("should reject unknown commands", func() {
+		Expect(run(ctx, []string{"-config", config, "nope"}, &bytes.Buffer{}, factory)).To(MatchError("unknown command 'nope'"))
+	})
+})
@@ -0,0 +1,221 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type DCAExecutionStatus string
+
+const (
+	PlacedDCAExecutionStatus              DCAExecutionStatus = "PLACED"
+	InsufficientBalanceDCAExecutionStatus DCAExecutionStatus = "SKIPPED_INSUFFICIENT_BALANCE"
+	PriceChangeDCAExecutionStatus         DCAExecutionStatus = "SKIPPED_PRICE_CHANGE"
+	FailedDCAExecutionStatus              DCAExecutionStatus = "FAILED"
+)
+
+type DCAExecution struct {
+	ScheduleID  string             `json:"schedule_id"`
+	ProductID   ProductID          `json:"product_id"`
+	ScheduledAt time.Time          `json:"scheduled_at"`
+	ExecutedAt  time.Time          `json:"executed_at"`
+	Status      DCAExecutionStatus `json:"status"`
+	OrderID     string             `json:"order_id,omitempty"`
+	Price       float64            `json:"price,omitempty"`
+	Quantity    float64            `json:"quantity,omitempty"`
+	QuoteAmount float64            `json:"quote_amount"`
+	// PriceChangePercentage24h is what the guard saw
+	PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
+	QuoteBalance             float64 `json:"quote_balance"`
+	Reason                   string  `json:"reason,omitempty"`
+}
+
+type DCAAuditLog interface {
+	Record(execution DCAExecution) error
+	Close() error
+}
+
+// NewFileDCAAuditLog appends every execution as a json line to the file at path
+func NewFileDCAAuditLog(path string) (DCAAuditLog, error) {
+	file, err := openJSONLFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileDCAAuditLog{jsonlFile: file}, nil
+}
+
+type fileDCAAuditLog struct {
+	*jsonlFile
+}
+
+func (l *fileDCAAuditLog) Record(execution DCAExecution) error {
+	return l.Append(execution)
+}
+
+type DCAParams struct {
+	// ID ties every execution together and makes each scheduled run idempotent. A uuid is generated when it's empty
+	ID          string
+	BaseTicker  string  `validate:"required"`
+	QuoteTicker string  `validate:"required"`
+	QuoteAmount float64 `validate:"required"`
+	// Schedule is anything ParseSchedule accepts ex. "@every 24h" or "0 14 * * 1"
+	Schedule string `validate:"required"`
+	// MaxPriceChangePercentage24h skips a run when the price moved more than this in the last 24 hours in either
+	// direction. 0 disables the guard. 1% will be 1.0
+	MaxPriceChangePercentage24h float64
+	// BaseIncrement rounds the quantity down. 0 leaves it alone
+	BaseIncrement float64
+	// AuditLog is optional. Every execution is also kept in memory
+	AuditLog DCAAuditLog
+	// OnError gets every run that failed and is called from Run's goroutine
+	OnError func(err error)
+}
+
+type DCAScheduler struct {
+	client     ApiClient
+	params     DCAParams
+	clock      Clock
+	schedule   Schedule
+	mutex      *sync.Mutex
+	executions []DCAExecution
+}
+
+func NewDCAScheduler(client ApiClient, params DCAParams) (*DCAScheduler, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	schedule, err := ParseSchedule(params.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	return &DCAScheduler{client: client, params: params, clock: clockOf(client), schedule: schedule, mutex: &sync.Mutex{}}, nil
+}
+
+func (s *DCAScheduler) Executions() []DCAExecution {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	executions := make([]DCAExecution, len(s.executions))
+	copy(executions, s.executions)
+
+	return executions
+}
+
+// Run executes on every scheduled time until ctx is cancelled. A failed run is recorded and the schedule carries on
+func (s *DCAScheduler) Run(ctx context.Context) error {
+	for {
+		next := s.schedule.Next(s.clock.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule '%s' never runs", s.params.Schedule)
+		}
+
+		if err := sleepUntil(ctx, s.clock, next); err != nil {
+			return err
+		}
+
+		if _, err := s.Execute(ctx, next); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if s.params.OnError != nil {
+				s.params.OnError(err)
+			}
+		}
+	}
+}
+
+// Execute runs the purchase for scheduledAt. The order uses ID-<unix scheduledAt> as its intent. Skipped runs are
+// not errors
+func (s *DCAScheduler) Execute(ctx context.Context, scheduledAt time.Time) (DCAExecution, error) {
+	execution := DCAExecution{
+		ScheduleID:  s.params.ID,
+		ProductID:   NewProductID(s.params.BaseTicker, s.params.QuoteTicker),
+		ScheduledAt: scheduledAt.UTC(),
+		QuoteAmount: s.params.QuoteAmount,
+	}
+
+	err := s.execute(ctx, &execution)
+	if err != nil {
+		execution.Status = FailedDCAExecutionStatus
+		execution.Reason = err.Error()
+	}
+	execution.ExecutedAt = s.clock.Now().UTC()
+
+	s.mutex.Lock()
+	s.executions = append(s.executions, execution)
+	s.mutex.Unlock()
+
+	if s.params.AuditLog != nil {
+		if auditErr := s.params.AuditLog.Record(execution); auditErr != nil && err == nil {
+			err = auditErr
+		}
+	}
+
+	return execution, err
+}
+
+func (s *DCAScheduler) execute(ctx context.Context, execution *DCAExecution) error {
+	_, _, price, change, err := s.client.GetProductMarketData(ctx, NewProductID(s.params.BaseTicker, s.params.QuoteTicker), nil)
+	if err != nil {
+		return err
+	}
+	execution.Price, execution.PriceChangePercentage24h = price, change
+
+	if s.params.MaxPriceChangePercentage24h > 0 && math.Abs(change) > s.params.MaxPriceChangePercentage24h {
+		execution.Status = PriceChangeDCAExecutionStatus
+		execution.Reason = fmt.Sprintf("24 hour price change %f%% is more than %f%%", change, s.params.MaxPriceChangePercentage24h)
+		return nil
+	}
+
+	_, _, _, quoteBalance, err := s.client.GetCurrentWallentAmount(ctx, NewProductID(s.params.BaseTicker, s.params.QuoteTicker))
+	if err != nil {
+		return err
+	}
+	execution.QuoteBalance = quoteBalance
+
+	if quoteBalance < s.params.QuoteAmount {
+		execution.Status = InsufficientBalanceDCAExecutionStatus
+		execution.Reason = fmt.Sprintf("%s balance %f is less than %f", s.params.QuoteTicker, quoteBalance, s.params.QuoteAmount)
+		return nil
+	}
+
+	if price <= 0 {
+		return fmt.Errorf("invalid price %f for %s", price, execution.ProductID)
+	}
+
+	quantity := s.params.QuoteAmount / price
+	if s.params.BaseIncrement > 0 {
+		quantity = utils.FloorToIncrement(quantity, s.params.BaseIncrement)
+	}
+	execution.Quantity = quantity
+
+	order, err := s.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          fmt.Sprintf("%s-%d", s.params.ID, execution.ScheduledAt.Unix()),
+		BaseTicker:  s.params.BaseTicker,
+		QuoteTicker: s.params.QuoteTicker,
+		Price:       price,
+		Quantity:    quantity,
+		Side:        BuySideType,
+	})
+	if err != nil {
+		return err
+	}
+
+	execution.Status = PlacedDCAExecutionStatus
+	execution.OrderID = order.GetOrderId()
+
+	return nil
+}
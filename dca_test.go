@@ -0,0 +1,146 @@
+package apiclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DCAScheduler", func() {
+	var (
+		ctrl      *gomock.Controller
+		client    *mocks.MockApiClient
+		scheduler *DCAScheduler
+		auditPath string
+		runAt     time.Time
+		clock     Clock
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		clock = NewRealClock()
+		client.EXPECT().Clock().DoAndReturn(func() Clock { return clock }).AnyTimes()
+		runAt = time.Date(2023, 2, 24, 14, 0, 0, 0, time.UTC)
+
+		auditPath = filepath.Join(GinkgoT().TempDir(), "dca.jsonl")
+		auditLog, err := NewFileDCAAuditLog(auditPath)
+		Expect(err).To(BeNil())
+		DeferCleanup(auditLog.Close)
+
+		scheduler, err = NewDCAScheduler(client, DCAParams{
+			ID:                          "weekly-btc",
+			BaseTicker:                  "BTC",
+			QuoteTicker:                 "USD",
+			QuoteAmount:                 100,
+			Schedule:                    "0 14 * * 5",
+			MaxPriceChangePercentage24h: 10,
+			BaseIncrement:               0.00001,
+			AuditLog:                    auditLog,
+		})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should buy the quote amount at the current price", func() {
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 30000.0, 2.5, nil)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 0.0, 500.0, nil)
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), &CreateLimitMarketOrderParams{
+			ID:          "weekly-btc-1677247200",
+			BaseTicker:  "BTC",
+			QuoteTicker: "USD",
+			Price:       30000,
+			Quantity:    0.00333,
+			Side:        BuySideType,
+		}).Return(&model.Order{OrderId: utils.StringToPtr("order-1")}, nil)
+
+		execution, err := scheduler.Execute(ctx, runAt)
+		Expect(err).To(BeNil())
+		Expect(execution.Status).To(Equal(PlacedDCAExecutionStatus))
+		Expect(execution.OrderID).To(Equal("order-1"))
+
+		bts, err := os.ReadFile(auditPath)
+		Expect(err).To(BeNil())
+
+		var recorded DCAExecution
+		Expect(json.Unmarshal(bts, &recorded)).To(Succeed())
+		Expect(recorded.OrderID).To(Equal("order-1"))
+		Expect(recorded.ScheduledAt).To(Equal(runAt))
+	})
+
+	It("should pass a failed run to OnError and keep the schedule", func() {
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 0.0, 0.0, errors.New("i/o timeout"))
+
+		cctx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		fakeClock := NewFakeClock(runAt.Add(-time.Minute))
+		clock = fakeClock
+
+		var err error
+		scheduler, err = NewDCAScheduler(client, DCAParams{
+			ID:          "weekly-btc",
+			BaseTicker:  "BTC",
+			QuoteTicker: "USD",
+			QuoteAmount: 100,
+			Schedule:    "0 14 * * 5",
+			OnError: func(err error) {
+				errs <- err
+				cancel()
+			},
+		})
+		Expect(err).To(BeNil())
+
+		done := make(chan error, 1)
+		go func() { done <- scheduler.Run(cctx) }()
+
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(time.Minute)
+
+		Eventually(errs).Should(Receive(MatchError("i/o timeout")))
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		Expect(scheduler.Executions()[0].Status).To(Equal(FailedDCAExecutionStatus))
+	})
+
+	It("should skip when the price moved too much", func() {
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 30000.0, -12.0, nil)
+
+		execution, err := scheduler.Execute(ctx, runAt)
+		Expect(err).To(BeNil())
+		Expect(execution.Status).To(Equal(PriceChangeDCAExecutionStatus))
+	})
+
+	It("should skip when the balance is too low and record every run", func() {
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 30000.0, 1.0, nil).Times(2)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 0.0, 50.0, nil).Times(2)
+
+		_, err := scheduler.Execute(ctx, runAt)
+		Expect(err).To(BeNil())
+		_, err = scheduler.Execute(ctx, runAt.AddDate(0, 0, 7))
+		Expect(err).To(BeNil())
+
+		executions := scheduler.Executions()
+		Expect(executions).To(HaveLen(2))
+		Expect(executions[1].Status).To(Equal(InsufficientBalanceDCAExecutionStatus))
+		Expect(executions[1].QuoteBalance).To(Equal(50.0))
+
+		bts, err := os.ReadFile(auditPath)
+		Expect(err).To(BeNil())
+		Expect(strings.Count(string(bts), "\n")).To(Equal(2))
+	})
+})
@@ -0,0 +1,365 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+)
+
+// DryRunOrderIDPrefix starts the order id of every order made up by DryRunInterceptor
+const DryRunOrderIDPrefix = "dry-run-"
+
+// IsDryRunOrder is true when the order never went to the exchange
+func IsDryRunOrder(order *cbadvmodel.Order) bool {
+	return order != nil && strings.HasPrefix(order.GetOrderId(), DryRunOrderIDPrefix)
+}
+
+// DryRunInterceptor never lets a write reach the exchange. Orders go through next.ValidateOrder and are then made
+// up, rest OPEN until something waits on them and fill at their limit price. Cancels check the real orders exist
+// and are open but only change made up ones. Every read still goes to next
+func DryRunInterceptor() Interceptor {
+	return func(next ApiClient) ApiClient {
+		return &dryRunClient{ApiClient: next, mutex: &sync.Mutex{}, orders: make(map[string]*cbadvmodel.Order)}
+	}
+}
+
+type dryRunClient struct {
+	ApiClient
+	mutex    *sync.Mutex
+	orders   map[string]*cbadvmodel.Order
+	sequence int
+}
+
+func (c *dryRunClient) CreateOrderAndWaitForCompletion(
+	ctx context.Context, params *CreateLimitMarketOrderParams, timeout time.Time,
+) (orderID string, err error) {
+	order, err := c.CreateLimitMarketOrder(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	return order.GetOrderId(), c.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), timeout)
+}
+
+func (c *dryRunClient) CreateLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (*cbadvmodel.Order, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	if err := c.ApiClient.ValidateOrder(ctx, params); err != nil {
+		return nil, err
+	}
+
+	return c.reserveAndPlace(params.ClientOrderID(), params.BaseTicker, params.QuoteTicker, params.Side, params.Price, params.Quantity, nil)
+}
+
+func (c *dryRunClient) CreateStopLimitOrder(ctx context.Context, params *CreateStopLimitOrderParams) (*cbadvmodel.Order, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	// validated at the limit price since that's the worst it can fill at
+	if err := c.ApiClient.ValidateOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          params.ID,
+		BaseTicker:  params.BaseTicker,
+		QuoteTicker: params.QuoteTicker,
+		Price:       params.LimitPrice,
+		Quantity:    params.Quantity,
+		Side:        params.Side,
+	}); err != nil {
+		return nil, err
+	}
+
+	return c.reserveAndPlace(params.ClientOrderID(), params.BaseTicker, params.QuoteTicker, params.Side, params.LimitPrice, params.Quantity, &params.StopPrice)
+}
+
+// VerifyMarketOrderCompletion fills made up orders right away
+func (c *dryRunClient) VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) error {
+	if !strings.HasPrefix(orderID, DryRunOrderIDPrefix) {
+		return c.ApiClient.VerifyMarketOrderCompletion(ctx, orderID, timeout)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	order, exists := c.orders[orderID]
+	if !exists {
+		return ErrOrderNotFound
+	}
+
+	switch order.GetStatus() {
+	case cbadvmodel.OPEN:
+		fill(order)
+		return nil
+	case cbadvmodel.FILLED:
+		return nil
+	}
+
+	return fmt.Errorf("unknown issue with the order: %s", order.GetStatus())
+}
+
+func (c *dryRunClient) GetOrder(ctx context.Context, orderID string) (*cbadvmodel.Order, error) {
+	if !strings.HasPrefix(orderID, DryRunOrderIDPrefix) {
+		return c.ApiClient.GetOrder(ctx, orderID)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	order, exists := c.orders[orderID]
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+func (c *dryRunClient) GetOrderByClientOrderID(ctx context.Context, productID ProductID, clientOrderID string) (*cbadvmodel.Order, error) {
+	if order := c.findByClientOrderID(clientOrderID); order != nil {
+		return order, nil
+	}
+
+	return c.ApiClient.GetOrderByClientOrderID(ctx, productID, clientOrderID)
+}
+
+// GetOpenOrdersByProductIDAndSide includes made up orders that are still open
+func (c *dryRunClient) GetOpenOrdersByProductIDAndSide(ctx context.Context, productID ProductID, side cbadvmodel.OrderSide) ([]cbadvmodel.Order, error) {
+	orders, err := c.ApiClient.GetOpenOrdersByProductIDAndSide(ctx, productID, side)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, order := range c.orders {
+		if order.GetStatus() != cbadvmodel.OPEN || (productID != "" && order.GetProductId() != productID.String()) {
+			continue
+		} else if side != cbadvmodel.UNKNOWN_ORDER_SIDE && order.GetSide() != string(side) {
+			continue
+		}
+		orders = append(orders, *order)
+	}
+
+	return orders, nil
+}
+
+// CancelOrders fails the same way coinbase would when a real order is missing or no longer open. Only made up
+// orders are actually cancelled
+func (c *dryRunClient) CancelOrders(ctx context.Context, orderIds ...string) error {
+	if len(orderIds) == 0 {
+		return errors.New("no order ids to cancel")
+	}
+
+	for _, orderID := range orderIds {
+		if strings.HasPrefix(orderID, DryRunOrderIDPrefix) {
+			continue
+		}
+
+		order, err := c.ApiClient.GetOrder(ctx, orderID)
+		if err != nil {
+			return err
+		} else if order.GetStatus() != cbadvmodel.OPEN {
+			return fmt.Errorf("order '%s' is not open and cannot be cancelled. status: '%s'", orderID, order.GetStatus())
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, orderID := range orderIds {
+		if !strings.HasPrefix(orderID, DryRunOrderIDPrefix) {
+			continue
+		}
+
+		order, exists := c.orders[orderID]
+		if !exists {
+			return ErrOrderNotFound
+		} else if order.GetStatus() != cbadvmodel.OPEN {
+			return fmt.Errorf("order '%s' is not open and cannot be cancelled. status: '%s'", orderID, order.GetStatus())
+		}
+		cancel(order)
+	}
+
+	return nil
+}
+
+// CancelExistingOrders still reads the real open orders so a bad product id fails like it would live
+func (c *dryRunClient) CancelExistingOrders(ctx context.Context, id string, productID ProductID, orderType model.OrderType) error {
+	if _, err := c.ApiClient.GetOpenOrdersByProductIDAndSide(ctx, productID, cbadvmodel.UNKNOWN_ORDER_SIDE); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, order := range c.orders {
+		if order.GetStatus() == cbadvmodel.OPEN && order.GetProductId() == productID.String() && strings.Contains(order.GetClientOrderId(), id) {
+			cancel(order)
+		}
+	}
+
+	return nil
+}
+
+func (c *dryRunClient) AmendOrder(ctx context.Context, params *AmendOrderParams) (*AmendOrderResult, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	} else if !strings.HasPrefix(params.OrderID, DryRunOrderIDPrefix) {
+		return nil, fmt.Errorf("order '%s' was not made in dry run and cannot be amended", params.OrderID)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	order, exists := c.orders[params.OrderID]
+	if !exists {
+		return nil, ErrOrderNotFound
+	} else if order.GetStatus() != cbadvmodel.OPEN {
+		return nil, fmt.Errorf("order '%s' is not open and cannot be amended. status: '%s'", params.OrderID, order.GetStatus())
+	}
+
+	limit := order.GetOrderConfiguration().LimitLimitGtc
+	if limit == nil {
+		return nil, fmt.Errorf("order '%s' is not a limit order and cannot be amended", params.OrderID)
+	}
+	if params.Price > 0 {
+		limit.LimitPrice = utils.Float64ToFloat64Ptr(params.Price)
+	}
+	if params.Quantity > 0 {
+		limit.BaseSize = utils.Float64ToFloat64Ptr(params.Quantity)
+	}
+
+	copied := *order
+	return &AmendOrderResult{OriginalOrderID: params.OrderID, OrderID: params.OrderID, Order: &copied}, nil
+}
+
+// EngageKillSwitch stops new orders without cancelling any real ones
+func (c *dryRunClient) EngageKillSwitch(ctx context.Context, cancelOpenOrders bool) error {
+	if cancelOpenOrders {
+		c.mutex.Lock()
+		for _, order := range c.orders {
+			if order.GetStatus() == cbadvmodel.OPEN {
+				cancel(order)
+			}
+		}
+		c.mutex.Unlock()
+	}
+
+	return c.ApiClient.EngageKillSwitch(ctx, false)
+}
+
+// reserveAndPlace counts the order against the daily notional the first time its intent is seen
+func (c *dryRunClient) reserveAndPlace(
+	clientOrderID, baseTicker, quoteTicker string, side sideType, price, quantity float64, stopPrice *float64,
+) (*cbadvmodel.Order, error) {
+	if order := c.findByClientOrderID(clientOrderID); order != nil {
+		return order, nil
+	}
+
+	if err := reserveDailyNotional(c.ApiClient, quoteTicker, price*quantity); err != nil {
+		return nil, err
+	}
+
+	return c.place(clientOrderID, baseTicker, quoteTicker, side, price, quantity, stopPrice), nil
+}
+
+func (c *dryRunClient) findByClientOrderID(clientOrderID string) *cbadvmodel.Order {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, order := range c.orders {
+		if order.GetClientOrderId() == clientOrderID {
+			copied := *order
+			return &copied
+		}
+	}
+
+	return nil
+}
+
+func (c *dryRunClient) place(
+	clientOrderID, baseTicker, quoteTicker string, side sideType, price, quantity float64, stopPrice *float64,
+) *cbadvmodel.Order {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// the same intent maps to the same order just like coinbase
+	for _, order := range c.orders {
+		if order.GetClientOrderId() == clientOrderID {
+			copied := *order
+			return &copied
+		}
+	}
+
+	c.sequence++
+	status := cbadvmodel.OPEN
+	order := &cbadvmodel.Order{
+		OrderId:       utils.StringToPtr(fmt.Sprintf("%s%d", DryRunOrderIDPrefix, c.sequence)),
+		ClientOrderId: utils.StringToPtr(clientOrderID),
+		ProductId:     utils.StringToPtr(NewProductID(baseTicker, quoteTicker).String()),
+		Side:          utils.StringToPtr(string(side)),
+		Status:        &status,
+		CreatedTime:   utils.StringToPtr(c.Clock().Now().UTC().Format(time.RFC3339Nano)),
+		FilledSize:    utils.Float64ToFloat64Ptr(0),
+		OrderConfiguration: &cbadvmodel.OutputOrderConfiguration{
+			LimitLimitGtc: &cbadvmodel.OutputOrderConfigurationLimitLimitGtc{
+				BaseSize:   utils.Float64ToFloat64Ptr(quantity),
+				LimitPrice: utils.Float64ToFloat64Ptr(price),
+			},
+		},
+	}
+	if stopPrice != nil {
+		order.OrderType = utils.StringToPtr("STOP_LIMIT")
+		order.OrderConfiguration = &cbadvmodel.OutputOrderConfiguration{
+			StopLimitStopLimitGtc: &cbadvmodel.OutputOrderConfigurationStopLimitStopLimitGtc{
+				BaseSize:   utils.Float64ToFloat64Ptr(quantity),
+				LimitPrice: utils.Float64ToFloat64Ptr(price),
+				StopPrice:  utils.Float64ToFloat64Ptr(*stopPrice),
+			},
+		}
+	}
+	c.orders[order.GetOrderId()] = order
+
+	copied := *order
+	return &copied
+}
+
+func fill(order *cbadvmodel.Order) {
+	size, price := 0.0, 0.0
+	if limit := order.GetOrderConfiguration().LimitLimitGtc; limit != nil {
+		size, price = limit.GetBaseSize(), limit.GetLimitPrice()
+	} else if stop := order.GetOrderConfiguration().StopLimitStopLimitGtc; stop != nil {
+		size, price = stop.GetBaseSize(), stop.GetLimitPrice()
+	}
+
+	status := cbadvmodel.FILLED
+	order.Status = &status
+	order.FilledSize = utils.Float64ToFloat64Ptr(size)
+	order.AverageFilledPrice = utils.Float64ToFloat64Ptr(price)
+	order.FilledValue = utils.Float64ToFloat64Ptr(size * price)
+	order.CompletionPercentage = utils.Float64ToFloat64Ptr(100)
+}
+
+func cancel(order *cbadvmodel.Order) {
+	status := cbadvmodel.CANCELLED
+	order.Status = &status
+}
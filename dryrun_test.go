@@ -0,0 +1,200 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dry run", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		cont     ApiClient
+		ctx      context.Context
+	)
+
+	account := func(currency string, available float64) *model.Account {
+		return &model.Account{
+			Uuid:             utils.StringToPtr(currency + "-uuid"),
+			Currency:         utils.StringToPtr(currency),
+			AvailableBalance: &model.AccountAvailableBalance{Value: utils.Float64ToFloat64Ptr(available)},
+		}
+	}
+
+	expectProduct := func() {
+		cbClient.EXPECT().GetProduct(gomock.Any(), "YFI-BTC").Return(&model.GetProductResponse{
+			ProductId:      utils.StringToPtr("YFI-BTC"),
+			BaseIncrement:  utils.Float64ToFloat64Ptr(0.001),
+			QuoteIncrement: utils.Float64ToFloat64Ptr(0.0001),
+			BaseMinSize:    utils.Float64ToFloat64Ptr(0.01),
+			QuoteMinSize:   utils.Float64ToFloat64Ptr(0.0001),
+		}, nil)
+	}
+
+	expectBalances := func(yfi, btc float64) {
+		cbClient.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Return(&model.ListAccountsResponse{
+			Accounts: []model.Account{*account("YFI", yfi), *account("BTC", btc)},
+		}, nil).MaxTimes(1)
+		cbClient.EXPECT().GetAccount(gomock.Any(), "YFI-uuid").Return(account("YFI", yfi), nil)
+		cbClient.EXPECT().GetAccount(gomock.Any(), "BTC-uuid").Return(account("BTC", btc), nil)
+	}
+
+	expectFeeTier := func() {
+		cbClient.EXPECT().CheckAuthentication(gomock.Any(), gomock.Any()).AnyTimes()
+		cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(200, `{"fee_tier": {"taker_fee_rate": "0.006", "maker_fee_rate": "0.004"}}`), nil
+		})}).AnyTimes()
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+		ctx = context.Background()
+
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false,
+			WithDryRun(), WithRiskPolicy(RiskPolicy{MaxOrderNotional: 1}))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should validate the order and return a made up one without creating it", func() {
+		expectProduct()
+		expectBalances(0, 1)
+		expectFeeTier()
+
+		order, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType,
+		})
+		Expect(err).To(BeNil())
+		Expect(IsDryRunOrder(order)).To(BeTrue())
+		Expect(order.GetOrderId()).To(HavePrefix(DryRunOrderIDPrefix))
+		Expect(order.GetStatus()).To(Equal(model.OPEN))
+	})
+
+	It("should reject a price that is not on the quote increment", func() {
+		expectProduct()
+
+		_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.12345, Quantity: 1, Side: BuySideType,
+		})
+		Expect(errors.Is(err, ErrInvalidOrder)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("quote increment"))
+	})
+
+	It("should reject an order the balance can't cover", func() {
+		expectProduct()
+		expectBalances(0.5, 1)
+
+		_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1, Side: SellSideType,
+		})
+		Expect(errors.Is(err, ErrInvalidOrder)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("insufficient YFI"))
+	})
+
+	It("should reject a buy the balance only covers before fees", func() {
+		expectProduct()
+		expectBalances(0, 0.75)
+		expectFeeTier()
+
+		_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType,
+		})
+		Expect(errors.Is(err, ErrInvalidOrder)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("need 0.754500 with fees"))
+	})
+
+	It("should count made up orders against the daily notional", func() {
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false,
+			WithDryRun(), WithRiskPolicy(RiskPolicy{MaxDailyNotional: 1}))
+		Expect(err).To(BeNil())
+
+		expectProduct()
+		expectBalances(0, 1)
+		expectFeeTier()
+
+		params := &CreateLimitMarketOrderParams{ID: "first", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType}
+		_, err = cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).To(BeNil())
+
+		params = &CreateLimitMarketOrderParams{ID: "second", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType}
+		_, err = cont.CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("daily BTC notional"))
+	})
+
+	It("should count made up orders against the daily notional through other interceptors", func() {
+		client, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false,
+			WithRiskPolicy(RiskPolicy{MaxDailyNotional: 1}))
+		Expect(err).To(BeNil())
+		cont = Chain(client, DryRunInterceptor(), LoggingInterceptor(log.New(io.Discard, "", 0)))
+
+		expectProduct()
+		expectBalances(0, 1)
+		expectFeeTier()
+
+		params := &CreateLimitMarketOrderParams{ID: "first", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType}
+		_, err = cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).To(BeNil())
+
+		params = &CreateLimitMarketOrderParams{ID: "second", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType}
+		_, err = cont.CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("daily BTC notional"))
+	})
+
+	It("should list made up orders for every product when no product is given", func() {
+		expectProduct()
+		expectBalances(0, 1)
+		expectFeeTier()
+
+		order, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 1.5, Side: BuySideType,
+		})
+		Expect(err).To(BeNil())
+
+		cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{}, nil)
+
+		orders, err := cont.GetOpenOrdersByProductIDAndSide(ctx, "", model.UNKNOWN_ORDER_SIDE)
+		Expect(err).To(BeNil())
+		Expect(orders).To(HaveLen(1))
+		Expect(orders[0].GetOrderId()).To(Equal(order.GetOrderId()))
+	})
+
+	It("should reject an order the risk policy would", func() {
+		_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 3, Side: BuySideType,
+		})
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should check real orders before pretending to cancel them", func() {
+		status := model.FILLED
+		cbClient.EXPECT().GetOrder(gomock.Any(), "123").Return(&model.GetOrderResponse{Order: &model.Order{
+			OrderId: utils.StringToPtr("123"),
+			Status:  &status,
+		}}, nil)
+
+		err := cont.CancelOrders(ctx, "123")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("is not open"))
+	})
+})
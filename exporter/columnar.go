@@ -0,0 +1,255 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// columnarMagic starts every columnar file. The layout after it is
+//
+//	uvarint column count
+//	per column: uvarint name length, name, type byte
+//	blocks until the end of the file, each
+//	  uvarint row count
+//	  per column: every value for that column in the block
+//
+// strings are a uvarint length and the bytes, floats are 8 byte little endian and times are varint unix nanos
+// with 0 for a zero time
+var columnarMagic = []byte("CBCOL1")
+
+// columnarBlockRows is how many rows the writer holds in memory before writing them out as a block
+const columnarBlockRows = 4096
+
+// NewColumnarWriter writes the header right away and every columnarBlockRows rows as a block. Close writes the rest
+func NewColumnarWriter(w io.Writer) RecordWriter {
+	return &columnarWriter{writer: w}
+}
+
+type columnarWriter struct {
+	writer  io.Writer
+	schema  Schema
+	columns [][]interface{}
+	rows    int
+	blocks  int
+}
+
+func (w *columnarWriter) WriteHeader(schema Schema) error {
+	w.schema = schema
+	w.columns = make([][]interface{}, len(schema.Columns))
+
+	buf := newColumnarBuffer(w.writer)
+	buf.Write(columnarMagic)
+	buf.putUvarint(uint64(len(schema.Columns)))
+	for _, col := range schema.Columns {
+		buf.putString(col.Name)
+		buf.WriteByte(byte(col.Type))
+	}
+
+	return buf.Flush()
+}
+
+func (w *columnarWriter) WriteRow(row []interface{}) error {
+	if len(row) != len(w.schema.Columns) {
+		return fmt.Errorf("row has %d values but schema '%s' has %d columns", len(row), w.schema.Name, len(w.schema.Columns))
+	}
+
+	for idx, val := range row {
+		if !matchesType(w.schema.Columns[idx].Type, val) {
+			return fmt.Errorf("unsupported value type %T in column '%s'", val, w.schema.Columns[idx].Name)
+		}
+		w.columns[idx] = append(w.columns[idx], val)
+	}
+	w.rows++
+
+	if w.rows >= columnarBlockRows {
+		return w.writeBlock()
+	}
+
+	return nil
+}
+
+// Close writes the rows left over. A file without rows still gets an empty block
+func (w *columnarWriter) Close() error {
+	if w.rows == 0 && w.blocks > 0 {
+		return nil
+	}
+
+	return w.writeBlock()
+}
+
+func (w *columnarWriter) writeBlock() error {
+	buf := newColumnarBuffer(w.writer)
+	buf.putUvarint(uint64(w.rows))
+
+	for idx, col := range w.schema.Columns {
+		for _, val := range w.columns[idx] {
+			switch col.Type {
+			case StringColumnType:
+				buf.putString(val.(string))
+			case FloatColumnType:
+				binary.LittleEndian.PutUint64(buf.scratch, math.Float64bits(val.(float64)))
+				buf.Write(buf.scratch[:8])
+			case TimeColumnType:
+				var nanos int64
+				if tm := val.(time.Time); !tm.IsZero() {
+					nanos = tm.UnixNano()
+				}
+				n := binary.PutVarint(buf.scratch, nanos)
+				buf.Write(buf.scratch[:n])
+			}
+		}
+		w.columns[idx] = w.columns[idx][:0]
+	}
+	w.rows = 0
+	w.blocks++
+
+	return buf.Flush()
+}
+
+type columnarBuffer struct {
+	*bufio.Writer
+	scratch []byte
+}
+
+func newColumnarBuffer(w io.Writer) *columnarBuffer {
+	return &columnarBuffer{Writer: bufio.NewWriter(w), scratch: make([]byte, binary.MaxVarintLen64)}
+}
+
+func (b *columnarBuffer) putUvarint(v uint64) {
+	n := binary.PutUvarint(b.scratch, v)
+	b.Write(b.scratch[:n])
+}
+
+func (b *columnarBuffer) putString(str string) {
+	b.putUvarint(uint64(len(str)))
+	b.WriteString(str)
+}
+
+// ReadColumnar reads a file written by NewColumnarWriter back into rows. Every count and length in the file is
+// checked against its size before anything is allocated so a corrupt header can't exhaust memory
+func ReadColumnar(r io.Reader) (Schema, [][]interface{}, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Schema{}, nil, err
+	}
+	reader := bytes.NewReader(data)
+	schema := Schema{}
+
+	magic := make([]byte, len(columnarMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return schema, nil, err
+	} else if string(magic) != string(columnarMagic) {
+		return schema, nil, errors.New("not a columnar export")
+	}
+
+	readString := func() (string, error) {
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return "", err
+		}
+		if size > uint64(reader.Len()) {
+			return "", fmt.Errorf("string length %d is more than the %d bytes left in the file", size, reader.Len())
+		}
+		bts := make([]byte, size)
+		_, err = io.ReadFull(reader, bts)
+		return string(bts), err
+	}
+
+	numOfColumns, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return schema, nil, err
+	}
+
+	// a column is at least a one byte name length and its type
+	if numOfColumns > uint64(reader.Len())/2 {
+		return schema, nil, fmt.Errorf("column count %d is more than the file holds", numOfColumns)
+	}
+
+	for idx := uint64(0); idx < numOfColumns; idx++ {
+		name, err := readString()
+		if err != nil {
+			return schema, nil, err
+		}
+		typ, err := reader.ReadByte()
+		if err != nil {
+			return schema, nil, err
+		}
+		schema.Columns = append(schema.Columns, Column{Name: name, Type: ColumnType(typ)})
+	}
+
+	// every value takes at least a byte so a row is at least as many bytes as there are columns
+	minRowSize := uint64(len(schema.Columns))
+	for _, col := range schema.Columns {
+		if col.Type == FloatColumnType {
+			minRowSize += 7
+		}
+	}
+
+	rows := [][]interface{}{}
+	float := make([]byte, 8)
+	for reader.Len() > 0 {
+		numOfRows, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return schema, nil, err
+		}
+		if numOfRows > 0 && (minRowSize == 0 || numOfRows > uint64(reader.Len())/minRowSize) {
+			return schema, nil, fmt.Errorf("row count %d is more than the file holds", numOfRows)
+		}
+
+		block := make([][]interface{}, numOfRows)
+		for idx := range block {
+			block[idx] = make([]interface{}, len(schema.Columns))
+		}
+
+		for colIdx, col := range schema.Columns {
+			for rowIdx := range block {
+				var val interface{}
+
+				switch col.Type {
+				case StringColumnType:
+					val, err = readString()
+				case FloatColumnType:
+					_, err = io.ReadFull(reader, float)
+					val = math.Float64frombits(binary.LittleEndian.Uint64(float))
+				case TimeColumnType:
+					var nanos int64
+					nanos, err = binary.ReadVarint(reader)
+					if nanos == 0 {
+						val = time.Time{}
+					} else {
+						val = time.Unix(0, nanos).UTC()
+					}
+				default:
+					return schema, nil, fmt.Errorf("unknown column type %d for column '%s'", col.Type, col.Name)
+				}
+				if err != nil {
+					return schema, nil, err
+				}
+
+				block[rowIdx][colIdx] = val
+			}
+		}
+		rows = append(rows, block...)
+	}
+
+	return schema, rows, nil
+}
+
+func matchesType(typ ColumnType, val interface{}) bool {
+	switch val.(type) {
+	case string:
+		return typ == StringColumnType
+	case float64:
+		return typ == FloatColumnType
+	case time.Time:
+		return typ == TimeColumnType
+	}
+
+	return false
+}
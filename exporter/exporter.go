@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+)
+
+type Format string
+
+const (
+	CSVFormat      Format = "csv"
+	JSONLFormat    Format = "jsonl"
+	ColumnarFormat Format = "columnar"
+)
+
+func NewRecordWriter(format Format, w io.Writer) (RecordWriter, error) {
+	switch format {
+	case CSVFormat:
+		return NewCSVWriter(w), nil
+	case JSONLFormat:
+		return NewJSONLWriter(w), nil
+	case ColumnarFormat:
+		return NewColumnarWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("unknown export format '%s'", format)
+}
+
+type Exporter struct {
+	client apiclient.ApiClient
+}
+
+func NewExporter(client apiclient.ApiClient) *Exporter {
+	return &Exporter{client: client}
+}
+
+// ExportOrders writes every order created between start and end a page at a time. An empty productID exports
+// every product
+func (e *Exporter) ExportOrders(ctx context.Context, w RecordWriter, productID apiclient.ProductID, start, end time.Time) error {
+	if err := w.WriteHeader(OrderSchema); err != nil {
+		return err
+	}
+
+	if err := e.client.EachOrderPage(ctx, productID, start, end, func(orders []cbadvmodel.Order) error {
+		for idx := range orders {
+			if !inRange(parseTime(orders[idx].GetCreatedTime()), start, end) {
+				continue
+			}
+
+			if err := w.WriteRow(orderRow(&orders[idx])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// ExportFills writes every fill that traded between start and end a page at a time. An empty productID exports
+// every product
+func (e *Exporter) ExportFills(ctx context.Context, w RecordWriter, productID apiclient.ProductID, start, end time.Time) error {
+	if err := w.WriteHeader(FillSchema); err != nil {
+		return err
+	}
+
+	if err := e.client.EachFillPage(ctx, productID, start, end, func(fills []cbadvmodel.OrderFill) error {
+		for idx := range fills {
+			if !inRange(apiclient.FillTime(&fills[idx]), start, end) {
+				continue
+			}
+
+			if err := w.WriteRow(fillRow(&fills[idx])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// ExportAccounts writes a snapshot of every account balance as of now
+func (e *Exporter) ExportAccounts(ctx context.Context, w RecordWriter) error {
+	accounts, err := e.client.GetAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = w.WriteHeader(AccountSchema); err != nil {
+		return err
+	}
+
+	snapshot := e.client.Clock().Now().UTC()
+	for idx := range accounts {
+		if err = w.WriteRow(accountRow(snapshot, &accounts[idx])); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// inRange keeps rows without a time because coinbase already filtered them by the range
+func inRange(tm, start, end time.Time) bool {
+	if tm.IsZero() {
+		return true
+	}
+
+	return (start.IsZero() || !tm.Before(start)) && (end.IsZero() || !tm.After(end))
+}
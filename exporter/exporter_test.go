@@ -0,0 +1,202 @@
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient/exporter"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exporter", func() {
+	var (
+		ctrl     *gomock.Controller
+		client   *mocks.MockApiClient
+		exporter *Exporter
+		start    time.Time
+		end      time.Time
+		fills    []model.OrderFill
+	)
+
+	newFill := func(entryID string, price, size float64, tradeTime string) model.OrderFill {
+		return model.OrderFill{
+			EntryId:    utils.StringToPtr(entryID),
+			OrderId:    utils.StringToPtr("order-1"),
+			ProductId:  utils.StringToPtr("BTC-USD"),
+			Side:       utils.StringToPtr("BUY"),
+			Price:      utils.Float64ToFloat64Ptr(price),
+			Size:       utils.Float64ToFloat64Ptr(size),
+			Commission: utils.Float64ToFloat64Ptr(0.5),
+			TradeTime:  utils.StringToPtr(tradeTime),
+		}
+	}
+
+	// eachFillPage hands the fills to the exporter one page per fill
+	eachFillPage := func(_ context.Context, _ apiclient.ProductID, _, _ time.Time, fn func([]model.OrderFill) error) error {
+		for idx := range fills {
+			if err := fn(fills[idx : idx+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(apiclient.NewRealClock()).AnyTimes()
+		exporter = NewExporter(client)
+
+		start = time.Date(2023, 2, 24, 0, 0, 0, 0, time.UTC)
+		end = start.Add(24 * time.Hour)
+		fills = []model.OrderFill{
+			newFill("1", 20000, 0.1, "2023-02-24T01:00:00Z"),
+			newFill("2", 20100.5, 0.2, "2023-02-24T02:00:00Z"),
+			// outside of the range
+			newFill("3", 20200, 0.3, "2023-02-25T02:00:00Z"),
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should export fills as csv", func() {
+		client.EXPECT().EachFillPage(ctx, apiclient.ProductID("BTC-USD"), start, end, gomock.Any()).DoAndReturn(eachFillPage)
+
+		buf := &bytes.Buffer{}
+		Expect(exporter.ExportFills(ctx, NewCSVWriter(buf), "BTC-USD", start, end)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(Equal([]string{
+			"entry_id,trade_id,order_id,product_id,side,trade_time,price,size,commission,liquidity_indicator",
+			"1,,order-1,BTC-USD,BUY,2023-02-24T01:00:00Z,20000,0.1,0.5,",
+			"2,,order-1,BTC-USD,BUY,2023-02-24T02:00:00Z,20100.5,0.2,0.5,",
+		}))
+	})
+
+	It("should export fills as json lines", func() {
+		client.EXPECT().EachFillPage(ctx, apiclient.ProductID("BTC-USD"), start, end, gomock.Any()).DoAndReturn(eachFillPage)
+
+		buf := &bytes.Buffer{}
+		Expect(exporter.ExportFills(ctx, NewJSONLWriter(buf), "BTC-USD", start, end)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(HaveLen(2))
+
+		var record map[string]interface{}
+		Expect(json.Unmarshal([]byte(lines[1]), &record)).To(Succeed())
+		Expect(record["entry_id"]).To(Equal("2"))
+		Expect(record["price"]).To(Equal(20100.5))
+		Expect(record["trade_time"]).To(Equal("2023-02-24T02:00:00Z"))
+	})
+
+	It("should round trip orders through the columnar format", func() {
+		status := model.FILLED
+		client.EXPECT().EachOrderPage(ctx, apiclient.ProductID(""), start, end, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ apiclient.ProductID, _, _ time.Time, fn func([]model.Order) error) error {
+				return fn([]model.Order{{
+					OrderId:     utils.StringToPtr("order-1"),
+					ProductId:   utils.StringToPtr("BTC-USD"),
+					Status:      &status,
+					CreatedTime: utils.StringToPtr("2023-02-24T01:00:00Z"),
+					FilledSize:  utils.Float64ToFloat64Ptr(0.1),
+				}})
+			})
+
+		buf := &bytes.Buffer{}
+		Expect(exporter.ExportOrders(ctx, NewColumnarWriter(buf), "", start, end)).To(Succeed())
+
+		schema, rows, err := ReadColumnar(buf)
+		Expect(err).To(BeNil())
+		Expect(schema.Columns).To(Equal(OrderSchema.Columns))
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0][0]).To(Equal("order-1"))
+		Expect(rows[0][5]).To(Equal("FILLED"))
+		Expect(rows[0][6]).To(Equal(start.Add(time.Hour)))
+		Expect(rows[0][9]).To(Equal(0.1))
+	})
+
+	It("should stop at the first page the writer fails on", func() {
+		client.EXPECT().EachFillPage(ctx, apiclient.ProductID("BTC-USD"), start, end, gomock.Any()).DoAndReturn(eachFillPage)
+
+		err := exporter.ExportFills(ctx, NewJSONLWriter(failingWriter{}), "BTC-USD", start, end)
+		Expect(err).To(MatchError("disk full"))
+	})
+
+	It("should write columnar rows in blocks before it is closed", func() {
+		buf := &bytes.Buffer{}
+		w := NewColumnarWriter(buf)
+		Expect(w.WriteHeader(AccountSchema)).To(Succeed())
+		header := buf.Len()
+		Expect(header).To(BeNumerically(">", 0))
+
+		snapshot := time.Date(2023, 2, 24, 0, 0, 0, 0, time.UTC)
+		row := func(idx int) []interface{} {
+			return []interface{}{snapshot, fmt.Sprintf("account-%d", idx), "BTC", float64(idx), 0.0}
+		}
+
+		for idx := 0; idx < 4096; idx++ {
+			Expect(w.WriteRow(row(idx))).To(Succeed())
+		}
+		Expect(buf.Len()).To(BeNumerically(">", header))
+
+		Expect(w.WriteRow(row(4096))).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		schema, rows, err := ReadColumnar(buf)
+		Expect(err).To(BeNil())
+		Expect(schema.Columns).To(Equal(AccountSchema.Columns))
+		Expect(rows).To(HaveLen(4097))
+		Expect(rows[4096]).To(Equal(row(4096)))
+	})
+
+	It("should refuse a columnar file whose row count is more than it holds", func() {
+		buf := &bytes.Buffer{}
+		w := NewColumnarWriter(buf)
+		Expect(w.WriteHeader(FillSchema)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		// swap the trailing row count of 0 for one that claims far more rows than there are bytes
+		data := buf.Bytes()[:buf.Len()-1]
+		data = binary.AppendUvarint(data, math.MaxUint32)
+
+		_, _, err := ReadColumnar(bytes.NewReader(data))
+		Expect(err).To(MatchError(ContainSubstring("row count")))
+	})
+
+	It("should snapshot account balances", func() {
+		client.EXPECT().GetAccounts(ctx).Return([]model.Account{{
+			Uuid:             utils.StringToPtr("account-1"),
+			Currency:         utils.StringToPtr("BTC"),
+			AvailableBalance: &model.AccountAvailableBalance{Value: utils.Float64ToFloat64Ptr(1.5)},
+		}}, nil)
+
+		buf := &bytes.Buffer{}
+		Expect(exporter.ExportAccounts(ctx, NewCSVWriter(buf))).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[1]).To(HaveSuffix(",account-1,BTC,1.5,0"))
+	})
+})
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+)
+
+type ColumnType byte
+
+const (
+	StringColumnType ColumnType = iota + 1
+	FloatColumnType
+	TimeColumnType
+)
+
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Schema columns are only ever appended to so files exported by older versions stay readable
+type Schema struct {
+	Name    string
+	Columns []Column
+}
+
+var OrderSchema = Schema{
+	Name: "orders",
+	Columns: []Column{
+		{Name: "order_id", Type: StringColumnType},
+		{Name: "client_order_id", Type: StringColumnType},
+		{Name: "product_id", Type: StringColumnType},
+		{Name: "side", Type: StringColumnType},
+		{Name: "order_type", Type: StringColumnType},
+		{Name: "status", Type: StringColumnType},
+		{Name: "created_time", Type: TimeColumnType},
+		{Name: "limit_price", Type: FloatColumnType},
+		{Name: "base_size", Type: FloatColumnType},
+		{Name: "filled_size", Type: FloatColumnType},
+		{Name: "average_filled_price", Type: FloatColumnType},
+		{Name: "filled_value", Type: FloatColumnType},
+		{Name: "total_fees", Type: FloatColumnType},
+	},
+}
+
+var FillSchema = Schema{
+	Name: "fills",
+	Columns: []Column{
+		{Name: "entry_id", Type: StringColumnType},
+		{Name: "trade_id", Type: StringColumnType},
+		{Name: "order_id", Type: StringColumnType},
+		{Name: "product_id", Type: StringColumnType},
+		{Name: "side", Type: StringColumnType},
+		{Name: "trade_time", Type: TimeColumnType},
+		{Name: "price", Type: FloatColumnType},
+		{Name: "size", Type: FloatColumnType},
+		{Name: "commission", Type: FloatColumnType},
+		{Name: "liquidity_indicator", Type: StringColumnType},
+	},
+}
+
+var AccountSchema = Schema{
+	Name: "accounts",
+	Columns: []Column{
+		{Name: "snapshot_time", Type: TimeColumnType},
+		{Name: "uuid", Type: StringColumnType},
+		{Name: "currency", Type: StringColumnType},
+		{Name: "available_balance", Type: FloatColumnType},
+		{Name: "hold", Type: FloatColumnType},
+	},
+}
+
+func orderRow(order *cbadvmodel.Order) []interface{} {
+	var limitPrice, baseSize float64
+	if limit := order.GetOrderConfiguration().LimitLimitGtc; limit != nil {
+		limitPrice, baseSize = limit.GetLimitPrice(), limit.GetBaseSize()
+	}
+
+	return []interface{}{
+		order.GetOrderId(),
+		order.GetClientOrderId(),
+		order.GetProductId(),
+		order.GetSide(),
+		order.GetOrderType(),
+		string(order.GetStatus()),
+		parseTime(order.GetCreatedTime()),
+		limitPrice,
+		baseSize,
+		order.GetFilledSize(),
+		order.GetAverageFilledPrice(),
+		order.GetFilledValue(),
+		order.GetTotalFees(),
+	}
+}
+
+func fillRow(fill *cbadvmodel.OrderFill) []interface{} {
+	return []interface{}{
+		fill.GetEntryId(),
+		fill.GetTradeId(),
+		fill.GetOrderId(),
+		fill.GetProductId(),
+		fill.GetSide(),
+		parseTime(fill.GetTradeTime()),
+		fill.GetPrice(),
+		fill.GetSize(),
+		fill.GetCommission(),
+		fill.GetLiquidityIndicator(),
+	}
+}
+
+func accountRow(snapshot time.Time, account *cbadvmodel.Account) []interface{} {
+	var available, hold float64
+	if account.AvailableBalance != nil {
+		available = account.AvailableBalance.GetValue()
+	}
+	if account.Hold != nil {
+		hold = account.Hold.GetValue()
+	}
+
+	return []interface{}{
+		snapshot,
+		account.GetUuid(),
+		account.GetCurrency(),
+		available,
+		hold,
+	}
+}
+
+func parseTime(str string) time.Time {
+	tm, _ := time.Parse(time.RFC3339Nano, str)
+	return tm
+}
@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type RecordWriter interface {
+	WriteHeader(schema Schema) error
+	WriteRow(row []interface{}) error
+	// Close flushes anything buffered. It does not close the underlying io.Writer
+	Close() error
+}
+
+func NewCSVWriter(w io.Writer) RecordWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	schema Schema
+}
+
+func (w *csvWriter) WriteHeader(schema Schema) error {
+	w.schema = schema
+
+	names := make([]string, len(schema.Columns))
+	for idx, col := range schema.Columns {
+		names[idx] = col.Name
+	}
+
+	return w.writer.Write(names)
+}
+
+func (w *csvWriter) WriteRow(row []interface{}) error {
+	if len(row) != len(w.schema.Columns) {
+		return fmt.Errorf("row has %d values but schema '%s' has %d columns", len(row), w.schema.Name, len(w.schema.Columns))
+	}
+
+	record := make([]string, len(row))
+	for idx, val := range row {
+		switch v := val.(type) {
+		case string:
+			record[idx] = v
+		case float64:
+			record[idx] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			if !v.IsZero() {
+				record[idx] = v.UTC().Format(time.RFC3339Nano)
+			}
+		default:
+			return fmt.Errorf("unsupported value type %T in column '%s'", val, w.schema.Columns[idx].Name)
+		}
+	}
+
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func NewJSONLWriter(w io.Writer) RecordWriter {
+	return &jsonlWriter{encoder: json.NewEncoder(w)}
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+	schema  Schema
+}
+
+func (w *jsonlWriter) WriteHeader(schema Schema) error {
+	w.schema = schema
+	return nil
+}
+
+func (w *jsonlWriter) WriteRow(row []interface{}) error {
+	if len(row) != len(w.schema.Columns) {
+		return fmt.Errorf("row has %d values but schema '%s' has %d columns", len(row), w.schema.Name, len(w.schema.Columns))
+	}
+
+	record := make(map[string]interface{}, len(row))
+	for idx, val := range row {
+		if tm, ok := val.(time.Time); ok {
+			if tm.IsZero() {
+				val = nil
+			} else {
+				val = tm.UTC().Format(time.RFC3339Nano)
+			}
+		}
+		record[w.schema.Columns[idx].Name] = val
+	}
+
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Close() error {
+	return nil
+}
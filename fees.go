@@ -0,0 +1,100 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type FeeTier struct {
+	PricingTier string
+	// 0.4% will be 0.004
+	MakerFeeRate float64
+	TakerFeeRate float64
+	// Volume30Day is the trailing 30 day volume in USD that decides the tier
+	Volume30Day float64
+	TotalFees   float64
+}
+
+type FeeEstimate struct {
+	FeeRate  float64
+	Notional float64
+	Fee      float64
+	// NetProceeds is the change to the quote balance. Buys are negative (notional + fee) and sells
+	// are positive (notional - fee)
+	NetProceeds float64
+}
+
+type transactionSummaryResponse struct {
+	TotalVolume float64 `json:"total_volume"`
+	TotalFees   float64 `json:"total_fees"`
+	FeeTier     struct {
+		PricingTier  string  `json:"pricing_tier"`
+		TakerFeeRate float64 `json:"taker_fee_rate,string"`
+		MakerFeeRate float64 `json:"maker_fee_rate,string"`
+	} `json:"fee_tier"`
+}
+
+func (c *apiclient) GetFeeTier(ctx context.Context) (_ *FeeTier, err error) {
+	ctx, span := c.startSpan(ctx, "GetFeeTier")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	query := url.Values{}
+	query.Set("product_type", "SPOT")
+
+	var res transactionSummaryResponse
+	if err := c.doRequest(ctx, "GetTransactionSummary", http.MethodGet, "/brokerage/transaction_summary?"+query.Encode(), nil, &res); err != nil {
+		return nil, err
+	}
+
+	return &FeeTier{
+		PricingTier:  res.FeeTier.PricingTier,
+		MakerFeeRate: res.FeeTier.MakerFeeRate,
+		TakerFeeRate: res.FeeTier.TakerFeeRate,
+		Volume30Day:  res.TotalVolume,
+		TotalFees:    res.TotalFees,
+	}, nil
+}
+
+// FeeRate
+//
+//	maker - true when the order is expected to rest on the book
+func (t *FeeTier) FeeRate(maker bool) float64 {
+	if maker {
+		return t.MakerFeeRate
+	}
+
+	return t.TakerFeeRate
+}
+
+func (t *FeeTier) EstimateFee(side sideType, price, size float64, maker bool) FeeEstimate {
+	est := FeeEstimate{FeeRate: t.FeeRate(maker), Notional: price * size}
+	est.Fee = est.Notional * est.FeeRate
+
+	if side == BuySideType {
+		est.NetProceeds = -(est.Notional + est.Fee)
+	} else {
+		est.NetProceeds = est.Notional - est.Fee
+	}
+
+	return est
+}
+
+// MaxAffordableBaseSize is the largest buy that quoteBalance covers once fees are added. It's rounded
+// down to baseIncrement when one is given
+func (t *FeeTier) MaxAffordableBaseSize(quoteBalance, price float64, maker bool, baseIncrement float64) float64 {
+	if price <= 0 || quoteBalance <= 0 {
+		return 0
+	}
+
+	size := quoteBalance / (price * (1 + t.FeeRate(maker)))
+	if baseIncrement > 0 {
+		size = utils.FloorToIncrement(size, baseIncrement)
+	}
+
+	return size
+}
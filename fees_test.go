@@ -0,0 +1,80 @@
+package apiclient_test
+
+import (
+	"net/http"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("fees", func() {
+	tier := &FeeTier{MakerFeeRate: 0.004, TakerFeeRate: 0.006}
+
+	Context("GetFeeTier", func() {
+		var ctrl *gomock.Controller
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should read the fee tier from the transaction summary", func() {
+			cbClient := mocks.NewMockCoinbaseClient(ctrl)
+			cbClient.EXPECT().CheckAuthentication(gomock.Any(), gomock.Any())
+			cbClient.EXPECT().HttpClient().Return(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				Expect(req.URL.Path).To(Equal("/api/v3/brokerage/transaction_summary"))
+				Expect(req.URL.Query().Get("product_type")).To(Equal("SPOT"))
+				return jsonResponse(200, `{
+					"total_volume": 12500.5,
+					"total_fees": 61.25,
+					"fee_tier": {"pricing_tier": "$10K-$50K", "taker_fee_rate": "0.004", "maker_fee_rate": "0.0025"}
+				}`), nil
+			})})
+
+			cont, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false)
+			Expect(err).To(BeNil())
+
+			feeTier, err := cont.GetFeeTier(ctx)
+			Expect(err).To(BeNil())
+			Expect(*feeTier).To(Equal(FeeTier{
+				PricingTier:  "$10K-$50K",
+				MakerFeeRate: 0.0025,
+				TakerFeeRate: 0.004,
+				Volume30Day:  12500.5,
+				TotalFees:    61.25,
+			}))
+		})
+	})
+
+	Context("EstimateFee", func() {
+		It("should add the fee to the cost of a buy", func() {
+			est := tier.EstimateFee(BuySideType, 100, 2, true)
+			Expect(est.Fee).To(BeNumerically("~", 0.8))
+			Expect(est.NetProceeds).To(BeNumerically("~", -200.8))
+		})
+
+		It("should take the fee out of the proceeds of a sell", func() {
+			est := tier.EstimateFee(SellSideType, 100, 2, false)
+			Expect(est.Fee).To(BeNumerically("~", 1.2))
+			Expect(est.NetProceeds).To(BeNumerically("~", 198.8))
+		})
+	})
+
+	Context("MaxAffordableBaseSize", func() {
+		It("should leave room for the fee and round down to the increment", func() {
+			size := tier.MaxAffordableBaseSize(100, 10, false, 0.01)
+			Expect(size).To(Equal(9.94))
+
+			est := tier.EstimateFee(BuySideType, 10, size, false)
+			Expect(-est.NetProceeds).To(BeNumerically("<=", 100))
+		})
+	})
+})
@@ -0,0 +1,357 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type GridSpacing string
+
+const (
+	// ArithmeticGridSpacing puts the same price difference between every level
+	ArithmeticGridSpacing GridSpacing = "ARITHMETIC"
+	// GeometricGridSpacing puts the same percentage between every level
+	GeometricGridSpacing GridSpacing = "GEOMETRIC"
+)
+
+type GridParams struct {
+	// ID is used for every order in the grid. A uuid is generated when it's empty
+	ID          string
+	BaseTicker  string `validate:"required"`
+	QuoteTicker string `validate:"required"`
+	// LowerPrice and UpperPrice default to the 24 hour low and high from GetProductMarketData
+	LowerPrice float64
+	UpperPrice float64
+	// Levels is the number of gaps between the bounds so there are Levels + 1 prices
+	Levels           int     `validate:"required"`
+	QuantityPerLevel float64 `validate:"required"`
+	// Spacing defaults to ArithmeticGridSpacing
+	Spacing GridSpacing
+	// PollInterval defaults to 5 seconds
+	PollInterval time.Duration
+	// OnError gets every sync that failed and is called from Run's goroutine
+	OnError func(err error)
+}
+
+type GridLevel struct {
+	Price   float64
+	Side    sideType
+	OrderID string
+	// Placements counts the orders placed at this level so every one gets its own client order id
+	Placements int
+	// Pending is set while the order for Side couldn't be placed yet. Every Sync tries it again
+	Pending bool
+}
+
+type GridStatus struct {
+	Levels    []GridLevel
+	BuyFills  int
+	SellFills int
+	// GridProfit is one level's spread for every filled sell less every fee paid, in the quote currency
+	GridProfit float64
+	Fees       float64
+	// Skipped counts levels left empty at the start because the balance ran out
+	Skipped int
+}
+
+type GridEngine struct {
+	client ApiClient
+	params GridParams
+	clock  Clock
+	mutex  *sync.Mutex
+	status GridStatus
+}
+
+func NewGridEngine(client ApiClient, params GridParams) (*GridEngine, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	if params.Levels < 2 {
+		return nil, errors.New("a grid needs at least 2 levels")
+	}
+	if params.LowerPrice < 0 || (params.UpperPrice > 0 && params.UpperPrice <= params.LowerPrice) {
+		return nil, fmt.Errorf("invalid grid bounds %f-%f", params.LowerPrice, params.UpperPrice)
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+	if params.Spacing == "" {
+		params.Spacing = ArithmeticGridSpacing
+	}
+	if params.PollInterval <= 0 {
+		params.PollInterval = time.Second * 5
+	}
+
+	return &GridEngine{client: client, params: params, clock: clockOf(client), mutex: &sync.Mutex{}}, nil
+}
+
+func (g *GridEngine) Status() GridStatus {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	status := g.status
+	status.Levels = make([]GridLevel, len(g.status.Levels))
+	copy(status.Levels, g.status.Levels)
+
+	return status
+}
+
+// Run starts the grid, syncs it every poll interval and cancels every grid order once ctx is cancelled. A failed
+// sync goes to OnError and is tried again on the next tick. When the grid can't be started whatever it placed is
+// cancelled
+func (g *GridEngine) Run(ctx context.Context) error {
+	if err := g.Start(ctx); err != nil {
+		// nothing would be left to manage the levels that were placed
+		if stopErr := g.Stop(context.Background()); stopErr != nil {
+			return fmt.Errorf("%w and the placed orders couldn't be cancelled: %s", err, stopErr.Error())
+		}
+		return err
+	}
+
+	ticker := g.clock.NewTicker(g.params.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := g.Stop(context.Background()); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C():
+		}
+
+		if err := g.Sync(ctx); err != nil && ctx.Err() == nil && g.params.OnError != nil {
+			g.params.OnError(err)
+		}
+	}
+}
+
+// Start lays out the levels and places buys below the current price and sells above it. The levels closest to
+// the price are funded first
+func (g *GridEngine) Start(ctx context.Context) error {
+	high, low, price, _, err := g.client.GetProductMarketData(ctx, NewProductID(g.params.BaseTicker, g.params.QuoteTicker), nil)
+	if err != nil {
+		return err
+	}
+
+	lower, upper := g.params.LowerPrice, g.params.UpperPrice
+	if lower == 0 {
+		lower = low
+	}
+	if upper == 0 {
+		upper = high
+	}
+	if lower <= 0 || upper <= lower {
+		return fmt.Errorf("invalid grid bounds %f-%f", lower, upper)
+	} else if price <= lower || price >= upper {
+		return fmt.Errorf("current price %f is outside of the grid %f-%f", price, lower, upper)
+	}
+
+	product, err := g.client.GetProduct(ctx, NewProductID(g.params.BaseTicker, g.params.QuoteTicker))
+	if err != nil {
+		return err
+	}
+
+	quantity := g.params.QuantityPerLevel
+	if product.GetBaseIncrement() > 0 {
+		quantity = utils.FloorToIncrement(quantity, product.GetBaseIncrement())
+	}
+	if quantity <= 0 || quantity < product.GetBaseMinSize() {
+		return fmt.Errorf("quantity per level %f is below the minimum size %f", quantity, product.GetBaseMinSize())
+	}
+	g.params.QuantityPerLevel = quantity
+
+	_, _, baseBalance, quoteBalance, err := g.client.GetCurrentWallentAmount(ctx, NewProductID(g.params.BaseTicker, g.params.QuoteTicker))
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.status = GridStatus{Levels: g.levels(lower, upper, product.GetQuoteIncrement())}
+
+	// nearest to the price first so a short balance still leaves a working grid around it
+	order := make([]int, len(g.status.Levels))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return math.Abs(g.status.Levels[order[i]].Price-price) < math.Abs(g.status.Levels[order[j]].Price-price)
+	})
+
+	// the level closest to the price stays empty so every buy has a sell one level above it
+	for _, idx := range order[1:] {
+		level := &g.status.Levels[idx]
+
+		if level.Price < price {
+			if cost := level.Price * quantity; cost <= quoteBalance {
+				quoteBalance -= cost
+				level.Side = BuySideType
+			}
+		} else if quantity <= baseBalance {
+			baseBalance -= quantity
+			level.Side = SellSideType
+		}
+
+		if level.Side == "" {
+			g.status.Skipped++
+			continue
+		}
+
+		if err = g.place(ctx, idx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sync replaces every filled order with the opposite side one level away. A replacement that couldn't be placed
+// stays pending on its level and is placed first on the next Sync
+func (g *GridEngine) Sync(ctx context.Context) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	// orders placed during this sync are checked on the next one
+	placed := []int{}
+	for idx, level := range g.status.Levels {
+		if level.OrderID != "" {
+			placed = append(placed, idx)
+		}
+	}
+
+	for idx := range g.status.Levels {
+		if !g.status.Levels[idx].Pending {
+			continue
+		}
+		if err := g.place(ctx, idx); err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range placed {
+		level := &g.status.Levels[idx]
+
+		order, err := g.client.GetOrder(ctx, level.OrderID)
+		if err != nil {
+			return err
+		}
+
+		switch order.GetStatus() {
+		case cbadvmodel.OPEN:
+			continue
+		case cbadvmodel.FILLED:
+		default:
+			// someone pulled the order so leave the level empty
+			level.OrderID, level.Side = "", ""
+			continue
+		}
+
+		g.status.Fees += order.GetTotalFees()
+		g.status.GridProfit -= order.GetTotalFees()
+
+		next := idx + 1
+		if level.Side == SellSideType {
+			g.status.SellFills++
+			if idx > 0 {
+				g.status.GridProfit += (level.Price - g.status.Levels[idx-1].Price) * order.GetFilledSize()
+			}
+			next = idx - 1
+		} else {
+			g.status.BuyFills++
+		}
+
+		side := level.Side
+		level.OrderID, level.Side = "", ""
+
+		if next < 0 || next >= len(g.status.Levels) || g.status.Levels[next].OrderID != "" || g.status.Levels[next].Pending {
+			continue
+		}
+
+		if side == BuySideType {
+			g.status.Levels[next].Side = SellSideType
+		} else {
+			g.status.Levels[next].Side = BuySideType
+		}
+		if err = g.place(ctx, next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop cancels every open grid order
+func (g *GridEngine) Stop(ctx context.Context) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	orderIDs := []string{}
+	for _, level := range g.status.Levels {
+		if level.OrderID != "" {
+			orderIDs = append(orderIDs, level.OrderID)
+		}
+	}
+
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	return g.client.CancelOrders(ctx, orderIDs...)
+}
+
+// place must be called with the mutex held. The level is left pending when the order can't be placed
+func (g *GridEngine) place(ctx context.Context, idx int) error {
+	level := &g.status.Levels[idx]
+
+	order, err := g.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          fmt.Sprintf("%s-%d-%d", g.params.ID, idx, level.Placements),
+		BaseTicker:  g.params.BaseTicker,
+		QuoteTicker: g.params.QuoteTicker,
+		Price:       level.Price,
+		Quantity:    g.params.QuantityPerLevel,
+		Side:        level.Side,
+	})
+	if err != nil {
+		level.Pending = true
+		return err
+	}
+
+	level.OrderID = order.GetOrderId()
+	level.Placements++
+	level.Pending = false
+
+	return nil
+}
+
+func (g *GridEngine) levels(lower, upper, quoteIncrement float64) []GridLevel {
+	levels := make([]GridLevel, g.params.Levels+1)
+
+	for idx := range levels {
+		price := lower + (upper-lower)*float64(idx)/float64(g.params.Levels)
+		if g.params.Spacing == GeometricGridSpacing {
+			price = lower * math.Pow(upper/lower, float64(idx)/float64(g.params.Levels))
+		}
+
+		if quoteIncrement > 0 {
+			price = math.Round(price/quoteIncrement) * quoteIncrement
+		}
+
+		levels[idx] = GridLevel{Price: price}
+	}
+
+	return levels
+}
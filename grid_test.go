@@ -0,0 +1,179 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GridEngine", func() {
+	var (
+		ctrl    *gomock.Controller
+		client  *mocks.MockApiClient
+		placed  map[string]*CreateLimitMarketOrderParams
+		failing map[string]error
+		clock   Clock
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		clock = NewRealClock()
+		client.EXPECT().Clock().DoAndReturn(func() Clock { return clock }).AnyTimes()
+		placed = map[string]*CreateLimitMarketOrderParams{}
+		failing = map[string]error{}
+
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(120.0, 80.0, 101.0, 0.0, nil)
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateLimitMarketOrderParams) (*model.Order, error) {
+				if err, exists := failing[p.ID]; exists {
+					delete(failing, p.ID)
+					return nil, err
+				}
+				placed[p.ID] = p
+				return &model.Order{OrderId: utils.StringToPtr(p.ID)}, nil
+			},
+		).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should ladder orders around the price and trade the spread", func() {
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("BTC-USD")).Return(&model.GetProductResponse{
+			BaseIncrement:  utils.Float64ToFloat64Ptr(0.01),
+			QuoteIncrement: utils.Float64ToFloat64Ptr(0.01),
+			BaseMinSize:    utils.Float64ToFloat64Ptr(0.01),
+		}, nil)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 1.0, 200.0, nil)
+
+		grid, err := NewGridEngine(client, GridParams{
+			ID: "grid", BaseTicker: "BTC", QuoteTicker: "USD", LowerPrice: 90, UpperPrice: 110, Levels: 4, QuantityPerLevel: 1.004,
+		})
+		Expect(err).To(BeNil())
+		Expect(grid.Start(ctx)).To(Succeed())
+
+		status := grid.Status()
+		Expect(status.Levels).To(HaveLen(5))
+		Expect(status.Levels[0]).To(Equal(GridLevel{Price: 90, Side: BuySideType, OrderID: "grid-0-0", Placements: 1}))
+		Expect(status.Levels[1].Side).To(Equal(BuySideType))
+		// closest to the price
+		Expect(status.Levels[2].OrderID).To(BeEmpty())
+		Expect(status.Levels[3].Side).To(Equal(SellSideType))
+		// only 1 BTC to sell
+		Expect(status.Levels[4].OrderID).To(BeEmpty())
+		Expect(status.Skipped).To(Equal(1))
+		Expect(placed["grid-0-0"].Quantity).To(Equal(1.0))
+
+		fees := func(order *model.Order) *model.Order {
+			order.TotalFees = utils.Float64ToFloat64Ptr(0.1)
+			return order
+		}
+		client.EXPECT().GetOrder(gomock.Any(), "grid-0-0").Return(newOrder("grid-0-0", model.OPEN, 0, 0), nil)
+		client.EXPECT().GetOrder(gomock.Any(), "grid-1-0").Return(fees(newOrder("grid-1-0", model.FILLED, 1, 95)), nil)
+		client.EXPECT().GetOrder(gomock.Any(), "grid-3-0").Return(fees(newOrder("grid-3-0", model.FILLED, 1, 105)), nil)
+
+		Expect(grid.Sync(ctx)).To(Succeed())
+
+		status = grid.Status()
+		Expect(status.Levels[1].OrderID).To(BeEmpty())
+		Expect(status.Levels[2]).To(Equal(GridLevel{Price: 100, Side: SellSideType, OrderID: "grid-2-0", Placements: 1}))
+		Expect(status.Levels[3].OrderID).To(BeEmpty())
+		Expect(status.BuyFills).To(Equal(1))
+		Expect(status.SellFills).To(Equal(1))
+		Expect(status.GridProfit).To(BeNumerically("~", 4.8))
+
+		client.EXPECT().CancelOrders(gomock.Any(), "grid-0-0", "grid-2-0")
+		Expect(grid.Stop(ctx)).To(Succeed())
+	})
+
+	It("should pass a failed sync to OnError and cancel the grid once stopped", func() {
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("BTC-USD")).Return(&model.GetProductResponse{
+			BaseIncrement:  utils.Float64ToFloat64Ptr(0.01),
+			QuoteIncrement: utils.Float64ToFloat64Ptr(0.01),
+		}, nil)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 1.0, 200.0, nil)
+		client.EXPECT().GetOrder(gomock.Any(), "grid-0-0").Return(nil, errors.New("i/o timeout"))
+		client.EXPECT().CancelOrders(gomock.Any(), "grid-0-0", "grid-1-0", "grid-3-0")
+
+		cctx, cancel := context.WithCancel(ctx)
+		fakeClock := NewFakeClock(time.Now())
+		clock = fakeClock
+		errs := []error{}
+
+		grid, err := NewGridEngine(client, GridParams{
+			ID: "grid", BaseTicker: "BTC", QuoteTicker: "USD", LowerPrice: 90, UpperPrice: 110, Levels: 4, QuantityPerLevel: 1,
+			OnError: func(err error) {
+				errs = append(errs, err)
+				cancel()
+			},
+		})
+		Expect(err).To(BeNil())
+
+		done := make(chan error, 1)
+		go func() { done <- grid.Run(cctx) }()
+
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(5 * time.Second)
+
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		Expect(errs).To(ConsistOf(MatchError("i/o timeout")))
+	})
+
+	It("should place a replacement that failed on the next sync", func() {
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("BTC-USD")).Return(&model.GetProductResponse{}, nil)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 1.0, 200.0, nil)
+
+		grid, err := NewGridEngine(client, GridParams{
+			ID: "grid", BaseTicker: "BTC", QuoteTicker: "USD", LowerPrice: 90, UpperPrice: 110, Levels: 4, QuantityPerLevel: 1,
+		})
+		Expect(err).To(BeNil())
+		Expect(grid.Start(ctx)).To(Succeed())
+
+		failing["grid-2-0"] = errors.New("i/o timeout")
+		client.EXPECT().GetOrder(gomock.Any(), "grid-0-0").Return(newOrder("grid-0-0", model.OPEN, 0, 0), nil).Times(2)
+		client.EXPECT().GetOrder(gomock.Any(), "grid-1-0").Return(newOrder("grid-1-0", model.FILLED, 1, 95), nil)
+
+		Expect(grid.Sync(ctx)).To(MatchError("i/o timeout"))
+		Expect(grid.Status().Levels[2]).To(Equal(GridLevel{Price: 100, Side: SellSideType, Pending: true}))
+
+		client.EXPECT().GetOrder(gomock.Any(), "grid-3-0").Return(newOrder("grid-3-0", model.OPEN, 0, 0), nil)
+
+		Expect(grid.Sync(ctx)).To(Succeed())
+		status := grid.Status()
+		Expect(status.Levels[2]).To(Equal(GridLevel{Price: 100, Side: SellSideType, OrderID: "grid-2-0", Placements: 1}))
+		Expect(status.BuyFills).To(Equal(1))
+		Expect(placed).To(HaveKey("grid-2-0"))
+	})
+
+	It("should cancel whatever it placed when it can't start", func() {
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("BTC-USD")).Return(&model.GetProductResponse{}, nil)
+		client.EXPECT().GetCurrentWallentAmount(gomock.Any(), ProductID("BTC-USD")).Return(nil, nil, 1.0, 200.0, nil)
+		client.EXPECT().CancelOrders(gomock.Any(), "grid-1-0", "grid-3-0")
+
+		failing["grid-0-0"] = errors.New("i/o timeout")
+		grid, err := NewGridEngine(client, GridParams{
+			ID: "grid", BaseTicker: "BTC", QuoteTicker: "USD", LowerPrice: 90, UpperPrice: 110, Levels: 4, QuantityPerLevel: 1,
+		})
+		Expect(err).To(BeNil())
+		Expect(grid.Run(ctx)).To(MatchError("i/o timeout"))
+	})
+
+	It("should refuse to start when the price is outside of the grid", func() {
+		grid, err := NewGridEngine(client, GridParams{
+			BaseTicker: "BTC", QuoteTicker: "USD", LowerPrice: 102, UpperPrice: 110, Levels: 4, QuantityPerLevel: 1,
+		})
+		Expect(err).To(BeNil())
+		Expect(grid.Start(ctx)).NotTo(Succeed())
+	})
+})
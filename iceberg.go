@@ -0,0 +1,233 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type IcebergParams struct {
+	// ID is the parent intent. Every clip uses ID-<clip> as its own intent. A uuid is generated when it's
+	// empty
+	ID          string
+	BaseTicker  string   `validate:"required"`
+	QuoteTicker string   `validate:"required"`
+	Price       float64  `validate:"required"`
+	Quantity    float64  `validate:"required"`
+	DisplaySize float64  `validate:"required"`
+	Side        sideType `validate:"required"`
+	// PriceRandomizationPercentage moves every clip a random amount up to this far away from Price so the clips
+	// are harder to spot. Buys only move down and sells only move up so Price is never crossed. 0.1% will be 0.1
+	PriceRandomizationPercentage float64
+	// QuoteIncrement and BaseIncrement round the clip price and size down. 0 leaves them alone
+	QuoteIncrement float64
+	BaseIncrement  float64
+	// PollInterval is how often the visible clip is checked. Defaults to 5 seconds
+	PollInterval time.Duration
+}
+
+type IcebergStatus struct {
+	ID                string
+	VisibleOrderID    string
+	Clips             int
+	FilledQuantity    float64
+	RemainingQuantity float64
+	// AveragePrice is the size weighted fill price of every clip
+	AveragePrice float64
+	Done         bool
+}
+
+type IcebergManager struct {
+	client ApiClient
+	params IcebergParams
+	clock  Clock
+	random func() float64
+	mutex  *sync.RWMutex
+	status IcebergStatus
+}
+
+func NewIcebergManager(client ApiClient, params IcebergParams) (*IcebergManager, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	if params.DisplaySize > params.Quantity {
+		return nil, fmt.Errorf("display size %f is larger than the quantity %f", params.DisplaySize, params.Quantity)
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	if params.PollInterval <= 0 {
+		params.PollInterval = time.Second * 5
+	}
+
+	return &IcebergManager{
+		client: client,
+		params: params,
+		clock:  clockOf(client),
+		random: rand.Float64,
+		mutex:  &sync.RWMutex{},
+		status: IcebergStatus{ID: params.ID, RemainingQuantity: params.Quantity},
+	}, nil
+}
+
+func (m *IcebergManager) Status() IcebergStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.status
+}
+
+// Run keeps one clip on the book until the whole quantity fills. When ctx is cancelled or the clip can't be
+// checked on the visible clip is cancelled and whatever it filled is counted before the error is returned. A
+// clip that couldn't be cancelled stays visible and the next Run watches it instead of placing another
+func (m *IcebergManager) Run(ctx context.Context) error {
+	for {
+		status := m.Status()
+		if status.Done {
+			return nil
+		}
+
+		if status.VisibleOrderID != "" {
+			if err := m.waitForClip(ctx, status.VisibleOrderID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		quantity := math.Min(m.params.DisplaySize, status.RemainingQuantity)
+		if m.params.BaseIncrement > 0 {
+			quantity = utils.FloorToIncrement(quantity, m.params.BaseIncrement)
+		}
+		if quantity <= 0 {
+			m.finish()
+			return nil
+		}
+
+		order, err := m.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			ID:          fmt.Sprintf("%s-%d", m.params.ID, status.Clips),
+			BaseTicker:  m.params.BaseTicker,
+			QuoteTicker: m.params.QuoteTicker,
+			Price:       m.clipPrice(),
+			Quantity:    quantity,
+			Side:        m.params.Side,
+		})
+		if err != nil {
+			return err
+		}
+
+		m.mutex.Lock()
+		m.status.VisibleOrderID = order.GetOrderId()
+		m.status.Clips++
+		m.mutex.Unlock()
+
+		if err = m.waitForClip(ctx, order.GetOrderId()); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *IcebergManager) waitForClip(ctx context.Context, orderID string) error {
+	ticker := m.clock.NewTicker(m.params.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C():
+		}
+
+		// checked outside of the select so a cancellation always wins over a tick
+		if ctx.Err() != nil {
+			if err := m.settleClip(ctx, orderID); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+
+		order, err := m.client.GetOrder(ctx, orderID)
+		if err != nil {
+			// pulled so it can't fill while nobody is watching it
+			if settleErr := m.settleClip(ctx, orderID); settleErr != nil {
+				return fmt.Errorf("%w and it couldn't be cancelled: %s", err, settleErr.Error())
+			}
+			return err
+		}
+
+		switch order.GetStatus() {
+		case cbadvmodel.OPEN:
+			continue
+		case cbadvmodel.FILLED:
+			m.record(order)
+			return nil
+		default:
+			m.record(order)
+			return fmt.Errorf("iceberg clip '%s' ended with status '%s'", orderID, order.GetStatus())
+		}
+	}
+}
+
+// settleClip counts the clip once it's no longer open. Otherwise it stays the visible clip
+func (m *IcebergManager) settleClip(ctx context.Context, orderID string) error {
+	order, err := settleOrder(ctx, m.client, orderID)
+	if order != nil && order.GetStatus() != cbadvmodel.OPEN {
+		m.record(order)
+	}
+
+	return err
+}
+
+func (m *IcebergManager) clipPrice() float64 {
+	price := m.params.Price
+
+	if m.params.PriceRandomizationPercentage > 0 {
+		offset := price * m.random() * m.params.PriceRandomizationPercentage / 100
+		if m.params.Side == BuySideType {
+			price -= offset
+		} else {
+			price += offset
+		}
+	}
+
+	if m.params.QuoteIncrement > 0 {
+		if m.params.Side == BuySideType {
+			price = utils.FloorToIncrement(price, m.params.QuoteIncrement)
+		} else {
+			// rounding a sell down could put it under Price
+			price = math.Ceil(price/m.params.QuoteIncrement-1e-9) * m.params.QuoteIncrement
+		}
+	}
+
+	return price
+}
+
+func (m *IcebergManager) record(order *cbadvmodel.Order) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	filled := order.GetFilledSize()
+	if total := m.status.FilledQuantity + filled; total > 0 {
+		m.status.AveragePrice = (m.status.AveragePrice*m.status.FilledQuantity + order.GetAverageFilledPrice()*filled) / total
+	}
+
+	m.status.FilledQuantity += filled
+	m.status.RemainingQuantity = math.Max(m.params.Quantity-m.status.FilledQuantity, 0)
+	m.status.VisibleOrderID = ""
+	m.status.Done = m.status.RemainingQuantity <= 0
+}
+
+func (m *IcebergManager) finish() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.status.Done = true
+}
@@ -0,0 +1,164 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IcebergManager", func() {
+	var (
+		ctrl   *gomock.Controller
+		client *mocks.MockApiClient
+		params IcebergParams
+		placed []*CreateLimitMarketOrderParams
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(NewRealClock()).AnyTimes()
+		placed = nil
+
+		params = IcebergParams{
+			ID:           "iceberg",
+			BaseTicker:   "BTC",
+			QuoteTicker:  "USD",
+			Price:        100,
+			Quantity:     2.5,
+			DisplaySize:  1,
+			Side:         SellSideType,
+			PollInterval: time.Millisecond,
+		}
+
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateLimitMarketOrderParams) (*model.Order, error) {
+				placed = append(placed, p)
+				return &model.Order{OrderId: utils.StringToPtr(p.ID)}, nil
+			},
+		).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should reject a display size larger than the quantity", func() {
+		params.DisplaySize = 3
+		_, err := NewIcebergManager(client, params)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should replenish the clip until everything fills", func() {
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.OPEN, 0.2, 100), nil),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.FILLED, 1, 100), nil),
+		)
+		client.EXPECT().GetOrder(gomock.Any(), "iceberg-1").Return(newOrder("iceberg-1", model.FILLED, 1, 101), nil)
+		client.EXPECT().GetOrder(gomock.Any(), "iceberg-2").Return(newOrder("iceberg-2", model.FILLED, 0.5, 102), nil)
+
+		manager, err := NewIcebergManager(client, params)
+		Expect(err).To(BeNil())
+		Expect(manager.Run(ctx)).To(Succeed())
+
+		Expect(placed).To(HaveLen(3))
+		Expect(placed[0].Quantity).To(Equal(1.0))
+		Expect(placed[2].Quantity).To(Equal(0.5))
+
+		status := manager.Status()
+		Expect(status.Done).To(BeTrue())
+		Expect(status.Clips).To(Equal(3))
+		Expect(status.FilledQuantity).To(BeNumerically("~", 2.5))
+		Expect(status.RemainingQuantity).To(BeNumerically("~", 0))
+		Expect(status.AveragePrice).To(BeNumerically("~", 100.8))
+	})
+
+	It("should keep randomized prices on the right side of the limit", func() {
+		params.PriceRandomizationPercentage = 1
+		params.QuoteIncrement = 0.01
+		params.Quantity, params.DisplaySize = 1, 1
+		client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.FILLED, 1, 100.5), nil)
+
+		manager, err := NewIcebergManager(client, params)
+		Expect(err).To(BeNil())
+		Expect(manager.Run(ctx)).To(Succeed())
+
+		Expect(placed[0].Price).To(BeNumerically(">=", 100))
+		Expect(placed[0].Price).To(BeNumerically("<=", 101))
+	})
+
+	It("should cancel the visible clip when the context is cancelled", func() {
+		cctx, cancel := context.WithCancel(ctx)
+
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").DoAndReturn(func(context.Context, string) (*model.Order, error) {
+				cancel()
+				return newOrder("iceberg-0", model.OPEN, 0.4, 100), nil
+			}),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.OPEN, 0.4, 100), nil),
+			client.EXPECT().CancelOrders(gomock.Any(), "iceberg-0").Return(nil),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.CANCELLED, 0.4, 100), nil),
+		)
+
+		manager, err := NewIcebergManager(client, params)
+		Expect(err).To(BeNil())
+
+		err = manager.Run(cctx)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+
+		status := manager.Status()
+		Expect(status.Done).To(BeFalse())
+		Expect(status.VisibleOrderID).To(BeEmpty())
+		Expect(status.FilledQuantity).To(BeNumerically("~", 0.4))
+		Expect(status.RemainingQuantity).To(BeNumerically("~", 2.1))
+	})
+
+	It("should cancel the visible clip when it can't be checked on", func() {
+		params.Quantity = 1
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(nil, errors.New("i/o timeout")),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.OPEN, 0.3, 100), nil),
+			client.EXPECT().CancelOrders(gomock.Any(), "iceberg-0").Return(nil),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.CANCELLED, 0.3, 100), nil),
+		)
+
+		manager, err := NewIcebergManager(client, params)
+		Expect(err).To(BeNil())
+		Expect(manager.Run(ctx)).To(MatchError("i/o timeout"))
+
+		status := manager.Status()
+		Expect(status.VisibleOrderID).To(BeEmpty())
+		Expect(status.FilledQuantity).To(BeNumerically("~", 0.3))
+		Expect(status.RemainingQuantity).To(BeNumerically("~", 0.7))
+	})
+
+	It("should watch a clip left over from an earlier run instead of placing another", func() {
+		params.Quantity = 1
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(nil, errors.New("i/o timeout")).Times(2),
+			client.EXPECT().GetOrder(gomock.Any(), "iceberg-0").Return(newOrder("iceberg-0", model.FILLED, 1, 100), nil),
+		)
+
+		manager, err := NewIcebergManager(client, params)
+		Expect(err).To(BeNil())
+		Expect(manager.Run(ctx)).NotTo(Succeed())
+		Expect(manager.Status().VisibleOrderID).To(Equal("iceberg-0"))
+
+		Expect(manager.Run(ctx)).To(Succeed())
+		Expect(placed).To(HaveLen(1))
+
+		status := manager.Status()
+		Expect(status.Done).To(BeTrue())
+		Expect(status.Clips).To(Equal(1))
+		Expect(status.FilledQuantity).To(BeNumerically("~", 1))
+	})
+})
@@ -0,0 +1,343 @@
+package apiclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+)
+
+// Interceptor wraps an ApiClient. Embed next in a struct and override only the methods that need to change
+type Interceptor func(next ApiClient) ApiClient
+
+// Chain wraps client so the first interceptor is the outermost one and sees every call first
+func Chain(client ApiClient, interceptors ...Interceptor) ApiClient {
+	for idx := len(interceptors) - 1; idx >= 0; idx-- {
+		client = interceptors[idx](client)
+	}
+
+	return client
+}
+
+// Call describes one ApiClient method call to an AroundHook
+type Call struct {
+	Method string
+	// Args are every argument after ctx in the order the method takes them
+	Args []interface{}
+	// Results are every result except the error. They are only set once invoke returns
+	Results []interface{}
+}
+
+var writeMethods = map[string]bool{
+	"CreateOrderAndWaitForCompletion": true,
+	"CreateLimitMarketOrder":          true,
+	"CreateStopLimitOrder":            true,
+	"CancelOrders":                    true,
+	"CancelExistingOrders":            true,
+	"AmendOrder":                      true,
+	"EngageKillSwitch":                true,
+	"ReleaseKillSwitch":               true,
+}
+
+// IsWrite is true for methods that place, change or cancel orders
+func (c *Call) IsWrite() bool {
+	return writeMethods[c.Method]
+}
+
+// AroundHook runs around every method call. It must call invoke to reach the next client and can change ctx,
+// skip the call or replace the error
+type AroundHook func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error
+
+// Around turns hook into an Interceptor for cross cutting behaviour that treats every method the same way
+func Around(hook AroundHook) Interceptor {
+	return func(next ApiClient) ApiClient {
+		return &aroundClient{next: next, hook: hook}
+	}
+}
+
+// aroundWithClock is Around for hooks that need the time. They get the wrapped client's clock
+func aroundWithClock(hook func(clock Clock) AroundHook) Interceptor {
+	return func(next ApiClient) ApiClient {
+		return Around(hook(clockOf(next)))(next)
+	}
+}
+
+type aroundClient struct {
+	next ApiClient
+	hook AroundHook
+}
+
+func (c *aroundClient) CreateOrderAndWaitForCompletion(
+	ctx context.Context, params *CreateLimitMarketOrderParams, timeout time.Time,
+) (orderID string, err error) {
+	call := &Call{Method: "CreateOrderAndWaitForCompletion", Args: []interface{}{params, timeout}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		orderID, err = c.next.CreateOrderAndWaitForCompletion(ctx, params, timeout)
+		call.Results = []interface{}{orderID}
+		return err
+	})
+
+	return orderID, err
+}
+
+func (c *aroundClient) GetCurrentWallentAmount(
+	ctx context.Context, productID ProductID,
+) (baseAccount, quoteAccount *cbadvmodel.Account, baseAmount, quoteAmount float64, err error) {
+	call := &Call{Method: "GetCurrentWallentAmount", Args: []interface{}{productID}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		baseAccount, quoteAccount, baseAmount, quoteAmount, err = c.next.GetCurrentWallentAmount(ctx, productID)
+		call.Results = []interface{}{baseAccount, quoteAccount, baseAmount, quoteAmount}
+		return err
+	})
+
+	return baseAccount, quoteAccount, baseAmount, quoteAmount, err
+}
+
+func (c *aroundClient) GetProduct(ctx context.Context, productID ProductID) (product *cbadvmodel.GetProductResponse, err error) {
+	call := &Call{Method: "GetProduct", Args: []interface{}{productID}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		product, err = c.next.GetProduct(ctx, productID)
+		call.Results = []interface{}{product}
+		return err
+	})
+
+	return product, err
+}
+
+func (c *aroundClient) GetProductMarketData(
+	ctx context.Context, productID ProductID, pricePercentageChange24h *float64,
+) (highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage float64, err error) {
+	call := &Call{Method: "GetProductMarketData", Args: []interface{}{productID, pricePercentageChange24h}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage, err = c.next.GetProductMarketData(
+			ctx, productID, pricePercentageChange24h,
+		)
+		call.Results = []interface{}{highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage}
+		return err
+	})
+
+	return highLast24Hr, lowLast24Hr, currentPrice, currentPriceChangePercentage, err
+}
+
+func (c *aroundClient) CreateLimitMarketOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (order *cbadvmodel.Order, err error) {
+	call := &Call{Method: "CreateLimitMarketOrder", Args: []interface{}{params}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		order, err = c.next.CreateLimitMarketOrder(ctx, params)
+		call.Results = []interface{}{order}
+		return err
+	})
+
+	return order, err
+}
+
+func (c *aroundClient) CreateStopLimitOrder(ctx context.Context, params *CreateStopLimitOrderParams) (order *cbadvmodel.Order, err error) {
+	call := &Call{Method: "CreateStopLimitOrder", Args: []interface{}{params}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		order, err = c.next.CreateStopLimitOrder(ctx, params)
+		call.Results = []interface{}{order}
+		return err
+	})
+
+	return order, err
+}
+
+func (c *aroundClient) VerifyMarketOrderCompletion(ctx context.Context, orderID string, timeout time.Time) error {
+	return c.hook(ctx, &Call{Method: "VerifyMarketOrderCompletion", Args: []interface{}{orderID, timeout}}, func(ctx context.Context) error {
+		return c.next.VerifyMarketOrderCompletion(ctx, orderID, timeout)
+	})
+}
+
+func (c *aroundClient) GetOrder(ctx context.Context, orderID string) (order *cbadvmodel.Order, err error) {
+	call := &Call{Method: "GetOrder", Args: []interface{}{orderID}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		order, err = c.next.GetOrder(ctx, orderID)
+		call.Results = []interface{}{order}
+		return err
+	})
+
+	return order, err
+}
+
+func (c *aroundClient) GetOrderByClientOrderID(ctx context.Context, productID ProductID, clientOrderID string) (order *cbadvmodel.Order, err error) {
+	call := &Call{Method: "GetOrderByClientOrderID", Args: []interface{}{productID, clientOrderID}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		order, err = c.next.GetOrderByClientOrderID(ctx, productID, clientOrderID)
+		call.Results = []interface{}{order}
+		return err
+	})
+
+	return order, err
+}
+
+func (c *aroundClient) GetOpenOrdersByProductIDAndSide(
+	ctx context.Context, productID ProductID, side cbadvmodel.OrderSide,
+) (orders []cbadvmodel.Order, err error) {
+	call := &Call{Method: "GetOpenOrdersByProductIDAndSide", Args: []interface{}{productID, side}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		orders, err = c.next.GetOpenOrdersByProductIDAndSide(ctx, productID, side)
+		call.Results = []interface{}{orders}
+		return err
+	})
+
+	return orders, err
+}
+
+func (c *aroundClient) GetOrderFills(ctx context.Context, orderID string, productID ProductID) (fills []cbadvmodel.OrderFill, err error) {
+	call := &Call{Method: "GetOrderFills", Args: []interface{}{orderID, productID}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		fills, err = c.next.GetOrderFills(ctx, orderID, productID)
+		call.Results = []interface{}{fills}
+		return err
+	})
+
+	return fills, err
+}
+
+func (c *aroundClient) GetFillsByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) (fills []cbadvmodel.OrderFill, err error) {
+	call := &Call{Method: "GetFillsByTimeRange", Args: []interface{}{productID, start, end}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		fills, err = c.next.GetFillsByTimeRange(ctx, productID, start, end)
+		call.Results = []interface{}{fills}
+		return err
+	})
+
+	return fills, err
+}
+
+func (c *aroundClient) GetOrdersByTimeRange(ctx context.Context, productID ProductID, start, end time.Time) (orders []cbadvmodel.Order, err error) {
+	call := &Call{Method: "GetOrdersByTimeRange", Args: []interface{}{productID, start, end}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		orders, err = c.next.GetOrdersByTimeRange(ctx, productID, start, end)
+		call.Results = []interface{}{orders}
+		return err
+	})
+
+	return orders, err
+}
+
+func (c *aroundClient) EachFillPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(fills []cbadvmodel.OrderFill) error,
+) error {
+	call := &Call{Method: "EachFillPage", Args: []interface{}{productID, start, end}}
+	return c.hook(ctx, call, func(ctx context.Context) error {
+		return c.next.EachFillPage(ctx, productID, start, end, fn)
+	})
+}
+
+func (c *aroundClient) EachOrderPage(
+	ctx context.Context, productID ProductID, start, end time.Time, fn func(orders []cbadvmodel.Order) error,
+) error {
+	call := &Call{Method: "EachOrderPage", Args: []interface{}{productID, start, end}}
+	return c.hook(ctx, call, func(ctx context.Context) error {
+		return c.next.EachOrderPage(ctx, productID, start, end, fn)
+	})
+}
+
+func (c *aroundClient) GetAccounts(ctx context.Context) (accounts []cbadvmodel.Account, err error) {
+	call := &Call{Method: "GetAccounts"}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		accounts, err = c.next.GetAccounts(ctx)
+		call.Results = []interface{}{accounts}
+		return err
+	})
+
+	return accounts, err
+}
+
+func (c *aroundClient) CancelOrders(ctx context.Context, orderIds ...string) error {
+	return c.hook(ctx, &Call{Method: "CancelOrders", Args: []interface{}{orderIds}}, func(ctx context.Context) error {
+		return c.next.CancelOrders(ctx, orderIds...)
+	})
+}
+
+func (c *aroundClient) CancelExistingOrders(ctx context.Context, id string, productID ProductID, orderType model.OrderType) error {
+	return c.hook(ctx, &Call{Method: "CancelExistingOrders", Args: []interface{}{id, productID, orderType}}, func(ctx context.Context) error {
+		return c.next.CancelExistingOrders(ctx, id, productID, orderType)
+	})
+}
+
+func (c *aroundClient) AmendOrder(ctx context.Context, params *AmendOrderParams) (res *AmendOrderResult, err error) {
+	call := &Call{Method: "AmendOrder", Args: []interface{}{params}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		res, err = c.next.AmendOrder(ctx, params)
+		call.Results = []interface{}{res}
+		return err
+	})
+
+	return res, err
+}
+
+func (c *aroundClient) Recover(ctx context.Context) (entries []JournalEntry, err error) {
+	call := &Call{Method: "Recover"}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		entries, err = c.next.Recover(ctx)
+		call.Results = []interface{}{entries}
+		return err
+	})
+
+	return entries, err
+}
+
+func (c *aroundClient) EngageKillSwitch(ctx context.Context, cancelOpenOrders bool) error {
+	return c.hook(ctx, &Call{Method: "EngageKillSwitch", Args: []interface{}{cancelOpenOrders}}, func(ctx context.Context) error {
+		return c.next.EngageKillSwitch(ctx, cancelOpenOrders)
+	})
+}
+
+// ReleaseKillSwitch doesn't take a ctx so the hook gets a background one and any error it returns is dropped
+func (c *aroundClient) ReleaseKillSwitch() {
+	c.hook(context.Background(), &Call{Method: "ReleaseKillSwitch"}, func(ctx context.Context) error {
+		c.next.ReleaseKillSwitch()
+		return nil
+	})
+}
+
+func (c *aroundClient) GetFeeTier(ctx context.Context) (tier *FeeTier, err error) {
+	call := &Call{Method: "GetFeeTier"}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		tier, err = c.next.GetFeeTier(ctx)
+		call.Results = []interface{}{tier}
+		return err
+	})
+
+	return tier, err
+}
+
+func (c *aroundClient) ValidateOrder(ctx context.Context, params *CreateLimitMarketOrderParams) error {
+	return c.hook(ctx, &Call{Method: "ValidateOrder", Args: []interface{}{params}}, func(ctx context.Context) error {
+		return c.next.ValidateOrder(ctx, params)
+	})
+}
+
+func (c *aroundClient) GetProductCatalog(ctx context.Context) (catalog *ProductCatalog, err error) {
+	call := &Call{Method: "GetProductCatalog"}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		catalog, err = c.next.GetProductCatalog(ctx)
+		call.Results = []interface{}{catalog}
+		return err
+	})
+
+	return catalog, err
+}
+
+// Clock isn't a call so it skips the hook
+func (c *aroundClient) Clock() Clock {
+	return c.next.Clock()
+}
+
+// reserveDailyNotional isn't a call so it skips the hook
+func (c *aroundClient) reserveDailyNotional(quoteTicker string, notional float64) error {
+	return reserveDailyNotional(c.next, quoteTicker, notional)
+}
+
+func (c *aroundClient) GetBestBidAsk(ctx context.Context, productIDs ...ProductID) (books map[ProductID]TopOfBook, err error) {
+	call := &Call{Method: "GetBestBidAsk", Args: []interface{}{productIDs}}
+	err = c.hook(ctx, call, func(ctx context.Context) error {
+		books, err = c.next.GetBestBidAsk(ctx, productIDs...)
+		call.Results = []interface{}{books}
+		return err
+	})
+
+	return books, err
+}
@@ -0,0 +1,253 @@
+package apiclient_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Interceptors", func() {
+	var (
+		ctrl      *gomock.Controller
+		apiClient *mocks.MockApiClient
+		ctx       context.Context
+		clock     *FakeClock
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		apiClient = mocks.NewMockApiClient(ctrl)
+		ctx = context.Background()
+		clock = NewFakeClock(time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+		apiClient.EXPECT().Clock().Return(clock).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("Chain", func() {
+		It("should run the first interceptor outermost", func() {
+			calls := []string{}
+			named := func(name string) Interceptor {
+				return Around(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+					calls = append(calls, name+" before "+call.Method)
+					err := invoke(ctx)
+					calls = append(calls, name+" after "+call.Method)
+					return err
+				})
+			}
+
+			apiClient.EXPECT().GetOrder(gomock.Any(), "123").Return(newOrder("123", model.OPEN, 0, 0), nil)
+
+			order, err := Chain(apiClient, named("first"), named("second")).GetOrder(ctx, "123")
+			Expect(err).To(BeNil())
+			Expect(order.GetOrderId()).To(Equal("123"))
+			Expect(calls).To(Equal([]string{
+				"first before GetOrder", "second before GetOrder", "second after GetOrder", "first after GetOrder",
+			}))
+		})
+
+		It("should return the client untouched without interceptors", func() {
+			Expect(Chain(apiClient)).To(BeIdenticalTo(apiClient))
+		})
+	})
+
+	Context("Around", func() {
+		It("should pass the args and results of the call to the hook", func() {
+			var seen *Call
+			client := Chain(apiClient, Around(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+				seen = call
+				return invoke(ctx)
+			}))
+
+			product := &model.GetProductResponse{ProductId: utils.StringToPtr("YFI-BTC")}
+			apiClient.EXPECT().GetProduct(gomock.Any(), ProductID("YFI-BTC")).Return(product, nil)
+
+			res, err := client.GetProduct(ctx, ProductID("YFI-BTC"))
+			Expect(err).To(BeNil())
+			Expect(res).To(BeIdenticalTo(product))
+			Expect(seen.Method).To(Equal("GetProduct"))
+			Expect(seen.Args).To(Equal([]interface{}{ProductID("YFI-BTC")}))
+			Expect(seen.Results).To(Equal([]interface{}{product}))
+			Expect(seen.IsWrite()).To(BeFalse())
+		})
+
+		It("should let the hook skip the call", func() {
+			client := Chain(apiClient, Around(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+				return errors.New("blocked")
+			}))
+
+			_, err := client.GetOrder(ctx, "123")
+			Expect(err).To(MatchError("blocked"))
+		})
+	})
+
+	Context("LoggingInterceptor", func() {
+		It("should log the method and error", func() {
+			var buf bytes.Buffer
+			client := Chain(apiClient, LoggingInterceptor(log.New(&buf, "", 0)))
+
+			apiClient.EXPECT().GetOrder(gomock.Any(), "123").Return(nil, ErrOrderNotFound)
+
+			_, err := client.GetOrder(ctx, "123")
+			Expect(err).To(MatchError(ErrOrderNotFound))
+			Expect(buf.String()).To(ContainSubstring("GetOrder[123] failed after"))
+			Expect(buf.String()).To(ContainSubstring(ErrOrderNotFound.Error()))
+		})
+	})
+
+	Context("TimingInterceptor", func() {
+		It("should report the duration from the clock", func() {
+			durations := map[string]time.Duration{}
+			client := Chain(apiClient, TimingInterceptor(func(method string, duration time.Duration, err error) {
+				durations[method] = duration
+			}))
+
+			apiClient.EXPECT().GetAccounts(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]model.Account, error) {
+				clock.Advance(3 * time.Second)
+				return []model.Account{}, nil
+			})
+
+			_, err := client.GetAccounts(ctx)
+			Expect(err).To(BeNil())
+			Expect(durations).To(Equal(map[string]time.Duration{"GetAccounts": 3 * time.Second}))
+		})
+	})
+
+	Context("AuditInterceptor", func() {
+		It("should only record calls that write", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+			auditLog, err := NewFileAuditLog(path)
+			Expect(err).To(BeNil())
+			DeferCleanup(auditLog.Close)
+
+			client := Chain(apiClient, AuditInterceptor(auditLog, nil))
+
+			apiClient.EXPECT().GetOrder(gomock.Any(), "123").Return(newOrder("123", model.OPEN, 0, 0), nil)
+			apiClient.EXPECT().CancelOrders(gomock.Any(), "123").Return(errors.New("cancel failed"))
+
+			_, err = client.GetOrder(ctx, "123")
+			Expect(err).To(BeNil())
+			Expect(client.CancelOrders(ctx, "123")).To(MatchError("cancel failed"))
+
+			file, err := os.Open(path)
+			Expect(err).To(BeNil())
+			defer file.Close()
+
+			records := []AuditRecord{}
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var record AuditRecord
+				Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+				records = append(records, record)
+			}
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Method).To(Equal("CancelOrders"))
+			Expect(records[0].Args).To(Equal([]interface{}{[]interface{}{"123"}}))
+			Expect(records[0].Error).To(Equal("cancel failed"))
+			Expect(records[0].Time).To(Equal(clock.Now()))
+		})
+
+		It("should pass a record it couldn't write to onError and carry on", func() {
+			auditLog, err := NewFileAuditLog(filepath.Join(GinkgoT().TempDir(), "audit.jsonl"))
+			Expect(err).To(BeNil())
+			Expect(auditLog.Close()).To(Succeed())
+
+			errs := []error{}
+			client := Chain(apiClient, AuditInterceptor(auditLog, func(err error) { errs = append(errs, err) }))
+
+			apiClient.EXPECT().CancelOrders(gomock.Any(), "123").Return(nil)
+
+			Expect(client.CancelOrders(ctx, "123")).To(Succeed())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0]).To(MatchError(ContainSubstring("unable to record CancelOrders")))
+		})
+	})
+
+	Context("DryRunInterceptor", func() {
+		It("should make up orders and never send writes", func() {
+			client := Chain(apiClient, DryRunInterceptor())
+			apiClient.EXPECT().ValidateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+			params := &CreateLimitMarketOrderParams{BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.5, Quantity: 2, Side: BuySideType}
+			order, err := client.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(IsDryRunOrder(order)).To(BeTrue())
+			Expect(order.GetStatus()).To(Equal(model.OPEN))
+			Expect(order.GetClientOrderId()).To(Equal(params.ClientOrderID()))
+
+			again, err := client.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+			Expect(again.GetOrderId()).To(Equal(order.GetOrderId()))
+
+			apiClient.EXPECT().GetOpenOrdersByProductIDAndSide(gomock.Any(), ProductID("YFI-BTC"), model.BUY).Return([]model.Order{}, nil)
+			open, err := client.GetOpenOrdersByProductIDAndSide(ctx, "YFI-BTC", model.BUY)
+			Expect(err).To(BeNil())
+			Expect(open).To(HaveLen(1))
+
+			Expect(client.VerifyMarketOrderCompletion(ctx, order.GetOrderId(), time.Now().Add(time.Minute))).To(Succeed())
+			filled, err := client.GetOrder(ctx, order.GetOrderId())
+			Expect(err).To(BeNil())
+			Expect(filled.GetStatus()).To(Equal(model.FILLED))
+			Expect(filled.GetFilledSize()).To(Equal(2.0))
+
+			apiClient.EXPECT().GetOrder(gomock.Any(), "real-order").Return(newOrder("real-order", model.OPEN, 0, 0), nil)
+			Expect(client.CancelOrders(ctx, "real-order")).To(Succeed())
+		})
+
+		It("should cancel made up orders and pass reads through", func() {
+			client := Chain(apiClient, DryRunInterceptor())
+			apiClient.EXPECT().ValidateOrder(gomock.Any(), gomock.Any()).Return(nil)
+
+			order, err := client.CreateStopLimitOrder(ctx, &CreateStopLimitOrderParams{
+				BaseTicker: "YFI", QuoteTicker: "BTC", StopPrice: 0.4, LimitPrice: 0.39, Quantity: 1, Side: SellSideType,
+			})
+			Expect(err).To(BeNil())
+			Expect(IsDryRunOrder(order)).To(BeTrue())
+
+			Expect(client.CancelOrders(ctx, order.GetOrderId())).To(Succeed())
+			cancelled, err := client.GetOrder(ctx, order.GetOrderId())
+			Expect(err).To(BeNil())
+			Expect(cancelled.GetStatus()).To(Equal(model.CANCELLED))
+
+			apiClient.EXPECT().GetOrder(gomock.Any(), "real-order").Return(newOrder("real-order", model.FILLED, 0, 0), nil)
+			real, err := client.GetOrder(ctx, "real-order")
+			Expect(err).To(BeNil())
+			Expect(IsDryRunOrder(real)).To(BeFalse())
+		})
+	})
+
+	Context("WithInterceptors", func() {
+		It("should wrap the client returned by NewApiClient", func() {
+			methods := []string{}
+			client, err := NewApiClient(mocks.NewMockCoinbaseClient(ctrl), mock_client.NewMockClient(ctrl), false,
+				WithInterceptors(Around(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+					methods = append(methods, call.Method)
+					return errors.New("blocked")
+				})))
+			Expect(err).To(BeNil())
+
+			_, err = client.GetOrder(ctx, "123")
+			Expect(err).To(MatchError("blocked"))
+			Expect(methods).To(Equal([]string{"GetOrder"}))
+		})
+	})
+})
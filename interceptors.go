@@ -0,0 +1,98 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// LoggingInterceptor logs every call with its arguments, duration and error. A nil logger uses log.Default
+func LoggingInterceptor(logger *log.Logger) Interceptor {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return aroundWithClock(func(clock Clock) AroundHook {
+		return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			start := clock.Now()
+			err := invoke(ctx)
+
+			if err != nil {
+				logger.Printf("%s%v failed after %s with err: %s\n", call.Method, call.Args, clock.Now().Sub(start), err.Error())
+			} else {
+				logger.Printf("%s%v took %s\n", call.Method, call.Args, clock.Now().Sub(start))
+			}
+
+			return err
+		}
+	})
+}
+
+// TimingInterceptor reports how long every call took on the client's clock
+func TimingInterceptor(observe func(method string, duration time.Duration, err error)) Interceptor {
+	return aroundWithClock(func(clock Clock) AroundHook {
+		return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			start := clock.Now()
+			err := invoke(ctx)
+			observe(call.Method, clock.Now().Sub(start), err)
+
+			return err
+		}
+	})
+}
+
+type AuditRecord struct {
+	Time    time.Time     `json:"time"`
+	Method  string        `json:"method"`
+	Args    []interface{} `json:"args,omitempty"`
+	Results []interface{} `json:"results,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type AuditLog interface {
+	Record(record AuditRecord) error
+	Close() error
+}
+
+// NewFileAuditLog appends every record as a json line to the file at path
+func NewFileAuditLog(path string) (AuditLog, error) {
+	file, err := openJSONLFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileAuditLog{jsonlFile: file}, nil
+}
+
+type fileAuditLog struct {
+	*jsonlFile
+}
+
+func (l *fileAuditLog) Record(record AuditRecord) error {
+	return l.Append(record)
+}
+
+// AuditInterceptor records every call that places, changes or cancels orders whether it succeeded or not. A
+// record that can't be written goes to onError when it's set and the call carries on
+func AuditInterceptor(auditLog AuditLog, onError func(err error)) Interceptor {
+	return aroundWithClock(func(clock Clock) AroundHook {
+		return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			if !call.IsWrite() {
+				return invoke(ctx)
+			}
+
+			err := invoke(ctx)
+
+			record := AuditRecord{Time: clock.Now().UTC(), Method: call.Method, Args: call.Args, Results: call.Results}
+			if err != nil {
+				record.Error = err.Error()
+			}
+			if recordErr := auditLog.Record(record); recordErr != nil && onError != nil {
+				onError(fmt.Errorf("unable to record %s: %w", call.Method, recordErr))
+			}
+
+			return err
+		}
+	})
+}
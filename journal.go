@@ -0,0 +1,229 @@
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+)
+
+type JournalStatus string
+
+const (
+	IntentJournalStatus    JournalStatus = "INTENT"
+	SubmittedJournalStatus JournalStatus = "SUBMITTED"
+	// NotPlacedJournalStatus is used when recovery could not find the order on coinbase
+	NotPlacedJournalStatus JournalStatus = "NOT_PLACED"
+	FilledJournalStatus    JournalStatus = JournalStatus(cbadvmodel.FILLED)
+	CancelledJournalStatus JournalStatus = JournalStatus(cbadvmodel.CANCELLED)
+	ExpiredJournalStatus   JournalStatus = JournalStatus(cbadvmodel.EXPIRED)
+	FailedJournalStatus    JournalStatus = JournalStatus(cbadvmodel.FAILED)
+)
+
+func (s JournalStatus) IsTerminal() bool {
+	switch s {
+	case NotPlacedJournalStatus, FilledJournalStatus, CancelledJournalStatus, ExpiredJournalStatus, FailedJournalStatus:
+		return true
+	}
+
+	return false
+}
+
+type JournalEntry struct {
+	// IntentID is CreateLimitMarketOrderParams.ID and ties every entry for an order together
+	IntentID      string        `json:"intent_id"`
+	ClientOrderID string        `json:"client_order_id,omitempty"`
+	OrderID       string        `json:"order_id,omitempty"`
+	ProductID     ProductID     `json:"product_id"`
+	Side          string        `json:"side"`
+	Price         float64       `json:"price"`
+	Quantity      float64       `json:"quantity"`
+	Status        JournalStatus `json:"status"`
+	Message       string        `json:"message,omitempty"`
+	Time          time.Time     `json:"time"`
+}
+
+type OrderJournal interface {
+	Record(entry JournalEntry) error
+	// Unfinished returns the latest entry of every intent that hasn't reached a terminal status
+	Unfinished() ([]JournalEntry, error)
+	Close() error
+}
+
+// NewFileOrderJournal appends every entry as a json line to the file at path
+func NewFileOrderJournal(path string) (OrderJournal, error) {
+	file, err := openJSONLFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileOrderJournal{jsonlFile: file}, nil
+}
+
+type fileOrderJournal struct {
+	*jsonlFile
+}
+
+func (j *fileOrderJournal) Record(entry JournalEntry) error {
+	return j.Append(entry)
+}
+
+func (j *fileOrderJournal) Unfinished() ([]JournalEntry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	order := []string{}
+	latest := make(map[string]JournalEntry)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry JournalEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A crash mid write leaves a partial last line behind
+			continue
+		}
+
+		if _, exists := latest[entry.IntentID]; !exists {
+			order = append(order, entry.IntentID)
+		}
+		latest[entry.IntentID] = entry
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := []JournalEntry{}
+	for _, id := range order {
+		if !latest[id].Status.IsTerminal() {
+			entries = append(entries, latest[id])
+		}
+	}
+
+	return entries, nil
+}
+
+// Recover reconciles every unfinished journal entry against coinbase and records what it finds. The
+// returned entries are the updated state. Orders that are still open are returned as SUBMITTED
+func (c *apiclient) Recover(ctx context.Context) (_ []JournalEntry, err error) {
+	ctx, span := c.startSpan(ctx, "Recover")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if c.orderJournal == nil {
+		return nil, errors.New("order journal is not configured")
+	}
+
+	entries, err := c.orderJournal.Unfinished()
+	if err != nil {
+		return nil, err
+	}
+
+	recovered := []JournalEntry{}
+	for _, entry := range entries {
+		var order *cbadvmodel.Order
+		if entry.OrderID != "" {
+			order, err = c.GetOrder(ctx, entry.OrderID)
+		} else {
+			// We crashed before learning the order id so it may or may not have been placed
+			order, err = c.findOrder(ctx, entry.ProductID, entry.Time.Add(-createdOrderLookback), func(o *cbadvmodel.Order) bool {
+				return matchesIntent(o.GetClientOrderId(), entry)
+			})
+		}
+
+		if errors.Is(err, ErrOrderNotFound) {
+			entry.Status = NotPlacedJournalStatus
+		} else if err != nil {
+			return recovered, err
+		} else {
+			entry.OrderID = order.GetOrderId()
+			entry.ClientOrderID = order.GetClientOrderId()
+			entry.Status = journalStatusFromOrder(order)
+		}
+
+		entry.Message = "recovered"
+		entry.Time = c.clock.Now()
+		if err = c.orderJournal.Record(entry); err != nil {
+			return recovered, err
+		}
+
+		recovered = append(recovered, entry)
+	}
+
+	return recovered, nil
+}
+
+func (c *apiclient) recordJournalEntry(entry JournalEntry) error {
+	if c.orderJournal == nil {
+		return nil
+	}
+
+	entry.Time = c.clock.Now()
+	if err := c.orderJournal.Record(entry); err != nil {
+		c.log("unable to record journal entry for '%s': %s", entry.IntentID, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func newJournalEntry(params *CreateLimitMarketOrderParams, status JournalStatus) JournalEntry {
+	return JournalEntry{
+		IntentID:      params.ID,
+		ClientOrderID: params.ClientOrderID(),
+		ProductID:     params.ProductID(),
+		Side:          string(params.Side),
+		Price:         params.Price,
+		Quantity:      params.Quantity,
+		Status:        status,
+	}
+}
+
+// matchesIntent is true when clientOrderID was made for the entry's intent at any price or quantity. The hash
+// suffix has a fixed length so intent "abc" never matches an order placed for intent "abc-def"
+func matchesIntent(clientOrderID string, entry JournalEntry) bool {
+	if entry.ProductID.Validate() != nil {
+		return false
+	}
+
+	params := CreateLimitMarketOrderParams{
+		ID: entry.IntentID, BaseTicker: entry.ProductID.Base(), QuoteTicker: entry.ProductID.Quote(), Side: sideType(entry.Side),
+	}
+
+	prefix := params.clientOrderIDPrefix()
+	if !strings.HasPrefix(clientOrderID, prefix) {
+		return false
+	}
+
+	suffix := strings.TrimPrefix(clientOrderID, prefix)
+	if len(suffix) != hex.EncodedLen(clientOrderIDHashBytes) {
+		return false
+	}
+	_, err := hex.DecodeString(suffix)
+
+	return err == nil
+}
+
+func journalStatusFromOrder(order *cbadvmodel.Order) JournalStatus {
+	if order.GetStatus() == cbadvmodel.OPEN {
+		return SubmittedJournalStatus
+	}
+
+	return JournalStatus(order.GetStatus())
+}
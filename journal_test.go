@@ -0,0 +1,164 @@
+package apiclient_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OrderJournal", func() {
+	var (
+		dir     string
+		journal OrderJournal
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "journal")
+		Expect(err).To(BeNil())
+
+		journal, err = NewFileOrderJournal(filepath.Join(dir, "orders.jsonl"))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(journal.Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should only return the latest entry of unfinished intents", func() {
+		Expect(journal.Record(JournalEntry{IntentID: "a", Status: IntentJournalStatus})).To(Succeed())
+		Expect(journal.Record(JournalEntry{IntentID: "b", Status: IntentJournalStatus})).To(Succeed())
+		Expect(journal.Record(JournalEntry{IntentID: "a", OrderID: "order-a", Status: SubmittedJournalStatus})).To(Succeed())
+		Expect(journal.Record(JournalEntry{IntentID: "b", OrderID: "order-b", Status: FilledJournalStatus})).To(Succeed())
+
+		entries, err := journal.Unfinished()
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].IntentID).To(Equal("a"))
+		Expect(entries[0].OrderID).To(Equal("order-a"))
+	})
+
+	It("should ignore a partially written last line", func() {
+		Expect(journal.Record(JournalEntry{IntentID: "a", Status: IntentJournalStatus})).To(Succeed())
+
+		file, err := os.OpenFile(filepath.Join(dir, "orders.jsonl"), os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).To(BeNil())
+		_, err = file.WriteString(`{"intent_id":"a","sta`)
+		Expect(err).To(BeNil())
+		Expect(file.Close()).To(Succeed())
+
+		entries, err := journal.Unfinished()
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("should keep the first entry written after a crash left a partial line", func() {
+		Expect(journal.Record(JournalEntry{IntentID: "a", Status: IntentJournalStatus})).To(Succeed())
+		Expect(journal.Close()).To(Succeed())
+
+		path := filepath.Join(dir, "orders.jsonl")
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).To(BeNil())
+		_, err = file.WriteString(`{"intent_id":"a","sta`)
+		Expect(err).To(BeNil())
+		Expect(file.Close()).To(Succeed())
+
+		journal, err = NewFileOrderJournal(path)
+		Expect(err).To(BeNil())
+		Expect(journal.Record(JournalEntry{IntentID: "b", Status: IntentJournalStatus})).To(Succeed())
+
+		entries, err := journal.Unfinished()
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1].IntentID).To(Equal("b"))
+	})
+
+	Context("Recover", func() {
+		var (
+			ctrl     *gomock.Controller
+			cbClient *mocks.MockCoinbaseClient
+			cont     ApiClient
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			cbClient = mocks.NewMockCoinbaseClient(ctrl)
+
+			var err error
+			cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithOrderJournal(journal))
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should reconcile unfinished entries against coinbase", func() {
+			placed := &CreateLimitMarketOrderParams{ID: "placed", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 1, Side: BuySideType}
+
+			Expect(journal.Record(JournalEntry{IntentID: "placed", ProductID: "YFI-BTC", Side: "BUY", Status: IntentJournalStatus})).To(Succeed())
+			Expect(journal.Record(JournalEntry{IntentID: "submitted", OrderID: "order-2", ProductID: "YFI-BTC", Side: "BUY", Status: SubmittedJournalStatus})).To(Succeed())
+			Expect(journal.Record(JournalEntry{IntentID: "lost", ProductID: "YFI-BTC", Side: "BUY", Status: IntentJournalStatus})).To(Succeed())
+
+			open, filled := model.OPEN, model.FILLED
+			// the price was adjusted on a retry so only the prefix of the client order id matches
+			adjusted := *placed
+			adjusted.Price = 0.39
+
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+				Orders: []model.Order{{
+					OrderId:       utils.StringToPtr("order-1"),
+					ClientOrderId: utils.StringToPtr(adjusted.ClientOrderID()),
+					Status:        &open,
+				}},
+			}, nil).Times(2)
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-2").Return(&model.GetOrderResponse{Order: &model.Order{
+				OrderId: utils.StringToPtr("order-2"),
+				Status:  &filled,
+			}}, nil)
+
+			entries, err := cont.Recover(ctx)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].OrderID).To(Equal("order-1"))
+			Expect(entries[0].Status).To(Equal(SubmittedJournalStatus))
+			Expect(entries[1].Status).To(Equal(FilledJournalStatus))
+			Expect(entries[2].Status).To(Equal(NotPlacedJournalStatus))
+
+			unfinished, err := journal.Unfinished()
+			Expect(err).To(BeNil())
+			Expect(unfinished).To(HaveLen(1))
+			Expect(unfinished[0].IntentID).To(Equal("placed"))
+		})
+
+		It("should not adopt an order placed for a longer intent with the same prefix", func() {
+			other := &CreateLimitMarketOrderParams{ID: "abc-def", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 1, Side: BuySideType}
+			Expect(journal.Record(JournalEntry{IntentID: "abc", ProductID: "YFI-BTC", Side: "BUY", Status: IntentJournalStatus})).To(Succeed())
+
+			open := model.OPEN
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+				Orders: []model.Order{{
+					OrderId:       utils.StringToPtr("order-1"),
+					ClientOrderId: utils.StringToPtr(other.ClientOrderID()),
+					Status:        &open,
+				}},
+			}, nil)
+
+			entries, err := cont.Recover(ctx)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].OrderID).To(BeEmpty())
+			Expect(entries[0].Status).To(Equal(NotPlacedJournalStatus))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// jsonlFile appends every value as a json line. Each line is synced before Append returns so a crash never
+// loses a value that was reported as written. A partial line left by a crash is ended when the file is opened so
+// the next value starts on a line of its own
+type jsonlFile struct {
+	path  string
+	file  *os.File
+	mutex *sync.Mutex
+}
+
+func openJSONLFile(path string) (*jsonlFile, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = endPartialLine(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &jsonlFile{path: path, file: file, mutex: &sync.Mutex{}}, nil
+}
+
+func (f *jsonlFile) Append(v interface{}) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, err = f.file.Write(append(bts, '\n')); err != nil {
+		return err
+	}
+
+	return f.file.Sync()
+}
+
+func (f *jsonlFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Close()
+}
+
+// endPartialLine adds the newline a crash mid write never got to
+func endPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err = file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	} else if last[0] == '\n' {
+		return nil
+	}
+
+	if _, err = file.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
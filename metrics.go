@@ -0,0 +1,30 @@
+package apiclient
+
+import (
+	"time"
+)
+
+// MetricsHook receives every measurement the client takes. Implementations must be safe for concurrent use and
+// should never block since they are called inline with the exchange requests
+type MetricsHook interface {
+	// ObserveRequest is called once per request sent to coinbase. endpoint is the coinbase operation e.g. CreateOrder
+	ObserveRequest(endpoint string, duration time.Duration, err error)
+	// ObserveErrorCode is called when coinbase answers with an error code in the body instead of a failed status
+	ObserveErrorCode(endpoint, code string)
+	// ObserveRetry is called every time CreateLimitMarketOrder tries again. reason is the error code or "request"
+	// when the request itself failed
+	ObserveRetry(reason string)
+	// ObserveTimeToFill is called when VerifyMarketOrderCompletion sees an order fill
+	ObserveTimeToFill(productID, side string, duration time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(endpoint string, duration time.Duration, err error) {}
+func (noopMetrics) ObserveErrorCode(endpoint, code string)                            {}
+func (noopMetrics) ObserveRetry(reason string)                                        {}
+func (noopMetrics) ObserveTimeToFill(productID, side string, duration time.Duration)  {}
+
+func (c *apiclient) observeRequest(endpoint string, start time.Time, err error) {
+	c.metrics.ObserveRequest(endpoint, c.clock.Now().Sub(start), err)
+}
@@ -0,0 +1,152 @@
+package apiclient_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	cbadvclient "github.com/QuantFu-Inc/coinbase-adv/client"
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingMetrics struct {
+	mutex      sync.Mutex
+	requests   []string
+	errorCodes []string
+	retries    []string
+	fills      []time.Duration
+}
+
+func (m *recordingMetrics) ObserveRequest(endpoint string, duration time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %v", endpoint, err == nil))
+}
+
+func (m *recordingMetrics) ObserveErrorCode(endpoint, code string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.errorCodes = append(m.errorCodes, endpoint+" "+code)
+}
+
+func (m *recordingMetrics) ObserveRetry(reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.retries = append(m.retries, reason)
+}
+
+func (m *recordingMetrics) ObserveTimeToFill(productID, side string, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.fills = append(m.fills, duration)
+}
+
+var _ = Describe("Metrics", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		cont     ApiClient
+		clock    *FakeClock
+		metrics  *recordingMetrics
+		start    time.Time
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+		start = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+		clock = NewFakeClock(start)
+		metrics = &recordingMetrics{}
+
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithClock(clock), WithMetrics(metrics))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should report requests, error codes and retries from CreateLimitMarketOrder", func() {
+		gomock.InOrder(
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+				Success:       utils.BoolToBoolPtr(false),
+				ErrorResponse: &model.CreateOrderResponseErrorResponse{Error: utils.StringToPtr("INSUFFICIENT_FUND")},
+			}, nil),
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+				Success: utils.BoolToBoolPtr(true),
+				OrderId: utils.StringToPtr("order-1"),
+			}, nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 1.9, 0), nil),
+		)
+
+		errCh := make(chan error)
+		go func() {
+			defer GinkgoRecover()
+			_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+				ID: "metrics", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 2, Side: BuySideType,
+			})
+			errCh <- err
+		}()
+
+		clock.BlockUntil(1)
+		clock.Advance(150 * time.Millisecond)
+		Eventually(errCh).Should(Receive(BeNil()))
+
+		Expect(metrics.requests).To(Equal([]string{"CreateOrder true", "CreateOrder true", "GetOrder true"}))
+		Expect(metrics.errorCodes).To(Equal([]string{"CreateOrder INSUFFICIENT_FUND"}))
+		Expect(metrics.retries).To(Equal([]string{"INSUFFICIENT_FUND"}))
+	})
+
+	It("should report time to fill once the order completes", func() {
+		order := openLimitOrder("order-1", 0.4, 2, 2)
+		filled := model.FILLED
+		order.Order.Status = &filled
+		order.Order.CreatedTime = utils.StringToPtr(start.Add(-90 * time.Second).Format(time.RFC3339Nano))
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(order, nil)
+
+		Expect(cont.VerifyMarketOrderCompletion(ctx, "order-1", start.Add(time.Minute))).To(Succeed())
+		Expect(metrics.fills).To(Equal([]time.Duration{90 * time.Second}))
+	})
+
+	It("should time requests without touching the requested time range", func() {
+		from, to := start.Add(-48*time.Hour), start.Add(-24*time.Hour)
+		cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, params *cbadvclient.ListOrdersParams) (*model.ListOrdersResponse, error) {
+				Expect(params.StartDate).To(Equal(from))
+				Expect(params.EndDate).To(Equal(to))
+				return &model.ListOrdersResponse{}, nil
+			})
+		cbClient.EXPECT().ListFills(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, params *cbadvclient.ListFillsParams) (*model.ListFillsResponse, error) {
+				Expect(params.StartSequenceTimestamp).To(Equal(from))
+				Expect(params.EndSequenceTimestamp).To(Equal(to))
+				return &model.ListFillsResponse{}, nil
+			})
+
+		_, err := cont.GetOrdersByTimeRange(ctx, "YFI-BTC", from, to)
+		Expect(err).To(BeNil())
+		_, err = cont.GetFillsByTimeRange(ctx, "YFI-BTC", from, to)
+		Expect(err).To(BeNil())
+		Expect(metrics.requests).To(Equal([]string{"ListOrders true", "ListFills true"}))
+	})
+	It("should report nothing without panicking when the hook is nil", func() {
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithMetrics(nil))
+		Expect(err).To(BeNil())
+
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0), nil)
+
+		_, err = cont.GetOrder(ctx, "order-1")
+		Expect(err).To(BeNil())
+	})
+})
@@ -37,8 +37,23 @@ func (m *MockApiClient) EXPECT() *MockApiClientMockRecorder {
 	return m.recorder
 }
 
+// AmendOrder mocks base method.
+func (m *MockApiClient) AmendOrder(arg0 context.Context, arg1 *apiclient.AmendOrderParams) (*apiclient.AmendOrderResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AmendOrder", arg0, arg1)
+	ret0, _ := ret[0].(*apiclient.AmendOrderResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AmendOrder indicates an expected call of AmendOrder.
+func (mr *MockApiClientMockRecorder) AmendOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AmendOrder", reflect.TypeOf((*MockApiClient)(nil).AmendOrder), arg0, arg1)
+}
+
 // CancelExistingOrders mocks base method.
-func (m *MockApiClient) CancelExistingOrders(arg0 context.Context, arg1, arg2 string, arg3 model.OrderType) error {
+func (m *MockApiClient) CancelExistingOrders(arg0 context.Context, arg1 string, arg2 apiclient.ProductID, arg3 model.OrderType) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CancelExistingOrders", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -70,6 +85,20 @@ func (mr *MockApiClientMockRecorder) CancelOrders(arg0 interface{}, arg1 ...inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrders", reflect.TypeOf((*MockApiClient)(nil).CancelOrders), varargs...)
 }
 
+// Clock mocks base method.
+func (m *MockApiClient) Clock() apiclient.Clock {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clock")
+	ret0, _ := ret[0].(apiclient.Clock)
+	return ret0
+}
+
+// Clock indicates an expected call of Clock.
+func (mr *MockApiClientMockRecorder) Clock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clock", reflect.TypeOf((*MockApiClient)(nil).Clock))
+}
+
 // CreateLimitMarketOrder mocks base method.
 func (m *MockApiClient) CreateLimitMarketOrder(arg0 context.Context, arg1 *apiclient.CreateLimitMarketOrderParams) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -100,10 +129,102 @@ func (mr *MockApiClientMockRecorder) CreateOrderAndWaitForCompletion(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderAndWaitForCompletion", reflect.TypeOf((*MockApiClient)(nil).CreateOrderAndWaitForCompletion), arg0, arg1, arg2)
 }
 
+// CreateStopLimitOrder mocks base method.
+func (m *MockApiClient) CreateStopLimitOrder(arg0 context.Context, arg1 *apiclient.CreateStopLimitOrderParams) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStopLimitOrder", arg0, arg1)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStopLimitOrder indicates an expected call of CreateStopLimitOrder.
+func (mr *MockApiClientMockRecorder) CreateStopLimitOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStopLimitOrder", reflect.TypeOf((*MockApiClient)(nil).CreateStopLimitOrder), arg0, arg1)
+}
+
+// EachFillPage mocks base method.
+func (m *MockApiClient) EachFillPage(arg0 context.Context, arg1 apiclient.ProductID, arg2, arg3 time.Time, arg4 func([]model.OrderFill) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachFillPage", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachFillPage indicates an expected call of EachFillPage.
+func (mr *MockApiClientMockRecorder) EachFillPage(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachFillPage", reflect.TypeOf((*MockApiClient)(nil).EachFillPage), arg0, arg1, arg2, arg3, arg4)
+}
+
+// EachOrderPage mocks base method.
+func (m *MockApiClient) EachOrderPage(arg0 context.Context, arg1 apiclient.ProductID, arg2, arg3 time.Time, arg4 func([]model.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachOrderPage", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachOrderPage indicates an expected call of EachOrderPage.
+func (mr *MockApiClientMockRecorder) EachOrderPage(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachOrderPage", reflect.TypeOf((*MockApiClient)(nil).EachOrderPage), arg0, arg1, arg2, arg3, arg4)
+}
+
+// EngageKillSwitch mocks base method.
+func (m *MockApiClient) EngageKillSwitch(arg0 context.Context, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EngageKillSwitch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EngageKillSwitch indicates an expected call of EngageKillSwitch.
+func (mr *MockApiClientMockRecorder) EngageKillSwitch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EngageKillSwitch", reflect.TypeOf((*MockApiClient)(nil).EngageKillSwitch), arg0, arg1)
+}
+
+// GetAccounts mocks base method.
+func (m *MockApiClient) GetAccounts(arg0 context.Context) ([]model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccounts", arg0)
+	ret0, _ := ret[0].([]model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccounts indicates an expected call of GetAccounts.
+func (mr *MockApiClientMockRecorder) GetAccounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockApiClient)(nil).GetAccounts), arg0)
+}
+
+// GetBestBidAsk mocks base method.
+func (m *MockApiClient) GetBestBidAsk(arg0 context.Context, arg1 ...apiclient.ProductID) (map[apiclient.ProductID]apiclient.TopOfBook, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetBestBidAsk", varargs...)
+	ret0, _ := ret[0].(map[apiclient.ProductID]apiclient.TopOfBook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBestBidAsk indicates an expected call of GetBestBidAsk.
+func (mr *MockApiClientMockRecorder) GetBestBidAsk(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBestBidAsk", reflect.TypeOf((*MockApiClient)(nil).GetBestBidAsk), varargs...)
+}
+
 // GetCurrentWallentAmount mocks base method.
-func (m *MockApiClient) GetCurrentWallentAmount(arg0 context.Context, arg1, arg2 string) (*model.Account, *model.Account, float64, float64, error) {
+func (m *MockApiClient) GetCurrentWallentAmount(arg0 context.Context, arg1 apiclient.ProductID) (*model.Account, *model.Account, float64, float64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrentWallentAmount", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetCurrentWallentAmount", arg0, arg1)
 	ret0, _ := ret[0].(*model.Account)
 	ret1, _ := ret[1].(*model.Account)
 	ret2, _ := ret[2].(float64)
@@ -113,13 +234,43 @@ func (m *MockApiClient) GetCurrentWallentAmount(arg0 context.Context, arg1, arg2
 }
 
 // GetCurrentWallentAmount indicates an expected call of GetCurrentWallentAmount.
-func (mr *MockApiClientMockRecorder) GetCurrentWallentAmount(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockApiClientMockRecorder) GetCurrentWallentAmount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentWallentAmount", reflect.TypeOf((*MockApiClient)(nil).GetCurrentWallentAmount), arg0, arg1)
+}
+
+// GetFeeTier mocks base method.
+func (m *MockApiClient) GetFeeTier(arg0 context.Context) (*apiclient.FeeTier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeeTier", arg0)
+	ret0, _ := ret[0].(*apiclient.FeeTier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeeTier indicates an expected call of GetFeeTier.
+func (mr *MockApiClientMockRecorder) GetFeeTier(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeeTier", reflect.TypeOf((*MockApiClient)(nil).GetFeeTier), arg0)
+}
+
+// GetFillsByTimeRange mocks base method.
+func (m *MockApiClient) GetFillsByTimeRange(arg0 context.Context, arg1 apiclient.ProductID, arg2, arg3 time.Time) ([]model.OrderFill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsByTimeRange", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.OrderFill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsByTimeRange indicates an expected call of GetFillsByTimeRange.
+func (mr *MockApiClientMockRecorder) GetFillsByTimeRange(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentWallentAmount", reflect.TypeOf((*MockApiClient)(nil).GetCurrentWallentAmount), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsByTimeRange", reflect.TypeOf((*MockApiClient)(nil).GetFillsByTimeRange), arg0, arg1, arg2, arg3)
 }
 
 // GetOpenOrdersByProductIDAndSide mocks base method.
-func (m *MockApiClient) GetOpenOrdersByProductIDAndSide(arg0 context.Context, arg1 string, arg2 model.OrderSide) ([]model.Order, error) {
+func (m *MockApiClient) GetOpenOrdersByProductIDAndSide(arg0 context.Context, arg1 apiclient.ProductID, arg2 model.OrderSide) ([]model.Order, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetOpenOrdersByProductIDAndSide", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]model.Order)
@@ -148,8 +299,23 @@ func (mr *MockApiClientMockRecorder) GetOrder(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockApiClient)(nil).GetOrder), arg0, arg1)
 }
 
+// GetOrderByClientOrderID mocks base method.
+func (m *MockApiClient) GetOrderByClientOrderID(arg0 context.Context, arg1 apiclient.ProductID, arg2 string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByClientOrderID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByClientOrderID indicates an expected call of GetOrderByClientOrderID.
+func (mr *MockApiClientMockRecorder) GetOrderByClientOrderID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByClientOrderID", reflect.TypeOf((*MockApiClient)(nil).GetOrderByClientOrderID), arg0, arg1, arg2)
+}
+
 // GetOrderFills mocks base method.
-func (m *MockApiClient) GetOrderFills(arg0 context.Context, arg1, arg2 string) ([]model.OrderFill, error) {
+func (m *MockApiClient) GetOrderFills(arg0 context.Context, arg1 string, arg2 apiclient.ProductID) ([]model.OrderFill, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetOrderFills", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]model.OrderFill)
@@ -163,25 +329,55 @@ func (mr *MockApiClientMockRecorder) GetOrderFills(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderFills", reflect.TypeOf((*MockApiClient)(nil).GetOrderFills), arg0, arg1, arg2)
 }
 
+// GetOrdersByTimeRange mocks base method.
+func (m *MockApiClient) GetOrdersByTimeRange(arg0 context.Context, arg1 apiclient.ProductID, arg2, arg3 time.Time) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByTimeRange", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByTimeRange indicates an expected call of GetOrdersByTimeRange.
+func (mr *MockApiClientMockRecorder) GetOrdersByTimeRange(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByTimeRange", reflect.TypeOf((*MockApiClient)(nil).GetOrdersByTimeRange), arg0, arg1, arg2, arg3)
+}
+
 // GetProduct mocks base method.
-func (m *MockApiClient) GetProduct(arg0 context.Context, arg1, arg2 string) (*model.GetProductResponse, error) {
+func (m *MockApiClient) GetProduct(arg0 context.Context, arg1 apiclient.ProductID) (*model.GetProductResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProduct", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetProduct", arg0, arg1)
 	ret0, _ := ret[0].(*model.GetProductResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProduct indicates an expected call of GetProduct.
-func (mr *MockApiClientMockRecorder) GetProduct(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockApiClientMockRecorder) GetProduct(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockApiClient)(nil).GetProduct), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockApiClient)(nil).GetProduct), arg0, arg1)
+}
+
+// GetProductCatalog mocks base method.
+func (m *MockApiClient) GetProductCatalog(arg0 context.Context) (*apiclient.ProductCatalog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductCatalog", arg0)
+	ret0, _ := ret[0].(*apiclient.ProductCatalog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductCatalog indicates an expected call of GetProductCatalog.
+func (mr *MockApiClientMockRecorder) GetProductCatalog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductCatalog", reflect.TypeOf((*MockApiClient)(nil).GetProductCatalog), arg0)
 }
 
 // GetProductMarketData mocks base method.
-func (m *MockApiClient) GetProductMarketData(arg0 context.Context, arg1, arg2 string, arg3 *float64) (float64, float64, float64, float64, error) {
+func (m *MockApiClient) GetProductMarketData(arg0 context.Context, arg1 apiclient.ProductID, arg2 *float64) (float64, float64, float64, float64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductMarketData", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "GetProductMarketData", arg0, arg1, arg2)
 	ret0, _ := ret[0].(float64)
 	ret1, _ := ret[1].(float64)
 	ret2, _ := ret[2].(float64)
@@ -191,9 +387,50 @@ func (m *MockApiClient) GetProductMarketData(arg0 context.Context, arg1, arg2 st
 }
 
 // GetProductMarketData indicates an expected call of GetProductMarketData.
-func (mr *MockApiClientMockRecorder) GetProductMarketData(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockApiClientMockRecorder) GetProductMarketData(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductMarketData", reflect.TypeOf((*MockApiClient)(nil).GetProductMarketData), arg0, arg1, arg2)
+}
+
+// Recover mocks base method.
+func (m *MockApiClient) Recover(arg0 context.Context) ([]apiclient.JournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recover", arg0)
+	ret0, _ := ret[0].([]apiclient.JournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recover indicates an expected call of Recover.
+func (mr *MockApiClientMockRecorder) Recover(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockApiClient)(nil).Recover), arg0)
+}
+
+// ReleaseKillSwitch mocks base method.
+func (m *MockApiClient) ReleaseKillSwitch() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReleaseKillSwitch")
+}
+
+// ReleaseKillSwitch indicates an expected call of ReleaseKillSwitch.
+func (mr *MockApiClientMockRecorder) ReleaseKillSwitch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseKillSwitch", reflect.TypeOf((*MockApiClient)(nil).ReleaseKillSwitch))
+}
+
+// ValidateOrder mocks base method.
+func (m *MockApiClient) ValidateOrder(arg0 context.Context, arg1 *apiclient.CreateLimitMarketOrderParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateOrder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateOrder indicates an expected call of ValidateOrder.
+func (mr *MockApiClientMockRecorder) ValidateOrder(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductMarketData", reflect.TypeOf((*MockApiClient)(nil).GetProductMarketData), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateOrder", reflect.TypeOf((*MockApiClient)(nil).ValidateOrder), arg0, arg1)
 }
 
 // VerifyMarketOrderCompletion mocks base method.
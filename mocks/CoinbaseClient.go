@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	http "net/http"
 	reflect "reflect"
 
@@ -37,18 +38,18 @@ func (m *MockCoinbaseClient) EXPECT() *MockCoinbaseClientMockRecorder {
 }
 
 // CancelOrders mocks base method.
-func (m *MockCoinbaseClient) CancelOrders(arg0 []string) (*model.CancelOrderResponse, error) {
+func (m *MockCoinbaseClient) CancelOrders(arg0 context.Context, arg1 []string) (*model.CancelOrderResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelOrders", arg0)
+	ret := m.ctrl.Call(m, "CancelOrders", arg0, arg1)
 	ret0, _ := ret[0].(*model.CancelOrderResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CancelOrders indicates an expected call of CancelOrders.
-func (mr *MockCoinbaseClientMockRecorder) CancelOrders(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) CancelOrders(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrders", reflect.TypeOf((*MockCoinbaseClient)(nil).CancelOrders), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrders", reflect.TypeOf((*MockCoinbaseClient)(nil).CancelOrders), arg0, arg1)
 }
 
 // CheckAuthentication mocks base method.
@@ -64,93 +65,108 @@ func (mr *MockCoinbaseClientMockRecorder) CheckAuthentication(arg0, arg1 interfa
 }
 
 // CreateOrder mocks base method.
-func (m *MockCoinbaseClient) CreateOrder(arg0 *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+func (m *MockCoinbaseClient) CreateOrder(arg0 context.Context, arg1 *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", arg0)
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1)
 	ret0, _ := ret[0].(*model.CreateOrderResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockCoinbaseClientMockRecorder) CreateOrder(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockCoinbaseClient)(nil).CreateOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockCoinbaseClient)(nil).CreateOrder), arg0, arg1)
 }
 
 // GetAccount mocks base method.
-func (m *MockCoinbaseClient) GetAccount(arg0 string) (*model.Account, error) {
+func (m *MockCoinbaseClient) GetAccount(arg0 context.Context, arg1 string) (*model.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0)
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
 	ret0, _ := ret[0].(*model.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAccount indicates an expected call of GetAccount.
-func (mr *MockCoinbaseClientMockRecorder) GetAccount(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockCoinbaseClient)(nil).GetAccount), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockCoinbaseClient)(nil).GetAccount), arg0, arg1)
+}
+
+// GetExchangeRate mocks base method.
+func (m *MockCoinbaseClient) GetExchangeRate(arg0 context.Context, arg1 string) (*model.GetExchangeRateResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRate", arg0, arg1)
+	ret0, _ := ret[0].(*model.GetExchangeRateResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRate indicates an expected call of GetExchangeRate.
+func (mr *MockCoinbaseClientMockRecorder) GetExchangeRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRate", reflect.TypeOf((*MockCoinbaseClient)(nil).GetExchangeRate), arg0, arg1)
 }
 
 // GetOrder mocks base method.
-func (m *MockCoinbaseClient) GetOrder(arg0 string) (*model.GetOrderResponse, error) {
+func (m *MockCoinbaseClient) GetOrder(arg0 context.Context, arg1 string) (*model.GetOrderResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrder", arg0)
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1)
 	ret0, _ := ret[0].(*model.GetOrderResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrder indicates an expected call of GetOrder.
-func (mr *MockCoinbaseClientMockRecorder) GetOrder(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) GetOrder(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockCoinbaseClient)(nil).GetOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockCoinbaseClient)(nil).GetOrder), arg0, arg1)
 }
 
 // GetPrice mocks base method.
-func (m *MockCoinbaseClient) GetPrice(arg0, arg1 string) (*float64, error) {
+func (m *MockCoinbaseClient) GetPrice(arg0 context.Context, arg1, arg2 string) (*float64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrice", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetPrice", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*float64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrice indicates an expected call of GetPrice.
-func (mr *MockCoinbaseClientMockRecorder) GetPrice(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) GetPrice(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrice", reflect.TypeOf((*MockCoinbaseClient)(nil).GetPrice), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrice", reflect.TypeOf((*MockCoinbaseClient)(nil).GetPrice), arg0, arg1, arg2)
 }
 
 // GetProduct mocks base method.
-func (m *MockCoinbaseClient) GetProduct(arg0 string) (*model.GetProductResponse, error) {
+func (m *MockCoinbaseClient) GetProduct(arg0 context.Context, arg1 string) (*model.GetProductResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProduct", arg0)
+	ret := m.ctrl.Call(m, "GetProduct", arg0, arg1)
 	ret0, _ := ret[0].(*model.GetProductResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProduct indicates an expected call of GetProduct.
-func (mr *MockCoinbaseClientMockRecorder) GetProduct(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) GetProduct(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockCoinbaseClient)(nil).GetProduct), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockCoinbaseClient)(nil).GetProduct), arg0, arg1)
 }
 
 // GetQuote mocks base method.
-func (m *MockCoinbaseClient) GetQuote(arg0 string) (*client.Quote, error) {
+func (m *MockCoinbaseClient) GetQuote(arg0 context.Context, arg1 string) (*client.Quote, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQuote", arg0)
+	ret := m.ctrl.Call(m, "GetQuote", arg0, arg1)
 	ret0, _ := ret[0].(*client.Quote)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetQuote indicates an expected call of GetQuote.
-func (mr *MockCoinbaseClientMockRecorder) GetQuote(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) GetQuote(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuote", reflect.TypeOf((*MockCoinbaseClient)(nil).GetQuote), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuote", reflect.TypeOf((*MockCoinbaseClient)(nil).GetQuote), arg0, arg1)
 }
 
 // HttpClient mocks base method.
@@ -182,48 +198,48 @@ func (mr *MockCoinbaseClientMockRecorder) IsTokenValid(arg0 interface{}) *gomock
 }
 
 // ListAccounts mocks base method.
-func (m *MockCoinbaseClient) ListAccounts(arg0 *client.ListAccountsParams) (*model.ListAccountsResponse, error) {
+func (m *MockCoinbaseClient) ListAccounts(arg0 context.Context, arg1 *client.ListAccountsParams) (*model.ListAccountsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0)
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
 	ret0, _ := ret[0].(*model.ListAccountsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockCoinbaseClientMockRecorder) ListAccounts(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockCoinbaseClient)(nil).ListAccounts), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockCoinbaseClient)(nil).ListAccounts), arg0, arg1)
 }
 
 // ListFills mocks base method.
-func (m *MockCoinbaseClient) ListFills(arg0 *client.ListFillsParams) (*model.ListFillsResponse, error) {
+func (m *MockCoinbaseClient) ListFills(arg0 context.Context, arg1 *client.ListFillsParams) (*model.ListFillsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListFills", arg0)
+	ret := m.ctrl.Call(m, "ListFills", arg0, arg1)
 	ret0, _ := ret[0].(*model.ListFillsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListFills indicates an expected call of ListFills.
-func (mr *MockCoinbaseClientMockRecorder) ListFills(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) ListFills(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFills", reflect.TypeOf((*MockCoinbaseClient)(nil).ListFills), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFills", reflect.TypeOf((*MockCoinbaseClient)(nil).ListFills), arg0, arg1)
 }
 
 // ListOrders mocks base method.
-func (m *MockCoinbaseClient) ListOrders(arg0 *client.ListOrdersParams) (*model.ListOrdersResponse, error) {
+func (m *MockCoinbaseClient) ListOrders(arg0 context.Context, arg1 *client.ListOrdersParams) (*model.ListOrdersResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListOrders", arg0)
+	ret := m.ctrl.Call(m, "ListOrders", arg0, arg1)
 	ret0, _ := ret[0].(*model.ListOrdersResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListOrders indicates an expected call of ListOrders.
-func (mr *MockCoinbaseClientMockRecorder) ListOrders(arg0 interface{}) *gomock.Call {
+func (mr *MockCoinbaseClientMockRecorder) ListOrders(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockCoinbaseClient)(nil).ListOrders), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockCoinbaseClient)(nil).ListOrders), arg0, arg1)
 }
 
 // SetRateLimit mocks base method.
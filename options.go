@@ -0,0 +1,71 @@
+package apiclient
+
+import (
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Option func(c *apiclient)
+
+// WithOrderJournal records every order placed through CreateOrderAndWaitForCompletion so Recover can
+// reconcile them after a crash
+func WithOrderJournal(journal OrderJournal) Option {
+	return func(c *apiclient) {
+		c.orderJournal = journal
+	}
+}
+
+// WithRiskPolicy checks every order against the policy before it is sent to coinbase
+func WithRiskPolicy(policy RiskPolicy) Option {
+	return func(c *apiclient) {
+		c.riskPolicy = &policy
+	}
+}
+
+// WithClock replaces the real clock for every sleep, retry delay, timeout and time window the client and the
+// strategies built on it use. A nil clock is the real clock
+func WithClock(clock Clock) Option {
+	return func(c *apiclient) {
+		c.clock = clockOrReal(clock)
+	}
+}
+
+// WithMetrics reports request latency, error codes, retries and time to fill to hook. A nil hook reports nothing
+func WithMetrics(hook MetricsHook) Option {
+	return func(c *apiclient) {
+		if hook == nil {
+			hook = noopMetrics{}
+		}
+		c.metrics = hook
+	}
+}
+
+// WithTracerProvider creates a span for every ApiClient method that takes a context. The global otel provider is
+// used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *apiclient) {
+		c.tracer = newTracer(provider)
+	}
+}
+
+// WithInterceptors wraps the client returned by NewApiClient. The first interceptor sees every call first
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *apiclient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithDryRun validates every order and cancel against the exchange but never sends them. Orders that would have been
+// placed come back with a DryRunOrderIDPrefix order id
+func WithDryRun() Option {
+	return func(c *apiclient) {
+		c.dryRun = true
+	}
+}
+
+// WithProductValidation checks every product id against the listed products before a request is sent so a swapped
+// base and quote fails fast. The catalog is fetched on first use and cached for an hour
+func WithProductValidation() Option {
+	return func(c *apiclient) {
+		c.validateProducts = true
+	}
+}
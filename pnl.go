@@ -0,0 +1,249 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+// Position is tracked with average cost. Prices, P&L and fees are in the product's quote currency
+type Position struct {
+	ProductID     string
+	BaseCurrency  string
+	QuoteCurrency string
+	// Quantity is negative when more has been sold than bought
+	Quantity    float64
+	AverageCost float64
+	RealizedPnL float64
+	Fees        float64
+}
+
+type ProductPnL struct {
+	Position
+	// CurrentPrice is in the product's quote currency
+	CurrentPrice float64
+	// ConversionRate converts the product's quote currency into the report currency
+	ConversionRate float64
+	// Everything below is in the report currency
+	Realized   float64
+	Unrealized float64
+	FeesPaid   float64
+	Exposure   float64
+}
+
+type PnLReport struct {
+	Currency      string
+	Products      []ProductPnL
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	Fees          float64
+	// NetPnL is realized + unrealized - fees
+	NetPnL      float64
+	NetExposure float64
+}
+
+type PnLTracker struct {
+	mutex     *sync.Mutex
+	positions map[string]*Position
+	seen      map[string]bool
+}
+
+func NewPnLTracker() *PnLTracker {
+	return &PnLTracker{
+		mutex:     &sync.Mutex{},
+		positions: make(map[string]*Position),
+		seen:      make(map[string]bool),
+	}
+}
+
+// Ingest applies fills in trade time order. Fills that were already ingested are skipped so overlapping
+// history ranges are safe
+func (t *PnLTracker) Ingest(fills ...cbadvmodel.OrderFill) error {
+	sorted := make([]cbadvmodel.OrderFill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return FillTime(&sorted[i]).Before(FillTime(&sorted[j]))
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for idx := range sorted {
+		fill := &sorted[idx]
+
+		key := fill.GetEntryId()
+		if key == "" {
+			key = fill.GetTradeId() + "-" + fill.GetOrderId()
+		}
+		if t.seen[key] {
+			continue
+		}
+
+		if err := t.apply(fill); err != nil {
+			return err
+		}
+		t.seen[key] = true
+	}
+
+	return nil
+}
+
+// IngestHistory pulls every fill for the product between start and end from the client
+func (t *PnLTracker) IngestHistory(ctx context.Context, client ApiClient, productID ProductID, start, end time.Time) error {
+	fills, err := client.GetFillsByTimeRange(ctx, productID, start, end)
+	if err != nil {
+		return err
+	}
+
+	return t.Ingest(fills...)
+}
+
+func (t *PnLTracker) IngestOrder(ctx context.Context, client ApiClient, orderID string, productID ProductID) error {
+	fills, err := client.GetOrderFills(ctx, orderID, productID)
+	if err != nil {
+		return err
+	}
+
+	return t.Ingest(fills...)
+}
+
+func (t *PnLTracker) Positions() []Position {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	positions := []Position{}
+	for _, pos := range t.positions {
+		positions = append(positions, *pos)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].ProductID < positions[j].ProductID })
+
+	return positions
+}
+
+// Report values every position at the current GetProduct price and converts everything into currency
+func (t *PnLTracker) Report(ctx context.Context, client ApiClient, currency string) (*PnLReport, error) {
+	report := &PnLReport{Currency: currency, Products: []ProductPnL{}}
+	rates := map[string]float64{currency: 1}
+
+	for _, pos := range t.Positions() {
+		product, err := client.GetProduct(ctx, NewProductID(pos.BaseCurrency, pos.QuoteCurrency))
+		if err != nil {
+			return nil, err
+		}
+
+		rate, exists := rates[pos.QuoteCurrency]
+		if !exists {
+			if rate, err = conversionRate(ctx, client, pos.QuoteCurrency, currency); err != nil {
+				return nil, err
+			}
+			rates[pos.QuoteCurrency] = rate
+		}
+
+		pnl := ProductPnL{
+			Position:       pos,
+			CurrentPrice:   utils.Float64PtrToFloat64(product.Price),
+			ConversionRate: rate,
+		}
+		pnl.Realized = pos.RealizedPnL * rate
+		pnl.Unrealized = pos.Quantity * (pnl.CurrentPrice - pos.AverageCost) * rate
+		pnl.FeesPaid = pos.Fees * rate
+		pnl.Exposure = pos.Quantity * pnl.CurrentPrice * rate
+
+		report.Products = append(report.Products, pnl)
+		report.RealizedPnL += pnl.Realized
+		report.UnrealizedPnL += pnl.Unrealized
+		report.Fees += pnl.FeesPaid
+		report.NetExposure += pnl.Exposure
+	}
+
+	report.NetPnL = report.RealizedPnL + report.UnrealizedPnL - report.Fees
+
+	return report, nil
+}
+
+// apply must be called with the mutex held
+func (t *PnLTracker) apply(fill *cbadvmodel.OrderFill) error {
+	pos, exists := t.positions[fill.GetProductId()]
+	if !exists {
+		productID, err := ParseProductID(fill.GetProductId())
+		if err != nil {
+			return err
+		}
+
+		pos = &Position{ProductID: fill.GetProductId(), BaseCurrency: productID.Base(), QuoteCurrency: productID.Quote()}
+		t.positions[fill.GetProductId()] = pos
+	}
+
+	price, size := fill.GetPrice(), fill.GetSize()
+	if fill.GetSizeInQuote() && price > 0 {
+		size = size / price
+	}
+
+	switch sideType(fill.GetSide()) {
+	case BuySideType:
+	case SellSideType:
+		size = -size
+	default:
+		return fmt.Errorf("fill '%s' has unknown side '%s'", fill.GetEntryId(), fill.GetSide())
+	}
+
+	pos.Fees += fill.GetCommission()
+
+	if pos.Quantity == 0 || math.Signbit(pos.Quantity) == math.Signbit(size) {
+		// adding to the position
+		pos.AverageCost = (pos.AverageCost*math.Abs(pos.Quantity) + price*math.Abs(size)) / (math.Abs(pos.Quantity) + math.Abs(size))
+		pos.Quantity += size
+		return nil
+	}
+
+	// reducing the position and maybe flipping it
+	closed := math.Min(math.Abs(size), math.Abs(pos.Quantity))
+	direction := 1.0
+	if pos.Quantity < 0 {
+		direction = -1
+	}
+	pos.RealizedPnL += closed * (price - pos.AverageCost) * direction
+
+	remaining := pos.Quantity + size
+	if math.Abs(remaining) < 1e-12 {
+		pos.Quantity, pos.AverageCost = 0, 0
+	} else if math.Signbit(remaining) != math.Signbit(pos.Quantity) {
+		pos.Quantity, pos.AverageCost = remaining, price
+	} else {
+		pos.Quantity = remaining
+	}
+
+	return nil
+}
+
+// conversionRate tries the direct product and then the inverse ex. BTC-USD or USD-BTC
+func conversionRate(ctx context.Context, client ApiClient, from, to string) (float64, error) {
+	if product, err := client.GetProduct(ctx, NewProductID(from, to)); err == nil && utils.Float64PtrToFloat64(product.Price) > 0 {
+		return utils.Float64PtrToFloat64(product.Price), nil
+	}
+
+	product, err := client.GetProduct(ctx, NewProductID(to, from))
+	if err != nil {
+		return 0, fmt.Errorf("unable to convert %s to %s: %w", from, to, err)
+	} else if utils.Float64PtrToFloat64(product.Price) <= 0 {
+		return 0, fmt.Errorf("unable to convert %s to %s: no price", from, to)
+	}
+
+	return 1 / utils.Float64PtrToFloat64(product.Price), nil
+}
+
+// FillTime is when the fill traded and falls back to its sequence timestamp
+func FillTime(fill *cbadvmodel.OrderFill) time.Time {
+	tm, err := time.Parse(time.RFC3339Nano, fill.GetTradeTime())
+	if err != nil {
+		tm, _ = time.Parse(time.RFC3339Nano, fill.GetSequenceTimestamp())
+	}
+
+	return tm
+}
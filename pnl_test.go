@@ -0,0 +1,100 @@
+package apiclient_test
+
+import (
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func newFill(entryID, productID, side string, price, size, commission float64, tradeTime string) model.OrderFill {
+	return model.OrderFill{
+		EntryId:    utils.StringToPtr(entryID),
+		ProductId:  utils.StringToPtr(productID),
+		Side:       utils.StringToPtr(side),
+		Price:      utils.Float64ToFloat64Ptr(price),
+		Size:       utils.Float64ToFloat64Ptr(size),
+		Commission: utils.Float64ToFloat64Ptr(commission),
+		TradeTime:  utils.StringToPtr(tradeTime),
+	}
+}
+
+var _ = Describe("PnLTracker", func() {
+	var (
+		ctrl    *gomock.Controller
+		client  *mocks.MockApiClient
+		tracker *PnLTracker
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		tracker = NewPnLTracker()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should track average cost and realized pnl", func() {
+		// out of order on purpose
+		Expect(tracker.Ingest(
+			newFill("3", "YFI-BTC", "SELL", 0.6, 1, 0.001, "2023-02-24T03:00:00Z"),
+			newFill("1", "YFI-BTC", "BUY", 0.4, 2, 0.001, "2023-02-24T01:00:00Z"),
+			newFill("2", "YFI-BTC", "BUY", 0.5, 2, 0.001, "2023-02-24T02:00:00Z"),
+		)).To(Succeed())
+		// the same fill again from an overlapping range
+		Expect(tracker.Ingest(newFill("3", "YFI-BTC", "SELL", 0.6, 1, 0.001, "2023-02-24T03:00:00Z"))).To(Succeed())
+
+		positions := tracker.Positions()
+		Expect(positions).To(HaveLen(1))
+		Expect(positions[0].Quantity).To(BeNumerically("~", 3))
+		Expect(positions[0].AverageCost).To(BeNumerically("~", 0.45))
+		Expect(positions[0].RealizedPnL).To(BeNumerically("~", 0.15))
+		Expect(positions[0].Fees).To(BeNumerically("~", 0.003))
+	})
+
+	It("should flip into a short position at the sell price", func() {
+		Expect(tracker.Ingest(
+			newFill("1", "BTC-USD", "BUY", 100, 1, 0, "2023-02-24T01:00:00Z"),
+			newFill("2", "BTC-USD", "SELL", 120, 3, 0, "2023-02-24T02:00:00Z"),
+		)).To(Succeed())
+
+		positions := tracker.Positions()
+		Expect(positions[0].Quantity).To(BeNumerically("~", -2))
+		Expect(positions[0].AverageCost).To(BeNumerically("~", 120))
+		Expect(positions[0].RealizedPnL).To(BeNumerically("~", 20))
+	})
+
+	It("should report everything in the chosen currency", func() {
+		start, end := time.Now().Add(-time.Hour), time.Now()
+		client.EXPECT().GetFillsByTimeRange(gomock.Any(), ProductID("YFI-BTC"), start, end).Return([]model.OrderFill{
+			newFill("1", "YFI-BTC", "BUY", 0.4, 2, 0.001, "2023-02-24T01:00:00Z"),
+			newFill("2", "YFI-BTC", "BUY", 0.5, 2, 0.001, "2023-02-24T02:00:00Z"),
+			newFill("3", "YFI-BTC", "SELL", 0.6, 1, 0.001, "2023-02-24T03:00:00Z"),
+		}, nil)
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("YFI-BTC")).Return(&model.GetProductResponse{
+			Price: utils.Float64ToFloat64Ptr(0.5),
+		}, nil)
+		client.EXPECT().GetProduct(gomock.Any(), ProductID("BTC-USD")).Return(&model.GetProductResponse{
+			Price: utils.Float64ToFloat64Ptr(20000),
+		}, nil)
+
+		Expect(tracker.IngestHistory(ctx, client, "YFI-BTC", start, end)).To(Succeed())
+
+		report, err := tracker.Report(ctx, client, "USD")
+		Expect(err).To(BeNil())
+		Expect(report.Products).To(HaveLen(1))
+		Expect(report.RealizedPnL).To(BeNumerically("~", 3000, 1e-6))
+		Expect(report.UnrealizedPnL).To(BeNumerically("~", 3000, 1e-6))
+		Expect(report.Fees).To(BeNumerically("~", 60, 1e-6))
+		Expect(report.NetPnL).To(BeNumerically("~", 5940, 1e-6))
+		Expect(report.NetExposure).To(BeNumerically("~", 30000, 1e-6))
+	})
+})
@@ -0,0 +1,241 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidProductID = errors.New("invalid product id")
+	ErrUnknownProduct   = errors.New("unknown product")
+	ErrProductDisabled  = errors.New("product is disabled")
+)
+
+// ProductID is a coinbase product id ex. BTC-USD where BTC is the base and USD is the quote currency
+type ProductID string
+
+func NewProductID(baseTicker, quoteTicker string) ProductID {
+	return ProductID(baseTicker + "-" + quoteTicker)
+}
+
+// ParseProductID only checks the format. Use ProductCatalog.Parse to make sure the product exists
+func ParseProductID(s string) (ProductID, error) {
+	id := ProductID(s)
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (id ProductID) Base() string {
+	base, _, _ := strings.Cut(string(id), "-")
+	return base
+}
+
+func (id ProductID) Quote() string {
+	_, quote, _ := strings.Cut(string(id), "-")
+	return quote
+}
+
+func (id ProductID) String() string {
+	return string(id)
+}
+
+// Inverse swaps the base and quote ex. USD-BTC for BTC-USD
+func (id ProductID) Inverse() ProductID {
+	return NewProductID(id.Quote(), id.Base())
+}
+
+// Validate checks for exactly two upper case tickers joined by a dash
+func (id ProductID) Validate() error {
+	parts := strings.Split(string(id), "-")
+	if len(parts) != 2 {
+		return fmt.Errorf("%w '%s': expected BASE-QUOTE", ErrInvalidProductID, id)
+	}
+
+	for _, ticker := range parts {
+		if ticker == "" {
+			return fmt.Errorf("%w '%s': empty ticker", ErrInvalidProductID, id)
+		}
+		for _, r := range ticker {
+			if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+				return fmt.Errorf("%w '%s': tickers must be upper case letters and digits", ErrInvalidProductID, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CatalogProduct is the part of a listed product the client cares about
+type CatalogProduct struct {
+	ID             ProductID
+	Price          float64
+	BaseIncrement  float64
+	QuoteIncrement float64
+	BaseMinSize    float64
+	QuoteMinSize   float64
+	// Disabled is true when the product can't be traded right now or is cancel only
+	Disabled bool
+}
+
+// ProductCatalog is every product listed on the exchange
+type ProductCatalog struct {
+	products map[ProductID]CatalogProduct
+	ids      []ProductID
+}
+
+func NewProductCatalog(products ...CatalogProduct) *ProductCatalog {
+	catalog := &ProductCatalog{products: make(map[ProductID]CatalogProduct)}
+	for _, product := range products {
+		if _, exists := catalog.products[product.ID]; !exists {
+			catalog.ids = append(catalog.ids, product.ID)
+		}
+		catalog.products[product.ID] = product
+	}
+	sort.Slice(catalog.ids, func(i, j int) bool { return catalog.ids[i] < catalog.ids[j] })
+
+	return catalog
+}
+
+// Products are sorted by id
+func (c *ProductCatalog) Products() []CatalogProduct {
+	products := make([]CatalogProduct, 0, len(c.ids))
+	for _, id := range c.ids {
+		products = append(products, c.products[id])
+	}
+
+	return products
+}
+
+func (c *ProductCatalog) Get(id ProductID) (CatalogProduct, bool) {
+	product, exists := c.products[id]
+	return product, exists
+}
+
+// Validate fails when the product isn't listed or can't be traded right now and says so when the base and quote
+// look swapped
+func (c *ProductCatalog) Validate(id ProductID) error {
+	if err := c.validateListed(id); err != nil {
+		return err
+	} else if c.products[id].Disabled {
+		return fmt.Errorf("%w '%s'", ErrProductDisabled, id)
+	}
+
+	return nil
+}
+
+// validateListed is Validate without the disabled check so a cancel only product can still be read and cancelled
+func (c *ProductCatalog) validateListed(id ProductID) error {
+	if err := id.Validate(); err != nil {
+		return err
+	} else if _, exists := c.products[id]; exists {
+		return nil
+	} else if _, exists := c.products[id.Inverse()]; exists {
+		return fmt.Errorf("%w '%s': base and quote are swapped, did you mean '%s'", ErrUnknownProduct, id, id.Inverse())
+	}
+
+	return fmt.Errorf("%w '%s'", ErrUnknownProduct, id)
+}
+
+// Parse checks the format and that the product is listed
+func (c *ProductCatalog) Parse(s string) (ProductID, error) {
+	id := ProductID(s)
+	if err := c.Validate(id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// catalogTTL is how long a listed catalog is trusted before it's fetched again
+const catalogTTL = time.Hour
+
+// GetProductCatalog lists every product. The result is cached for an hour
+func (c *apiclient) GetProductCatalog(ctx context.Context) (_ *ProductCatalog, err error) {
+	ctx, span := c.startSpan(ctx, "GetProductCatalog")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	c.catalogMutex.Lock()
+	defer c.catalogMutex.Unlock()
+
+	if c.catalog != nil && c.clock.Now().Sub(c.catalogFetchedAt) < catalogTTL {
+		return c.catalog, nil
+	}
+
+	start := c.clock.Now()
+	listed, err := c.backup.ListProducts(ctx)
+	c.observeRequest("ListProducts", start, err)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []CatalogProduct{}
+	for _, product := range listed {
+		if product == nil {
+			continue
+		}
+
+		id := ProductID(product.ProductID)
+		if id.Validate() != nil {
+			// futures and other products that don't follow BASE-QUOTE
+			continue
+		}
+
+		products = append(products, CatalogProduct{
+			ID:             id,
+			Price:          parseCatalogFloat(product.Price),
+			BaseIncrement:  parseCatalogFloat(product.BaseIncrement),
+			QuoteIncrement: parseCatalogFloat(product.QuoteIncrement),
+			BaseMinSize:    parseCatalogFloat(product.BaseMinSize),
+			QuoteMinSize:   parseCatalogFloat(product.QuoteMinSize),
+			Disabled:       product.IsDisabled || product.TradingDisabled || product.CancelOnly,
+		})
+	}
+
+	c.catalog = NewProductCatalog(products...)
+	c.catalogFetchedAt = c.clock.Now()
+
+	return c.catalog, nil
+}
+
+// checkProductID runs before any request that takes a product id. An empty id means every product and is left
+// alone. The catalog is only consulted when WithProductValidation is set
+func (c *apiclient) checkProductID(ctx context.Context, id ProductID) error {
+	return c.checkProductIDWith(ctx, id, (*ProductCatalog).validateListed)
+}
+
+// checkTradableProductID is checkProductID for requests that place orders so a disabled product fails too
+func (c *apiclient) checkTradableProductID(ctx context.Context, id ProductID) error {
+	return c.checkProductIDWith(ctx, id, (*ProductCatalog).Validate)
+}
+
+func (c *apiclient) checkProductIDWith(ctx context.Context, id ProductID, validate func(*ProductCatalog, ProductID) error) error {
+	if id == "" {
+		return nil
+	} else if err := id.Validate(); err != nil {
+		return err
+	} else if !c.validateProducts {
+		return nil
+	}
+
+	catalog, err := c.GetProductCatalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	return validate(catalog, id)
+}
+
+func parseCatalogFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
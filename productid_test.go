@@ -0,0 +1,134 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	coinbasegoclientv3 "github.com/happilymarrieddad/coinbase-go-client-v3"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProductID", func() {
+	It("should parse and split a product id", func() {
+		id, err := ParseProductID("YFI-BTC")
+		Expect(err).To(BeNil())
+		Expect(id.Base()).To(Equal("YFI"))
+		Expect(id.Quote()).To(Equal("BTC"))
+		Expect(id.String()).To(Equal("YFI-BTC"))
+		Expect(id.Inverse()).To(Equal(ProductID("BTC-YFI")))
+		Expect(NewProductID("YFI", "BTC")).To(Equal(id))
+	})
+
+	It("should reject malformed product ids", func() {
+		for _, s := range []string{"", "YFI", "YFI-", "-BTC", "YFI-BTC-USD", "yfi-btc", "YFI_BTC"} {
+			_, err := ParseProductID(s)
+			Expect(errors.Is(err, ErrInvalidProductID)).To(BeTrue(), s)
+		}
+	})
+
+	It("should catch a swapped base and quote against the catalog", func() {
+		catalog := NewProductCatalog(CatalogProduct{ID: "YFI-BTC"}, CatalogProduct{ID: "BTC-USD"})
+
+		id, err := catalog.Parse("BTC-USD")
+		Expect(err).To(BeNil())
+		Expect(id).To(Equal(ProductID("BTC-USD")))
+
+		_, err = catalog.Parse("BTC-YFI")
+		Expect(errors.Is(err, ErrUnknownProduct)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("did you mean 'YFI-BTC'"))
+
+		_, err = catalog.Parse("ETH-USD")
+		Expect(errors.Is(err, ErrUnknownProduct)).To(BeTrue())
+
+		Expect(catalog.Products()).To(Equal([]CatalogProduct{{ID: "BTC-USD"}, {ID: "YFI-BTC"}}))
+	})
+
+	It("should reject a disabled product", func() {
+		catalog := NewProductCatalog(CatalogProduct{ID: "ETH-BTC", Disabled: true})
+
+		_, err := catalog.Parse("ETH-BTC")
+		Expect(errors.Is(err, ErrProductDisabled)).To(BeTrue())
+	})
+
+	Context("WithProductValidation", func() {
+		var (
+			ctrl     *gomock.Controller
+			cbClient *mocks.MockCoinbaseClient
+			backup   *mock_client.MockClient
+			clock    *FakeClock
+			cont     ApiClient
+			ctx      context.Context
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			cbClient = mocks.NewMockCoinbaseClient(ctrl)
+			backup = mock_client.NewMockClient(ctrl)
+			clock = NewFakeClock(time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+			ctx = context.Background()
+
+			var err error
+			cont, err = NewApiClient(cbClient, backup, false, WithClock(clock), WithProductValidation())
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should fail before sending a request for a product that isn't listed", func() {
+			backup.EXPECT().ListProducts(gomock.Any()).Return([]*coinbasegoclientv3.Product{
+				{ProductID: "YFI-BTC", BaseIncrement: "0.001", QuoteIncrement: "0.00001", Price: "0.25"},
+				{ProductID: "BTC-PERP-INTX"},
+			}, nil)
+
+			_, err := cont.GetProduct(ctx, NewProductID("BTC", "YFI"))
+			Expect(errors.Is(err, ErrUnknownProduct)).To(BeTrue())
+
+			_, err = cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+				BaseTicker: "BTC", QuoteTicker: "YFI", Price: 4, Quantity: 1, Side: BuySideType,
+			})
+			Expect(errors.Is(err, ErrUnknownProduct)).To(BeTrue())
+
+			catalog, err := cont.GetProductCatalog(ctx)
+			Expect(err).To(BeNil())
+			Expect(catalog.Products()).To(Equal([]CatalogProduct{{ID: "YFI-BTC", Price: 0.25, BaseIncrement: 0.001, QuoteIncrement: 0.00001}}))
+		})
+
+		It("should still read a disabled product but not place orders on it", func() {
+			backup.EXPECT().ListProducts(gomock.Any()).Return([]*coinbasegoclientv3.Product{
+				{ProductID: "YFI-BTC", CancelOnly: true},
+			}, nil)
+			cbClient.EXPECT().GetProduct(gomock.Any(), "YFI-BTC").Return(&model.GetProductResponse{}, nil)
+
+			_, err := cont.GetProduct(ctx, NewProductID("YFI", "BTC"))
+			Expect(err).To(BeNil())
+
+			_, err = cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+				BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.25, Quantity: 1, Side: BuySideType,
+			})
+			Expect(errors.Is(err, ErrProductDisabled)).To(BeTrue())
+		})
+
+		It("should fetch the catalog again once it's an hour old", func() {
+			backup.EXPECT().ListProducts(gomock.Any()).Return([]*coinbasegoclientv3.Product{{ProductID: "YFI-BTC"}}, nil).Times(2)
+
+			_, err := cont.GetProductCatalog(ctx)
+			Expect(err).To(BeNil())
+			_, err = cont.GetProductCatalog(ctx)
+			Expect(err).To(BeNil())
+
+			clock.Advance(time.Hour)
+			_, err = cont.GetProductCatalog(ctx)
+			Expect(err).To(BeNil())
+		})
+	})
+})
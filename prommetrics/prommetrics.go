@@ -0,0 +1,94 @@
+package prommetrics
+
+import (
+	"time"
+
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TimeToFillBuckets cover resting limit orders that can take hours to fill
+var TimeToFillBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 4 * 3600, 24 * 3600}
+
+// Metrics is an apiclient.MetricsHook backed by prometheus collectors
+type Metrics struct {
+	requests   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	errorCodes *prometheus.CounterVec
+	retries    *prometheus.CounterVec
+	timeToFill *prometheus.HistogramVec
+}
+
+var _ apiclient.MetricsHook = (*Metrics)(nil)
+
+// New registers every collector with registerer. An empty namespace defaults to coinbase
+func New(registerer prometheus.Registerer, namespace string) (*Metrics, error) {
+	if namespace == "" {
+		namespace = "coinbase"
+	}
+
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Requests sent to coinbase by endpoint and result",
+		}, []string{"endpoint", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests sent to coinbase by endpoint",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		errorCodes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "error_responses_total",
+			Help:      "Error codes coinbase returned in the response body by endpoint",
+		}, []string{"endpoint", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_retries_total",
+			Help:      "Retries made while creating orders by reason",
+		}, []string{"reason"}),
+		timeToFill: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "order_time_to_fill_seconds",
+			Help:      "Time from order creation until it was seen filled",
+			Buckets:   TimeToFillBuckets,
+		}, []string{"product_id", "side"}),
+	}
+
+	for _, collector := range []prometheus.Collector{m.requests, m.latency, m.errorCodes, m.retries, m.timeToFill} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Metrics) ObserveRequest(endpoint string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.requests.WithLabelValues(endpoint, result).Inc()
+	m.latency.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveErrorCode(endpoint, code string) {
+	if code == "" {
+		code = "UNKNOWN"
+	}
+
+	m.errorCodes.WithLabelValues(endpoint, code).Inc()
+}
+
+func (m *Metrics) ObserveRetry(reason string) {
+	m.retries.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) ObserveTimeToFill(productID, side string, duration time.Duration) {
+	m.timeToFill.WithLabelValues(productID, side).Observe(duration.Seconds())
+}
@@ -0,0 +1,13 @@
+package prommetrics_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPromMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PromMetrics Suite")
+}
@@ -0,0 +1,69 @@
+package prommetrics_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient/prommetrics"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		registry *prometheus.Registry
+		metrics  *Metrics
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+
+		var err error
+		metrics, err = New(registry, "")
+		Expect(err).To(BeNil())
+	})
+
+	It("should count requests by endpoint and result and record their latency", func() {
+		metrics.ObserveRequest("GetOrder", 120*time.Millisecond, nil)
+		metrics.ObserveRequest("GetOrder", 80*time.Millisecond, nil)
+		metrics.ObserveRequest("CreateOrder", time.Second, errors.New("boom"))
+
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP coinbase_requests_total Requests sent to coinbase by endpoint and result
+# TYPE coinbase_requests_total counter
+coinbase_requests_total{endpoint="CreateOrder",result="error"} 1
+coinbase_requests_total{endpoint="GetOrder",result="success"} 2
+`), "coinbase_requests_total")).To(Succeed())
+
+		Expect(testutil.CollectAndCount(registry, "coinbase_request_duration_seconds")).To(Equal(2))
+	})
+
+	It("should count error codes, retries and time to fill", func() {
+		metrics.ObserveErrorCode("CreateOrder", "INSUFFICIENT_FUND")
+		metrics.ObserveErrorCode("CancelOrders", "")
+		metrics.ObserveRetry("INVALID_PRICE_PRECISION")
+		metrics.ObserveRetry("INVALID_PRICE_PRECISION")
+		metrics.ObserveTimeToFill("BTC-USD", "BUY", 42*time.Second)
+
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP coinbase_error_responses_total Error codes coinbase returned in the response body by endpoint
+# TYPE coinbase_error_responses_total counter
+coinbase_error_responses_total{code="INSUFFICIENT_FUND",endpoint="CreateOrder"} 1
+coinbase_error_responses_total{code="UNKNOWN",endpoint="CancelOrders"} 1
+# HELP coinbase_order_retries_total Retries made while creating orders by reason
+# TYPE coinbase_order_retries_total counter
+coinbase_order_retries_total{reason="INVALID_PRICE_PRECISION"} 2
+`), "coinbase_error_responses_total", "coinbase_order_retries_total")).To(Succeed())
+
+		Expect(testutil.CollectAndCount(registry, "coinbase_order_time_to_fill_seconds")).To(Equal(1))
+	})
+
+	It("should refuse to register twice", func() {
+		_, err := New(registry, "")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,62 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	cbadvclient "github.com/QuantFu-Inc/coinbase-adv/client"
+)
+
+// doRequest sends a signed request straight to the advanced trade api. This is only
+// for endpoints the coinbase client doesn't support yet
+//
+//	endpoint - the name the request is reported under in metrics
+//	path - everything after /api/v3 including the query string
+func (c *apiclient) doRequest(ctx context.Context, endpoint, method, path string, body, dest interface{}) (err error) {
+	start := c.clock.Now()
+	defer func() {
+		c.observeRequest(endpoint, start, err)
+	}()
+
+	var payload []byte
+	if body != nil {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bts
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cbadvclient.CoinbaseAdvV3endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	c.client.CheckAuthentication(req, payload)
+
+	res, err := c.client.HttpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, res.StatusCode, string(resBody))
+	}
+
+	if dest == nil {
+		return nil
+	}
+
+	return json.Unmarshal(resBody, dest)
+}
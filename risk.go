@@ -0,0 +1,261 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+var (
+	ErrRiskRejected      = errors.New("order rejected by risk policy")
+	ErrKillSwitchEngaged = errors.New("kill switch is engaged")
+)
+
+// RiskPolicy is evaluated before every order. A zero value for any limit disables it
+type RiskPolicy struct {
+	// MaxOrderNotional is price * quantity in the quote currency
+	MaxOrderNotional float64
+	// MaxDailyNotional is per quote currency and resets at midnight UTC
+	MaxDailyNotional        float64
+	MaxOpenOrdersPerProduct int
+	// AllowedProducts ex. YFI-BTC. Empty allows every product
+	AllowedProducts []string
+	// MaxPriceDeviationPercentage from the current mid price. 1% will be 1.0
+	MaxPriceDeviationPercentage float64
+}
+
+type riskState struct {
+	mutex      *sync.Mutex
+	killSwitch bool
+	// notional by day and quote currency
+	day           string
+	dailyNotional map[string]float64
+}
+
+func newRiskState() *riskState {
+	return &riskState{mutex: &sync.Mutex{}, dailyNotional: make(map[string]float64)}
+}
+
+// EngageKillSwitch rejects every new order until ReleaseKillSwitch is called
+func (c *apiclient) EngageKillSwitch(ctx context.Context, cancelOpenOrders bool) (err error) {
+	ctx, span := c.startSpan(ctx, "EngageKillSwitch")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	c.risk.mutex.Lock()
+	c.risk.killSwitch = true
+	c.risk.mutex.Unlock()
+
+	c.log("kill switch engaged")
+
+	if !cancelOpenOrders {
+		return nil
+	}
+
+	orders, err := c.GetOpenOrdersByProductIDAndSide(ctx, "", cbadvmodel.UNKNOWN_ORDER_SIDE)
+	if err != nil {
+		return err
+	}
+
+	orderIDs := []string{}
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.GetOrderId())
+	}
+
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	c.log("kill switch cancelling %d open orders", len(orderIDs))
+	return c.CancelOrders(ctx, orderIDs...)
+}
+
+func (c *apiclient) ReleaseKillSwitch() {
+	c.risk.mutex.Lock()
+	defer c.risk.mutex.Unlock()
+
+	c.risk.killSwitch = false
+	c.log("kill switch released")
+}
+
+func (c *apiclient) checkRisk(ctx context.Context, params *CreateLimitMarketOrderParams) error {
+	return c.checkRiskFor(ctx, params, params.Price*params.Quantity, true)
+}
+
+// checkRiskFor
+//
+//	added - what the order adds to the daily notional
+//	newOrder - false for an order that is already open so it doesn't count against the open order limit
+func (c *apiclient) checkRiskFor(ctx context.Context, params *CreateLimitMarketOrderParams, added float64, newOrder bool) error {
+	c.risk.mutex.Lock()
+	killSwitch := c.risk.killSwitch
+	c.risk.mutex.Unlock()
+
+	if killSwitch {
+		return ErrKillSwitchEngaged
+	} else if c.riskPolicy == nil {
+		return nil
+	}
+
+	productID := params.ProductID()
+	notional := params.Price * params.Quantity
+
+	if len(c.riskPolicy.AllowedProducts) > 0 {
+		allowed := false
+		for _, id := range c.riskPolicy.AllowedProducts {
+			allowed = allowed || id == productID.String()
+		}
+		if !allowed {
+			return fmt.Errorf("%w: product '%s' is not allowed", ErrRiskRejected, productID)
+		}
+	}
+
+	if c.riskPolicy.MaxOrderNotional > 0 && notional > c.riskPolicy.MaxOrderNotional {
+		return fmt.Errorf("%w: order notional %f is over the max of %f", ErrRiskRejected, notional, c.riskPolicy.MaxOrderNotional)
+	}
+
+	if err := c.checkDailyNotional(params.QuoteTicker, added, false); err != nil {
+		return err
+	}
+
+	if newOrder && c.riskPolicy.MaxOpenOrdersPerProduct > 0 {
+		orders, err := c.GetOpenOrdersByProductIDAndSide(ctx, productID, cbadvmodel.UNKNOWN_ORDER_SIDE)
+		if err != nil {
+			return err
+		}
+
+		if len(orders) >= c.riskPolicy.MaxOpenOrdersPerProduct {
+			return fmt.Errorf(
+				"%w: product '%s' already has %d open orders", ErrRiskRejected, productID, len(orders),
+			)
+		}
+	}
+
+	if c.riskPolicy.MaxPriceDeviationPercentage > 0 {
+		start := c.clock.Now()
+		product, err := c.client.GetProduct(ctx, productID.String())
+		c.observeRequest("GetProduct", start, err)
+		if err != nil {
+			return err
+		}
+
+		mid := utils.Float64PtrToFloat64(product.Price)
+		if midMarket, err := strconv.ParseFloat(product.GetMidMarketPrice(), 64); err == nil && midMarket > 0 {
+			mid = midMarket
+		}
+
+		if mid <= 0 {
+			return fmt.Errorf("%w: no current price for product '%s'", ErrRiskRejected, productID)
+		}
+
+		if deviation := math.Abs(params.Price-mid) / mid * 100; deviation > c.riskPolicy.MaxPriceDeviationPercentage {
+			return fmt.Errorf(
+				"%w: price %f is %f%% away from the mid price %f", ErrRiskRejected, params.Price, deviation, mid,
+			)
+		}
+	}
+
+	return nil
+}
+
+// reserveRisk runs checkRisk and counts the order against the daily notional in the same step so concurrent orders
+// can't each pass the check and go over the limit together. release gives the notional back and must only be called
+// when the order was rejected. Any other error could still have left an order on the book
+func (c *apiclient) reserveRisk(ctx context.Context, params *CreateLimitMarketOrderParams) (release func(), err error) {
+	return c.reserveRiskFor(ctx, params, params.Price*params.Quantity, true)
+}
+
+// reserveRiskFor is reserveRisk with the arguments of checkRiskFor
+func (c *apiclient) reserveRiskFor(
+	ctx context.Context, params *CreateLimitMarketOrderParams, added float64, newOrder bool,
+) (release func(), err error) {
+	if err := c.checkRiskFor(ctx, params, added, newOrder); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkDailyNotional(params.QuoteTicker, added, true); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		c.risk.mutex.Lock()
+		defer c.risk.mutex.Unlock()
+
+		c.rollRiskDay()
+		c.risk.dailyNotional[params.QuoteTicker] = math.Max(0, c.risk.dailyNotional[params.QuoteTicker]-added)
+	}, nil
+}
+
+// rejectedOrderError marks an order that never reached the book because it was refused before or by coinbase
+type rejectedOrderError struct {
+	error
+}
+
+func (e rejectedOrderError) Unwrap() error {
+	return e.error
+}
+
+func rejectOrder(err error) error {
+	return rejectedOrderError{error: err}
+}
+
+func isRejectedOrder(err error) bool {
+	var rejected rejectedOrderError
+	return errors.As(err, &rejected)
+}
+
+// checkDailyNotional adds notional to the day's total when reserve is set and it fits under the limit
+func (c *apiclient) checkDailyNotional(quoteTicker string, notional float64, reserve bool) error {
+	c.risk.mutex.Lock()
+	defer c.risk.mutex.Unlock()
+
+	c.rollRiskDay()
+
+	traded := c.risk.dailyNotional[quoteTicker]
+	if c.riskPolicy != nil && c.riskPolicy.MaxDailyNotional > 0 && traded+notional > c.riskPolicy.MaxDailyNotional {
+		return fmt.Errorf(
+			"%w: daily %s notional would be %f which is over the max of %f",
+			ErrRiskRejected, quoteTicker, traded+notional, c.riskPolicy.MaxDailyNotional,
+		)
+	}
+
+	if reserve {
+		c.risk.dailyNotional[quoteTicker] = traded + notional
+	}
+
+	return nil
+}
+
+// dailyNotionalReserver counts orders against the risk policy's daily notional. The dry run client uses it so the
+// orders it makes up count the same as placed ones
+type dailyNotionalReserver interface {
+	reserveDailyNotional(quoteTicker string, notional float64) error
+}
+
+func (c *apiclient) reserveDailyNotional(quoteTicker string, notional float64) error {
+	return c.checkDailyNotional(quoteTicker, notional, true)
+}
+
+// reserveDailyNotional counts notional against client's daily limit when it keeps one
+func reserveDailyNotional(client ApiClient, quoteTicker string, notional float64) error {
+	if reserver, ok := client.(dailyNotionalReserver); ok {
+		return reserver.reserveDailyNotional(quoteTicker, notional)
+	}
+
+	return nil
+}
+
+// rollRiskDay must be called with the risk mutex held
+func (c *apiclient) rollRiskDay() {
+	if day := c.clock.Now().UTC().Format("2006-01-02"); day != c.risk.day {
+		c.risk.day = day
+		c.risk.dailyNotional = make(map[string]float64)
+	}
+}
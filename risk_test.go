@@ -0,0 +1,242 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	cbadvclient "github.com/QuantFu-Inc/coinbase-adv/client"
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RiskPolicy", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		params   *CreateLimitMarketOrderParams
+	)
+
+	newClient := func(policy RiskPolicy) ApiClient {
+		cont, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithRiskPolicy(policy))
+		Expect(err).To(BeNil())
+		return cont
+	}
+
+	expectOrderPlaced := func(orderID string) {
+		success, status := true, model.OPEN
+		cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+			Success: &success, OrderId: utils.StringToPtr(orderID),
+		}, nil)
+		cbClient.EXPECT().GetOrder(gomock.Any(), orderID).Return(&model.GetOrderResponse{Order: &model.Order{
+			OrderId: utils.StringToPtr(orderID), Status: &status,
+		}}, nil)
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+
+		params = &CreateLimitMarketOrderParams{
+			BaseTicker:  "YFI",
+			QuoteTicker: "BTC",
+			Price:       0.4,
+			Quantity:    2,
+			Side:        BuySideType,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should reject products that are not allowed", func() {
+		_, err := newClient(RiskPolicy{AllowedProducts: []string{"BTC-USD"}}).CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should reject orders over the max notional", func() {
+		_, err := newClient(RiskPolicy{MaxOrderNotional: 0.5}).CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("order notional 0.800000"))
+	})
+
+	It("should reject orders once the daily notional is used up", func() {
+		cont := newClient(RiskPolicy{MaxDailyNotional: 1})
+		expectOrderPlaced("order-1")
+
+		_, err := cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).To(BeNil())
+
+		_, err = cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 1, Side: BuySideType,
+		})
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should not let a caller skip the policy by setting NumOfTries", func() {
+		params.NumOfTries = 3
+
+		_, err := newClient(RiskPolicy{MaxOrderNotional: 0.5}).CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should not let concurrent orders go over the daily notional together", func() {
+		cont := newClient(RiskPolicy{MaxDailyNotional: 1})
+
+		// the first create is in flight while the second one is checked
+		entered, release := make(chan struct{}), make(chan struct{})
+		success, status := true, model.OPEN
+		cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, _ *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+				close(entered)
+				<-release
+				return &model.CreateOrderResponse{Success: &success, OrderId: utils.StringToPtr("order-1")}, nil
+			})
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(&model.GetOrderResponse{Order: &model.Order{
+			OrderId: utils.StringToPtr("order-1"), Status: &status,
+		}}, nil)
+
+		done := make(chan error, 1)
+		go func() {
+			defer GinkgoRecover()
+			_, err := cont.CreateLimitMarketOrder(ctx, params)
+			done <- err
+		}()
+		Eventually(entered).Should(BeClosed())
+
+		_, err := cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 1, Side: BuySideType,
+		})
+		close(release)
+
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("should give the daily notional back when the create fails", func() {
+		cont := newClient(RiskPolicy{MaxDailyNotional: 1})
+
+		failure := false
+		cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+			Success:       &failure,
+			ErrorResponse: &model.CreateOrderResponseErrorResponse{Error: utils.StringToPtr("UNSUPPORTED_ORDER_CONFIGURATION")},
+		}, nil)
+
+		_, err := cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).NotTo(BeNil())
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeFalse())
+
+		expectOrderPlaced("order-1")
+		_, err = cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).To(BeNil())
+	})
+
+	It("should keep the daily notional when the order was placed but couldn't be looked up", func() {
+		cont := newClient(RiskPolicy{MaxDailyNotional: 1})
+
+		success := true
+		cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&model.CreateOrderResponse{
+			Success: &success, OrderId: utils.StringToPtr("order-1"),
+		}, nil)
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(nil, errors.New("i/o timeout"))
+
+		_, err := cont.CreateLimitMarketOrder(ctx, params)
+		Expect(err).To(MatchError("i/o timeout"))
+
+		_, err = cont.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+			BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 1, Side: BuySideType,
+		})
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should reject products with too many open orders", func() {
+		hasNext := true
+		gomock.InOrder(
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+				Orders: []model.Order{{}}, HasNext: &hasNext, Cursor: utils.StringToPtr("page-2"),
+			}, nil),
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+				Orders: []model.Order{{}},
+			}, nil),
+		)
+
+		_, err := newClient(RiskPolicy{MaxOpenOrdersPerProduct: 2}).CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+	})
+
+	It("should reject prices outside of the band around the mid price", func() {
+		cbClient.EXPECT().GetProduct(gomock.Any(), "YFI-BTC").Return(&model.GetProductResponse{
+			Price: utils.Float64ToFloat64Ptr(0.5),
+		}, nil)
+
+		_, err := newClient(RiskPolicy{MaxPriceDeviationPercentage: 5}).CreateLimitMarketOrder(ctx, params)
+		Expect(errors.Is(err, ErrRiskRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("20.000000% away"))
+	})
+
+	Context("kill switch", func() {
+		It("should reject every order until it is released", func() {
+			cont := newClient(RiskPolicy{})
+			Expect(cont.EngageKillSwitch(ctx, false)).To(Succeed())
+
+			_, err := cont.CreateLimitMarketOrder(ctx, params)
+			Expect(errors.Is(err, ErrKillSwitchEngaged)).To(BeTrue())
+
+			cont.ReleaseKillSwitch()
+			expectOrderPlaced("order-1")
+
+			_, err = cont.CreateLimitMarketOrder(ctx, params)
+			Expect(err).To(BeNil())
+		})
+
+		It("should cancel every open order when asked to", func() {
+			success := true
+			cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+				Orders: []model.Order{{OrderId: utils.StringToPtr("order-1")}, {OrderId: utils.StringToPtr("order-2")}},
+			}, nil)
+			cbClient.EXPECT().CancelOrders(gomock.Any(), []string{"order-1", "order-2"}).Return(&model.CancelOrderResponse{
+				Results: []model.CancelOrderResponseResultsInner{{Success: &success}, {Success: &success}},
+			}, nil)
+
+			Expect(newClient(RiskPolicy{}).EngageKillSwitch(ctx, true)).To(Succeed())
+		})
+
+		It("should page through the open orders and report every one that wasn't cancelled", func() {
+			success, failure, hasNext := true, false, true
+			gomock.InOrder(
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(&model.ListOrdersResponse{
+					Orders:  []model.Order{{OrderId: utils.StringToPtr("order-1")}},
+					HasNext: &hasNext,
+					Cursor:  utils.StringToPtr("page-2"),
+				}, nil),
+				cbClient.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, p *cbadvclient.ListOrdersParams) (*model.ListOrdersResponse, error) {
+						Expect(utils.StringPtrToString(p.Cursor)).To(Equal("page-2"))
+						return &model.ListOrdersResponse{
+							Orders: []model.Order{{OrderId: utils.StringToPtr("order-2")}, {OrderId: utils.StringToPtr("order-3")}},
+						}, nil
+					}),
+			)
+			cbClient.EXPECT().CancelOrders(gomock.Any(), []string{"order-1", "order-2", "order-3"}).Return(&model.CancelOrderResponse{
+				Results: []model.CancelOrderResponseResultsInner{
+					{Success: &success, OrderId: utils.StringToPtr("order-1")},
+					{Success: &failure, OrderId: utils.StringToPtr("order-2"), FailureReason: utils.StringToPtr("DUPLICATE_CANCEL_REQUEST")},
+					{Success: &success, OrderId: utils.StringToPtr("order-3")},
+				},
+			}, nil)
+
+			err := newClient(RiskPolicy{}).EngageKillSwitch(ctx, true)
+
+			var cancelErr *CancelOrdersError
+			Expect(errors.As(err, &cancelErr)).To(BeTrue())
+			Expect(cancelErr.Failures).To(Equal(map[string]string{"order-2": "DUPLICATE_CANCEL_REQUEST"}))
+		})
+	})
+})
@@ -0,0 +1,340 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+var ErrNoRoute = errors.New("no route")
+
+// DefaultMaxRouteLegs allows one or two intermediate currencies ex. YFI-BTC, BTC-USD, ETH-USD
+const DefaultMaxRouteLegs = 3
+
+// RouteLeg converts From into To on ProductID. Buying spends the quote currency and selling spends the base
+type RouteLeg struct {
+	ProductID ProductID
+	Side      sideType
+	From      string
+	To        string
+	// Price is the quoted price of ProductID when the route was found
+	Price          float64
+	FeeRate        float64
+	BaseIncrement  float64
+	QuoteIncrement float64
+	BaseMinSize    float64
+	// Rate is how much To one From turns into at Price once fees are paid
+	Rate float64
+}
+
+type Route struct {
+	From string
+	To   string
+	Legs []RouteLeg
+	// Rate is how much To one From turns into after every leg and fee
+	Rate float64
+}
+
+// Quote is what amount of From is expected to turn into at the quoted prices
+func (r *Route) Quote(amount float64) float64 {
+	return amount * r.Rate
+}
+
+type RouteFinder struct {
+	feeRate float64
+	// by currency so every product shows up under both its base and quote
+	edges   map[string][]RouteLeg
+	maxLegs int
+}
+
+// NewRouteFinder prices every leg at the quoted price plus the taker fee since every leg crosses the spread. A nil
+// fee tier is treated as free
+func NewRouteFinder(catalog *ProductCatalog, feeTier *FeeTier) *RouteFinder {
+	f := &RouteFinder{edges: make(map[string][]RouteLeg), maxLegs: DefaultMaxRouteLegs}
+	if feeTier != nil {
+		f.feeRate = feeTier.TakerFeeRate
+	}
+
+	for _, product := range catalog.Products() {
+		if product.Disabled || product.Price <= 0 {
+			continue
+		}
+
+		leg := RouteLeg{
+			ProductID:      product.ID,
+			Price:          product.Price,
+			FeeRate:        f.feeRate,
+			BaseIncrement:  product.BaseIncrement,
+			QuoteIncrement: product.QuoteIncrement,
+			BaseMinSize:    product.BaseMinSize,
+		}
+
+		buy := leg
+		buy.Side, buy.From, buy.To = BuySideType, product.ID.Quote(), product.ID.Base()
+		buy.Rate = 1 / (product.Price * (1 + f.feeRate))
+
+		sell := leg
+		sell.Side, sell.From, sell.To = SellSideType, product.ID.Base(), product.ID.Quote()
+		sell.Rate = product.Price * (1 - f.feeRate)
+
+		f.edges[buy.From] = append(f.edges[buy.From], buy)
+		f.edges[sell.From] = append(f.edges[sell.From], sell)
+	}
+
+	return f
+}
+
+// SetMaxLegs limits how many trades a route can take. Anything below 1 uses DefaultMaxRouteLegs
+func (f *RouteFinder) SetMaxLegs(maxLegs int) {
+	if maxLegs < 1 {
+		maxLegs = DefaultMaxRouteLegs
+	}
+	f.maxLegs = maxLegs
+}
+
+// FindRoute returns the route that turns one From into the most To. A currency is never visited twice
+func (f *RouteFinder) FindRoute(from, to string) (*Route, error) {
+	if from == to {
+		return nil, fmt.Errorf("%w: %s and %s are the same currency", ErrNoRoute, from, to)
+	}
+
+	var best *Route
+	visited := map[string]bool{from: true}
+	path := []RouteLeg{}
+
+	var walk func(currency string, rate float64)
+	walk = func(currency string, rate float64) {
+		for _, leg := range f.edges[currency] {
+			if visited[leg.To] {
+				continue
+			}
+
+			next := rate * leg.Rate
+			path = append(path, leg)
+
+			if leg.To == to {
+				// fewer legs wins a tie since every leg is another chance to fail
+				if best == nil || next > best.Rate || (next == best.Rate && len(path) < len(best.Legs)) {
+					best = &Route{From: from, To: to, Legs: append([]RouteLeg{}, path...), Rate: next}
+				}
+			} else if len(path) < f.maxLegs {
+				visited[leg.To] = true
+				walk(leg.To, next)
+				visited[leg.To] = false
+			}
+
+			path = path[:len(path)-1]
+		}
+	}
+	walk(from, 1)
+
+	if best == nil {
+		return nil, fmt.Errorf("%w from %s to %s within %d legs", ErrNoRoute, from, to, f.maxLegs)
+	}
+
+	return best, nil
+}
+
+type RouteExecutionParams struct {
+	// ID is the parent intent. Every leg uses ID-leg-<n> and every unwind ID-unwind-<n> as its own intent. A uuid
+	// is generated when it's empty
+	ID     string
+	Route  *Route  `validate:"required"`
+	Amount float64 `validate:"required"`
+	// LegTimeout is how long each leg gets to fill before it's cancelled
+	LegTimeout time.Duration `validate:"required"`
+	// SlippagePercentage prices each leg this far past the current price so it fills. 0.1% will be 0.1
+	SlippagePercentage float64
+	// Unwind trades whatever was acquired back into Route.From when a leg fails
+	Unwind bool
+}
+
+type RouteLegExecution struct {
+	Leg       RouteLeg
+	OrderID   string
+	Price     float64
+	Quantity  float64
+	AmountIn  float64
+	AmountOut float64
+	Status    string
+}
+
+type RouteExecution struct {
+	ID     string
+	Legs   []RouteLegExecution
+	Unwind []RouteLegExecution
+	// Holdings is what the execution is left holding by currency. A complete run only holds Route.To and whatever
+	// dust the increments left behind
+	Holdings  map[string]float64
+	Completed bool
+}
+
+type RouteExecutor struct {
+	client ApiClient
+}
+
+func NewRouteExecutor(client ApiClient) *RouteExecutor {
+	return &RouteExecutor{client: client}
+}
+
+// Execute trades Amount of Route.From through every leg in order and waits for each to fill before starting the
+// next. The result is always returned so partial progress can be inspected when an error stops it early
+func (e *RouteExecutor) Execute(ctx context.Context, params *RouteExecutionParams) (*RouteExecution, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	} else if len(params.Route.Legs) == 0 {
+		return nil, fmt.Errorf("%w: route has no legs", ErrNoRoute)
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	res := &RouteExecution{ID: params.ID, Legs: []RouteLegExecution{}, Holdings: map[string]float64{params.Route.From: params.Amount}}
+
+	for idx, leg := range params.Route.Legs {
+		exec, err := e.executeLeg(ctx, params, fmt.Sprintf("%s-leg-%d", params.ID, idx), leg, res.Holdings[leg.From])
+		if exec != nil {
+			res.Legs = append(res.Legs, *exec)
+			res.hold(exec)
+		}
+		if err != nil {
+			err = fmt.Errorf("leg %d %s %s failed: %w", idx+1, leg.Side, leg.ProductID, err)
+			if params.Unwind {
+				if unwindErr := e.unwind(ctx, params, res, idx); unwindErr != nil {
+					return res, fmt.Errorf("%s. unwind failed: %w", err.Error(), unwindErr)
+				}
+			}
+			return res, err
+		}
+	}
+
+	res.Completed = true
+	return res, nil
+}
+
+// unwind reverses every leg up to and including failed so anything a later leg picked up is carried back with it
+func (e *RouteExecutor) unwind(ctx context.Context, params *RouteExecutionParams, res *RouteExecution, failed int) error {
+	for idx := failed; idx >= 0; idx-- {
+		leg := inverseLeg(params.Route.Legs[idx])
+		if res.Holdings[leg.From] <= 0 {
+			continue
+		}
+
+		exec, err := e.executeLeg(ctx, params, fmt.Sprintf("%s-unwind-%d", params.ID, idx), leg, res.Holdings[leg.From])
+		if exec != nil {
+			res.Unwind = append(res.Unwind, *exec)
+			res.hold(exec)
+		}
+		if errors.Is(err, errBelowMinSize) {
+			// dust stays in Holdings
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var errBelowMinSize = errors.New("amount is below the minimum order size")
+
+func (e *RouteExecutor) executeLeg(
+	ctx context.Context, params *RouteExecutionParams, id string, leg RouteLeg, amount float64,
+) (*RouteLegExecution, error) {
+	_, _, currentPrice, _, err := e.client.GetProductMarketData(ctx, leg.ProductID, nil)
+	if err != nil {
+		return nil, err
+	} else if currentPrice <= 0 {
+		return nil, fmt.Errorf("no current price for %s", leg.ProductID)
+	}
+
+	price, quantity := currentPrice*(1-params.SlippagePercentage/100), amount
+	if leg.Side == BuySideType {
+		price = currentPrice * (1 + params.SlippagePercentage/100)
+	}
+	if leg.QuoteIncrement > 0 {
+		price = utils.FloorToIncrement(price, leg.QuoteIncrement)
+	}
+	if leg.Side == BuySideType {
+		quantity = amount / (price * (1 + leg.FeeRate))
+	}
+	if leg.BaseIncrement > 0 {
+		quantity = utils.FloorToIncrement(quantity, leg.BaseIncrement)
+	}
+	if quantity <= 0 || quantity < leg.BaseMinSize {
+		return nil, fmt.Errorf("%w: %f %s", errBelowMinSize, amount, leg.From)
+	}
+
+	exec := &RouteLegExecution{Leg: leg, Price: price, Quantity: quantity}
+
+	orderID, err := e.client.CreateOrderAndWaitForCompletion(ctx, &CreateLimitMarketOrderParams{
+		ID:          id,
+		BaseTicker:  leg.ProductID.Base(),
+		QuoteTicker: leg.ProductID.Quote(),
+		Price:       price,
+		Quantity:    quantity,
+		Side:        leg.Side,
+	}, clockOf(e.client).Now().Add(params.LegTimeout))
+	if orderID == "" {
+		return nil, err
+	}
+	exec.OrderID = orderID
+
+	// whatever didn't fill in time can't be left working while the route moves on
+	order, settleErr := settleOrder(ctx, e.client, orderID)
+	if order != nil {
+		exec.fill(order)
+	}
+	if err == nil {
+		err = settleErr
+	}
+
+	return exec, err
+}
+
+func (l *RouteLegExecution) fill(order *cbadvmodel.Order) {
+	l.Status = string(order.GetStatus())
+
+	size, value, fees := order.GetFilledSize(), order.GetFilledValue(), order.GetTotalFees()
+	if value == 0 {
+		value = size * order.GetAverageFilledPrice()
+	}
+
+	if l.Leg.Side == BuySideType {
+		l.AmountIn, l.AmountOut = value+fees, size
+	} else {
+		l.AmountIn, l.AmountOut = size, value-fees
+	}
+}
+
+func (r *RouteExecution) hold(exec *RouteLegExecution) {
+	r.Holdings[exec.Leg.From] -= exec.AmountIn
+	r.Holdings[exec.Leg.To] += exec.AmountOut
+
+	for currency, amount := range r.Holdings {
+		if math.Abs(amount) < 1e-12 {
+			delete(r.Holdings, currency)
+		}
+	}
+}
+
+func inverseLeg(leg RouteLeg) RouteLeg {
+	inverse := leg
+	inverse.From, inverse.To = leg.To, leg.From
+	if leg.Side == BuySideType {
+		inverse.Side = SellSideType
+		inverse.Rate = leg.Price * (1 - leg.FeeRate)
+	} else {
+		inverse.Side = BuySideType
+		inverse.Rate = 1 / (leg.Price * (1 + leg.FeeRate))
+	}
+
+	return inverse
+}
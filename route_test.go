@@ -0,0 +1,199 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func routeCatalog() *ProductCatalog {
+	return NewProductCatalog(
+		CatalogProduct{ID: "BTC-USD", Price: 30000, BaseIncrement: 0.0001, QuoteIncrement: 0.01},
+		CatalogProduct{ID: "YFI-BTC", Price: 0.2, BaseIncrement: 0.001, QuoteIncrement: 0.0001},
+		CatalogProduct{ID: "YFI-USD", Price: 7000, BaseIncrement: 0.001, QuoteIncrement: 0.01},
+		CatalogProduct{ID: "ETH-USD", Price: 2000, BaseIncrement: 0.001, QuoteIncrement: 0.01},
+		CatalogProduct{ID: "YFI-ETH", Price: 1, BaseIncrement: 0.001, QuoteIncrement: 0.001, Disabled: true},
+	)
+}
+
+var _ = Describe("RouteFinder", func() {
+	var finder *RouteFinder
+
+	BeforeEach(func() {
+		finder = NewRouteFinder(routeCatalog(), &FeeTier{TakerFeeRate: 0.005})
+	})
+
+	It("should pick the cheapest path even when it takes more legs", func() {
+		route, err := finder.FindRoute("USD", "YFI")
+		Expect(err).To(BeNil())
+		Expect(route.Legs).To(HaveLen(2))
+		Expect(route.Legs[0].ProductID).To(Equal(ProductID("BTC-USD")))
+		Expect(route.Legs[0].Side).To(Equal(BuySideType))
+		Expect(route.Legs[1].ProductID).To(Equal(ProductID("YFI-BTC")))
+		Expect(route.Legs[1].Side).To(Equal(BuySideType))
+		Expect(route.Rate).To(BeNumerically("~", 1/(30000*1.005)/(0.2*1.005), 1e-12))
+		Expect(route.Quote(6000)).To(BeNumerically("~", 6000*route.Rate, 1e-12))
+	})
+
+	It("should sell through the quote currency", func() {
+		route, err := finder.FindRoute("YFI", "ETH")
+		Expect(err).To(BeNil())
+		Expect(route.Legs).To(HaveLen(2))
+		Expect(route.Legs[0].Side).To(Equal(SellSideType))
+		Expect(route.Legs[0].ProductID).To(Equal(ProductID("YFI-USD")))
+		Expect(route.Legs[1].Side).To(Equal(BuySideType))
+		Expect(route.Legs[1].ProductID).To(Equal(ProductID("ETH-USD")))
+	})
+
+	It("should respect the max legs", func() {
+		finder.SetMaxLegs(1)
+
+		route, err := finder.FindRoute("USD", "YFI")
+		Expect(err).To(BeNil())
+		Expect(route.Legs).To(HaveLen(1))
+		Expect(route.Legs[0].ProductID).To(Equal(ProductID("YFI-USD")))
+	})
+
+	It("should fail when no route exists", func() {
+		_, err := finder.FindRoute("USD", "DOGE")
+		Expect(errors.Is(err, ErrNoRoute)).To(BeTrue())
+
+		_, err = finder.FindRoute("USD", "USD")
+		Expect(errors.Is(err, ErrNoRoute)).To(BeTrue())
+	})
+})
+
+var _ = Describe("RouteExecutor", func() {
+	var (
+		ctrl     *gomock.Controller
+		client   *mocks.MockApiClient
+		executor *RouteExecutor
+		params   *RouteExecutionParams
+		placed   []*CreateLimitMarketOrderParams
+		failing  map[string]*model.Order
+	)
+
+	filled := func(p *CreateLimitMarketOrderParams, status model.OrderStatus, size float64) *model.Order {
+		order := newOrder(p.ID, status, size, p.Price)
+		order.FilledValue = utils.Float64ToFloat64Ptr(size * p.Price)
+		order.TotalFees = utils.Float64ToFloat64Ptr(size * p.Price * 0.005)
+		return order
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(NewRealClock()).AnyTimes()
+		executor = NewRouteExecutor(client)
+		placed = nil
+		failing = map[string]*model.Order{}
+
+		route, err := NewRouteFinder(routeCatalog(), &FeeTier{TakerFeeRate: 0.005}).FindRoute("USD", "YFI")
+		Expect(err).To(BeNil())
+
+		params = &RouteExecutionParams{ID: "parent", Route: route, Amount: 3015, LegTimeout: time.Minute}
+
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 30000.0, 0.0, nil).AnyTimes()
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("YFI-BTC"), nil).Return(0.0, 0.0, 0.2, 0.0, nil).AnyTimes()
+		client.EXPECT().CreateOrderAndWaitForCompletion(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateLimitMarketOrderParams, _ time.Time) (string, error) {
+				placed = append(placed, p)
+				if _, ok := failing[p.ID]; ok {
+					return p.ID, errors.New("market order has timed out")
+				}
+				return p.ID, nil
+			},
+		).AnyTimes()
+		client.EXPECT().GetOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, orderID string) (*model.Order, error) {
+				if order, ok := failing[orderID]; ok {
+					return order, nil
+				}
+				for _, p := range placed {
+					if p.ID == orderID {
+						return filled(p, model.FILLED, p.Quantity), nil
+					}
+				}
+				return nil, errors.New("unknown order")
+			},
+		).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should perform every leg in order", func() {
+		res, err := executor.Execute(ctx, params)
+		Expect(err).To(BeNil())
+		Expect(res.Completed).To(BeTrue())
+
+		Expect(placed).To(HaveLen(2))
+		Expect(placed[0].ID).To(Equal("parent-leg-0"))
+		Expect(placed[0].Quantity).To(BeNumerically("~", 0.1, 1e-9))
+		Expect(placed[1].ID).To(Equal("parent-leg-1"))
+		Expect(placed[1].BaseTicker).To(Equal("YFI"))
+		Expect(placed[1].Quantity).To(BeNumerically("~", 0.497, 1e-9))
+
+		Expect(res.Legs).To(HaveLen(2))
+		Expect(res.Legs[0].AmountIn).To(BeNumerically("~", 3015, 1e-6))
+		Expect(res.Legs[0].AmountOut).To(BeNumerically("~", 0.1, 1e-9))
+		Expect(res.Holdings["YFI"]).To(BeNumerically("~", 0.497, 1e-9))
+		Expect(res.Holdings).NotTo(HaveKey("USD"))
+	})
+
+	It("should report partial progress when a leg fails", func() {
+		failing["parent-leg-1"] = newOrder("parent-leg-1", model.OPEN, 0, 0)
+		client.EXPECT().CancelOrders(gomock.Any(), "parent-leg-1").DoAndReturn(func(context.Context, ...string) error {
+			failing["parent-leg-1"] = filled(placed[1], model.CANCELLED, 0.2)
+			return nil
+		})
+
+		res, err := executor.Execute(ctx, params)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("leg 2 BUY YFI-BTC failed"))
+		Expect(res.Completed).To(BeFalse())
+		Expect(res.Unwind).To(BeEmpty())
+
+		Expect(res.Legs).To(HaveLen(2))
+		Expect(res.Legs[1].Status).To(Equal(string(model.CANCELLED)))
+		Expect(res.Holdings["YFI"]).To(BeNumerically("~", 0.2, 1e-9))
+		Expect(res.Holdings["BTC"]).To(BeNumerically("~", 0.1-0.04*1.005, 1e-9))
+	})
+
+	It("should unwind back into the starting currency when a leg fails", func() {
+		params.Unwind = true
+
+		failing["parent-leg-1"] = newOrder("parent-leg-1", model.OPEN, 0, 0)
+		client.EXPECT().CancelOrders(gomock.Any(), "parent-leg-1").DoAndReturn(func(context.Context, ...string) error {
+			failing["parent-leg-1"] = filled(placed[1], model.CANCELLED, 0.2)
+			return nil
+		})
+
+		res, err := executor.Execute(ctx, params)
+		Expect(err).NotTo(BeNil())
+		Expect(res.Completed).To(BeFalse())
+
+		Expect(res.Unwind).To(HaveLen(2))
+		Expect(res.Unwind[0].Leg.ProductID).To(Equal(ProductID("YFI-BTC")))
+		Expect(res.Unwind[0].Leg.Side).To(Equal(SellSideType))
+		Expect(res.Unwind[0].OrderID).To(Equal("parent-unwind-1"))
+		Expect(res.Unwind[0].Quantity).To(BeNumerically("~", 0.2, 1e-9))
+		Expect(res.Unwind[1].Leg.ProductID).To(Equal(ProductID("BTC-USD")))
+		Expect(res.Unwind[1].Leg.Side).To(Equal(SellSideType))
+		Expect(res.Unwind[1].OrderID).To(Equal("parent-unwind-0"))
+
+		Expect(res.Holdings).NotTo(HaveKey("YFI"))
+		Expect(res.Holdings["BTC"]).To(BeNumerically("<", 0.0001))
+		Expect(res.Holdings["USD"]).To(BeNumerically(">", 2900))
+	})
+})
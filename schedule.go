@@ -0,0 +1,159 @@
+package apiclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// Next returns the first run strictly after after
+	Next(after time.Time) time.Time
+}
+
+// ParseSchedule understands "@every <duration>", "@hourly", "@daily", "@weekly", "@monthly" and five field cron
+// specs "minute hour day-of-month month day-of-week" with *, lists, ranges and steps ex. "0 9 * * 1-5". Cron specs
+// are evaluated in UTC
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, err
+		} else if interval <= 0 {
+			return nil, fmt.Errorf("invalid schedule '%s'", spec)
+		}
+
+		return everySchedule(interval), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule '%s': expected 5 fields", spec)
+	}
+
+	// day-of-week 7 is also Sunday
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sched := &cronSchedule{}
+	sets := []*[64]bool{&sched.minutes, &sched.hours, &sched.days, &sched.months, &sched.weekdays}
+
+	for idx, field := range fields {
+		if err := parseCronField(field, bounds[idx][0], bounds[idx][1], sets[idx]); err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", spec, err)
+		}
+	}
+	sched.weekdays[0] = sched.weekdays[0] || sched.weekdays[7]
+	sched.anyDay, sched.anyWeekday = fields[2] == "*", fields[4] == "*"
+
+	return sched, nil
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(s)).Add(time.Duration(s))
+}
+
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays [64]bool
+	anyDay, anyWeekday                     bool
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	tm := after.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// every valid spec matches at least once within 5 years (Feb 29 on a given weekday)
+	for limit := tm.AddDate(5, 0, 0); tm.Before(limit); {
+		if !s.months[tm.Month()] {
+			tm = time.Date(tm.Year(), tm.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(tm) {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.hours[tm.Hour()] {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.minutes[tm.Minute()] {
+			tm = tm.Add(time.Minute)
+			continue
+		}
+
+		return tm
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron where a restricted day-of-month and day-of-week match when either does
+func (s *cronSchedule) dayMatches(tm time.Time) bool {
+	day, weekday := s.days[tm.Day()], s.weekdays[tm.Weekday()]
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+
+	return day || weekday
+}
+
+func parseCronField(field string, min, max int, set *[64]bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[idx+1:]); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step in '%s'", part)
+			}
+			part, stepped = part[:idx], true
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid value '%s'", part)
+			}
+			end = start
+			if stepped && len(bounds) == 1 {
+				// N/step runs from N to the end of the range like cron
+				end = max
+			} else if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("invalid value '%s'", part)
+				}
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return fmt.Errorf("'%s' is outside of %d-%d", part, min, max)
+		}
+
+		for val := start; val <= end; val += step {
+			set[val] = true
+		}
+	}
+
+	return nil
+}
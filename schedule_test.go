@@ -0,0 +1,38 @@
+package apiclient_test
+
+import (
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseSchedule", func() {
+	// a Friday
+	now := time.Date(2023, 2, 24, 10, 30, 15, 0, time.UTC)
+
+	DescribeTable("next run",
+		func(spec string, expected time.Time) {
+			schedule, err := ParseSchedule(spec)
+			Expect(err).To(BeNil())
+			Expect(schedule.Next(now)).To(Equal(expected))
+		},
+		Entry("every interval", "@every 1h", time.Date(2023, 2, 24, 11, 0, 0, 0, time.UTC)),
+		Entry("daily", "@daily", time.Date(2023, 2, 25, 0, 0, 0, 0, time.UTC)),
+		Entry("weekdays at 9", "0 9 * * 1-5", time.Date(2023, 2, 27, 9, 0, 0, 0, time.UTC)),
+		Entry("every 15 minutes", "*/15 * * * *", time.Date(2023, 2, 24, 10, 45, 0, 0, time.UTC)),
+		Entry("first of the month", "0 0 1 * *", time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("every 20 minutes from 5", "5/20 * * * *", time.Date(2023, 2, 24, 10, 45, 0, 0, time.UTC)),
+		Entry("sunday as 7", "0 0 * * 7", time.Date(2023, 2, 26, 0, 0, 0, 0, time.UTC)),
+		Entry("leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)),
+	)
+
+	It("should reject invalid specs", func() {
+		for _, spec := range []string{"* * *", "60 * * * *", "*/0 * * * *", "@every -1h", "a * * * *", "0 0 * * 8"} {
+			_, err := ParseSchedule(spec)
+			Expect(err).NotTo(BeNil(), spec)
+		}
+	})
+})
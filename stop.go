@@ -0,0 +1,124 @@
+package apiclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type CreateStopLimitOrderParams struct {
+	// ID identifies the callers intent the same way CreateLimitMarketOrderParams.ID does. A uuid is generated
+	// when it's empty
+	ID          string
+	BaseTicker  string  `validate:"required"`
+	QuoteTicker string  `validate:"required"`
+	StopPrice   float64 `validate:"required"`
+	LimitPrice  float64 `validate:"required"`
+	Quantity    float64 `validate:"required"`
+	// Side decides the stop direction. Sells trigger when the price falls to StopPrice and buys trigger when
+	// it rises to StopPrice
+	Side sideType `validate:"required"`
+}
+
+func (p *CreateStopLimitOrderParams) ClientOrderID() string {
+	intent := sha256.Sum256([]byte(fmt.Sprintf("%f-%f-%f", p.StopPrice, p.LimitPrice, p.Quantity)))
+
+	return fmt.Sprintf("create-stop-limit-order-%s-%s-%s-%s-", p.Side, p.BaseTicker, p.QuoteTicker, p.ID) +
+		hex.EncodeToString(intent[:4])
+}
+
+func (p *CreateStopLimitOrderParams) ProductID() ProductID {
+	return NewProductID(p.BaseTicker, p.QuoteTicker)
+}
+
+func (c *apiclient) CreateStopLimitOrder(ctx context.Context, params *CreateStopLimitOrderParams) (order *cbadvmodel.Order, err error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	ctx, span := c.startSpan(ctx, "CreateStopLimitOrder", productIDAttribute.String(params.ProductID().String()),
+		sideAttribute.String(string(params.Side)), clientOrderIDAttribute.String(params.ClientOrderID()))
+	defer func() {
+		setOrderAttributes(span, order)
+		endSpan(span, err)
+	}()
+
+	// the risk checks only care about the worst price the order can fill at
+	riskParams := &CreateLimitMarketOrderParams{
+		ID:          params.ID,
+		BaseTicker:  params.BaseTicker,
+		QuoteTicker: params.QuoteTicker,
+		Price:       params.LimitPrice,
+		Quantity:    params.Quantity,
+		Side:        params.Side,
+	}
+	release, err := c.reserveRisk(ctx, riskParams)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if isRejectedOrder(err) {
+			release()
+		}
+	}()
+
+	direction := cbadvmodel.STOP_DIRECTION_STOP_DOWN
+	if params.Side == BuySideType {
+		direction = cbadvmodel.STOP_DIRECTION_STOP_UP
+	}
+
+	coid := params.ClientOrderID()
+	productID := params.ProductID()
+	if err := c.checkTradableProductID(ctx, productID); err != nil {
+		return nil, rejectOrder(err)
+	}
+
+	start := c.clock.Now()
+	since := start.Add(-createdOrderLookback)
+	req, err := c.client.CreateOrder(ctx, &cbadvmodel.CreateOrderRequest{
+		ClientOrderId: utils.StringToPtr(coid),
+		ProductId:     utils.StringToPtr(productID.String()),
+		Side:          utils.StringToPtr(string(params.Side)),
+		OrderConfiguration: &cbadvmodel.CreateOrderRequestOrderConfiguration{
+			StopLimitStopLimitGtc: &cbadvmodel.CreateOrderRequestOrderConfigurationStopLimitStopLimitGtc{
+				BaseSize:      utils.StringToPtr(fmt.Sprintf("%f", params.Quantity)),
+				LimitPrice:    utils.StringToPtr(fmt.Sprintf("%f", params.LimitPrice)),
+				StopPrice:     utils.StringToPtr(fmt.Sprintf("%f", params.StopPrice)),
+				StopDirection: utils.StringToPtr(string(direction)),
+			},
+		},
+	})
+	c.observeRequest("CreateOrder", start, err)
+	if err != nil {
+		// We don't know if coinbase placed the order so look for it before giving up
+		existing, lookupErr := c.findOrderByClientOrderID(ctx, productID, coid, since)
+		if lookupErr == nil {
+			c.log("stop limit order '%s' was placed even though create failed with err: %s", coid, err.Error())
+			return c.verifyCreatedOrder(riskParams, existing)
+		}
+
+		return nil, err
+	} else if req == nil {
+		return nil, errors.New("create order request is nil")
+	} else if !req.GetSuccess() {
+		c.metrics.ObserveErrorCode("CreateOrder", req.ErrorResponse.GetError())
+		return nil, rejectOrder(errors.New(req.ErrorResponse.GetMessage()))
+	}
+
+	order, err = c.GetOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.verifyCreatedOrder(riskParams, order)
+}
@@ -0,0 +1,55 @@
+package apiclient_test
+
+import (
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CreateStopLimitOrder", func() {
+	var ctrl *gomock.Controller
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should trigger sells when the price falls", func() {
+		cbClient := mocks.NewMockCoinbaseClient(ctrl)
+		success, status := true, model.OPEN
+
+		params := &CreateStopLimitOrderParams{
+			ID: "stop", BaseTicker: "BTC", QuoteTicker: "USD", StopPrice: 95, LimitPrice: 94, Quantity: 1, Side: SellSideType,
+		}
+
+		cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, req *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+				Expect(req.GetClientOrderId()).To(Equal(params.ClientOrderID()))
+				config := req.OrderConfiguration.StopLimitStopLimitGtc
+				Expect(config.GetStopDirection()).To(Equal(string(model.STOP_DIRECTION_STOP_DOWN)))
+				Expect(config.GetStopPrice()).To(Equal("95.000000"))
+				Expect(config.GetLimitPrice()).To(Equal("94.000000"))
+				return &model.CreateOrderResponse{Success: &success, OrderId: utils.StringToPtr("order-1")}, nil
+			},
+		)
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(&model.GetOrderResponse{Order: &model.Order{
+			OrderId: utils.StringToPtr("order-1"), Status: &status,
+		}}, nil)
+
+		cont, err := NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false)
+		Expect(err).To(BeNil())
+
+		order, err := cont.CreateStopLimitOrder(ctx, params)
+		Expect(err).To(BeNil())
+		Expect(order.GetOrderId()).To(Equal("order-1"))
+	})
+})
@@ -0,0 +1,74 @@
+package taxlots
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrMissingBasis means a disposal has no lots behind it. Reporting it with a 0 cost basis would over report the gain
+var ErrMissingBasis = errors.New("disposal is missing its cost basis")
+
+// MissingBasis replaces the acquired date and cost basis of a disposal without one
+const MissingBasis = "MISSING BASIS"
+
+// Form8949Header matches the columns of IRS form 8949 which most tax software imports
+var Form8949Header = []string{
+	"Description of property",
+	"Date acquired",
+	"Date sold or disposed of",
+	"Proceeds",
+	"Cost or other basis",
+	"Gain or (loss)",
+	"Term",
+}
+
+// WriteDisposalsCSV fails with ErrMissingBasis when any disposal is missing its cost basis unless allowMissingBasis
+// is set. Those disposals are then written with MissingBasis and no gain so they stand out for manual review
+func WriteDisposalsCSV(w io.Writer, disposals []Disposal, allowMissingBasis bool) error {
+	if !allowMissingBasis {
+		for _, disposal := range disposals {
+			if disposal.MissingBasis {
+				return fmt.Errorf("%w: %f %s sold %s", ErrMissingBasis,
+					disposal.Quantity, disposal.Currency, disposal.Sold.Format("01/02/2006"))
+			}
+		}
+	}
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(Form8949Header); err != nil {
+		return err
+	}
+
+	for _, disposal := range disposals {
+		acquired := disposal.Acquired.Format("01/02/2006")
+		costBasis := strconv.FormatFloat(disposal.CostBasis, 'f', 2, 64)
+		gain := strconv.FormatFloat(disposal.Gain, 'f', 2, 64)
+		if disposal.MissingBasis {
+			acquired, costBasis, gain = MissingBasis, MissingBasis, ""
+		}
+
+		term := "Short"
+		if disposal.LongTerm {
+			term = "Long"
+		}
+
+		if err := writer.Write([]string{
+			strconv.FormatFloat(disposal.Quantity, 'f', -1, 64) + " " + disposal.Currency,
+			acquired,
+			disposal.Sold.Format("01/02/2006"),
+			strconv.FormatFloat(disposal.Proceeds, 'f', 2, 64),
+			costBasis,
+			gain,
+			term,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
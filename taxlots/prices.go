@@ -0,0 +1,64 @@
+package taxlots
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	coinbasegoclientv3 "github.com/happilymarrieddad/coinbase-go-client-v3"
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+)
+
+const USD = "USD"
+
+type PriceSource interface {
+	// USDPrice is the price of one unit of currency in USD at the given time
+	USDPrice(ctx context.Context, currency string, at time.Time) (float64, error)
+}
+
+// NewCandlePriceSource uses the close of the one minute <currency>-USD candle that contains the trade
+func NewCandlePriceSource(client coinbasegoclientv3.Client) PriceSource {
+	return &candlePriceSource{client: client, cache: make(map[string]float64), mutex: &sync.Mutex{}}
+}
+
+type candlePriceSource struct {
+	client coinbasegoclientv3.Client
+	cache  map[string]float64
+	mutex  *sync.Mutex
+}
+
+func (s *candlePriceSource) USDPrice(ctx context.Context, currency string, at time.Time) (float64, error) {
+	start := at.UTC().Truncate(time.Minute)
+	key := fmt.Sprintf("%s-%d", currency, start.Unix())
+
+	s.mutex.Lock()
+	price, exists := s.cache[key]
+	s.mutex.Unlock()
+	if exists {
+		return price, nil
+	}
+
+	candles, err := s.client.GetProductCandles(
+		ctx, apiclient.NewProductID(currency, USD).String(),
+		strconv.FormatInt(start.Unix(), 10),
+		strconv.FormatInt(start.Add(time.Minute).Unix(), 10),
+		coinbasegoclientv3.OneMinuteGranularity,
+	)
+	if err != nil {
+		return 0, err
+	} else if len(candles) == 0 {
+		return 0, fmt.Errorf("no %s-%s candle at %s", currency, USD, start.Format(time.RFC3339))
+	}
+
+	if price, err = strconv.ParseFloat(candles[0].Close, 64); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	s.cache[key] = price
+	s.mutex.Unlock()
+
+	return price, nil
+}
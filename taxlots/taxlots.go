@@ -0,0 +1,267 @@
+package taxlots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	apiclient "github.com/happilymarrieddad/coinbase-v3-apiclient"
+)
+
+type Method string
+
+const (
+	FIFOMethod Method = "FIFO"
+	LIFOMethod Method = "LIFO"
+	// HIFOMethod disposes of the lots with the highest cost per unit first
+	HIFOMethod Method = "HIFO"
+)
+
+// dust left over from float math shouldn't turn into tiny lots or disposals
+const epsilon = 1e-12
+
+type Lot struct {
+	Currency string
+	Quantity float64
+	// CostBasis is in USD and includes fees
+	CostBasis float64
+	Acquired  time.Time
+	// OrderID of the fill that opened the lot
+	OrderID string
+}
+
+func (l Lot) UnitCost() float64 {
+	if l.Quantity == 0 {
+		return 0
+	}
+
+	return l.CostBasis / l.Quantity
+}
+
+type Disposal struct {
+	Currency string
+	Quantity float64
+	Acquired time.Time
+	Sold     time.Time
+	// Proceeds and CostBasis are in USD
+	Proceeds  float64
+	CostBasis float64
+	Gain      float64
+	LongTerm  bool
+	// MissingBasis is set when there were not enough lots to cover the disposal. The history passed
+	// in probably doesn't go back far enough
+	MissingBasis bool
+}
+
+type Engine struct {
+	method    Method
+	prices    PriceSource
+	lots      map[string][]*Lot
+	disposals []Disposal
+	seen      map[string]bool
+}
+
+func NewEngine(method Method, prices PriceSource) (*Engine, error) {
+	switch method {
+	case FIFOMethod, LIFOMethod, HIFOMethod:
+	default:
+		return nil, fmt.Errorf("unknown lot matching method '%s'", method)
+	}
+
+	if prices == nil {
+		return nil, errors.New("price source is required")
+	}
+
+	return &Engine{method: method, prices: prices, lots: make(map[string][]*Lot), seen: make(map[string]bool)}, nil
+}
+
+// LoadHistory processes every fill for the product between start and end. An empty productID loads every product
+func (e *Engine) LoadHistory(ctx context.Context, client apiclient.ApiClient, productID apiclient.ProductID, start, end time.Time) error {
+	fills, err := client.GetFillsByTimeRange(ctx, productID, start, end)
+	if err != nil {
+		return err
+	}
+
+	return e.Process(ctx, fills...)
+}
+
+// Process applies fills in trade time order. Buying YFI-BTC acquires YFI and disposes of BTC so both
+// legs are valued in USD at the time of the trade
+func (e *Engine) Process(ctx context.Context, fills ...cbadvmodel.OrderFill) error {
+	sorted := make([]cbadvmodel.OrderFill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return apiclient.FillTime(&sorted[i]).Before(apiclient.FillTime(&sorted[j]))
+	})
+
+	for idx := range sorted {
+		fill := &sorted[idx]
+
+		key := fill.GetEntryId()
+		if key == "" {
+			key = fill.GetTradeId() + "-" + fill.GetOrderId()
+		}
+		if e.seen[key] {
+			continue
+		}
+
+		if err := e.process(ctx, fill); err != nil {
+			return err
+		}
+		e.seen[key] = true
+	}
+
+	return nil
+}
+
+func (e *Engine) Disposals() []Disposal {
+	disposals := make([]Disposal, len(e.disposals))
+	copy(disposals, e.disposals)
+
+	return disposals
+}
+
+func (e *Engine) OpenLots() []Lot {
+	lots := []Lot{}
+	for _, currencyLots := range e.lots {
+		for _, lot := range currencyLots {
+			lots = append(lots, *lot)
+		}
+	}
+	sort.SliceStable(lots, func(i, j int) bool {
+		if lots[i].Currency != lots[j].Currency {
+			return lots[i].Currency < lots[j].Currency
+		}
+		return lots[i].Acquired.Before(lots[j].Acquired)
+	})
+
+	return lots
+}
+
+func (e *Engine) process(ctx context.Context, fill *cbadvmodel.OrderFill) error {
+	productID, err := apiclient.ParseProductID(fill.GetProductId())
+	if err != nil {
+		return err
+	}
+	base, quote := productID.Base(), productID.Quote()
+
+	at := apiclient.FillTime(fill)
+	price, size, commission := fill.GetPrice(), fill.GetSize(), fill.GetCommission()
+	if fill.GetSizeInQuote() && price > 0 {
+		size = size / price
+	}
+
+	quoteUSD, err := e.usdPrice(ctx, quote, at)
+	if err != nil {
+		return err
+	}
+
+	notional := price * size
+
+	switch fill.GetSide() {
+	case string(apiclient.BuySideType):
+		e.acquire(base, size, (notional+commission)*quoteUSD, at, fill.GetOrderId())
+		if quote != USD {
+			e.dispose(quote, notional+commission, (notional+commission)*quoteUSD, at)
+		}
+	case string(apiclient.SellSideType):
+		e.dispose(base, size, (notional-commission)*quoteUSD, at)
+		if quote != USD {
+			e.acquire(quote, notional-commission, (notional-commission)*quoteUSD, at, fill.GetOrderId())
+		}
+	default:
+		return fmt.Errorf("fill '%s' has unknown side '%s'", fill.GetEntryId(), fill.GetSide())
+	}
+
+	return nil
+}
+
+func (e *Engine) usdPrice(ctx context.Context, currency string, at time.Time) (float64, error) {
+	if currency == USD {
+		return 1, nil
+	}
+
+	return e.prices.USDPrice(ctx, currency, at)
+}
+
+func (e *Engine) acquire(currency string, quantity, costBasis float64, at time.Time, orderID string) {
+	if quantity <= epsilon {
+		return
+	}
+
+	e.lots[currency] = append(e.lots[currency], &Lot{
+		Currency:  currency,
+		Quantity:  quantity,
+		CostBasis: costBasis,
+		Acquired:  at,
+		OrderID:   orderID,
+	})
+}
+
+func (e *Engine) dispose(currency string, quantity, proceeds float64, at time.Time) {
+	if quantity <= epsilon {
+		return
+	}
+
+	lots := e.lots[currency]
+	e.sortLots(lots)
+
+	remaining := quantity
+	for len(lots) > 0 && remaining > epsilon {
+		lot := lots[0]
+		used := math.Min(lot.Quantity, remaining)
+		basis := lot.UnitCost() * used
+
+		e.addDisposal(Disposal{
+			Currency:  currency,
+			Quantity:  used,
+			Acquired:  lot.Acquired,
+			Sold:      at,
+			Proceeds:  proceeds * used / quantity,
+			CostBasis: basis,
+		})
+
+		lot.Quantity -= used
+		lot.CostBasis -= basis
+		remaining -= used
+
+		if lot.Quantity <= epsilon {
+			lots = lots[1:]
+		}
+	}
+	e.lots[currency] = lots
+
+	if remaining > epsilon {
+		e.addDisposal(Disposal{
+			Currency:     currency,
+			Quantity:     remaining,
+			Sold:         at,
+			Proceeds:     proceeds * remaining / quantity,
+			MissingBasis: true,
+		})
+	}
+}
+
+func (e *Engine) addDisposal(disposal Disposal) {
+	disposal.Gain = disposal.Proceeds - disposal.CostBasis
+	disposal.LongTerm = !disposal.Acquired.IsZero() && disposal.Sold.After(disposal.Acquired.AddDate(1, 0, 0))
+
+	e.disposals = append(e.disposals, disposal)
+}
+
+// sortLots puts the next lot to dispose of first
+func (e *Engine) sortLots(lots []*Lot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		switch e.method {
+		case LIFOMethod:
+			return lots[i].Acquired.After(lots[j].Acquired)
+		case HIFOMethod:
+			return lots[i].UnitCost() > lots[j].UnitCost()
+		}
+		return lots[i].Acquired.Before(lots[j].Acquired)
+	})
+}
@@ -0,0 +1,20 @@
+package taxlots_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+var _ = BeforeSuite(func() {
+	ctx = context.Background()
+})
+
+func TestTaxLots(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TaxLots Suite")
+}
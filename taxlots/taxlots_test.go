@@ -0,0 +1,118 @@
+package taxlots_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/taxlots"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type staticPrices map[string]float64
+
+func (p staticPrices) USDPrice(_ context.Context, currency string, _ time.Time) (float64, error) {
+	return p[currency], nil
+}
+
+func newFill(entryID, productID, side string, price, size, commission float64, tradeTime string) model.OrderFill {
+	return model.OrderFill{
+		EntryId:    utils.StringToPtr(entryID),
+		ProductId:  utils.StringToPtr(productID),
+		Side:       utils.StringToPtr(side),
+		Price:      utils.Float64ToFloat64Ptr(price),
+		Size:       utils.Float64ToFloat64Ptr(size),
+		Commission: utils.Float64ToFloat64Ptr(commission),
+		TradeTime:  utils.StringToPtr(tradeTime),
+	}
+}
+
+var _ = Describe("Engine", func() {
+	fills := []model.OrderFill{
+		newFill("1", "BTC-USD", "BUY", 100, 1, 0, "2021-01-01T00:00:00Z"),
+		newFill("2", "BTC-USD", "BUY", 300, 1, 0, "2021-06-01T00:00:00Z"),
+		newFill("3", "BTC-USD", "SELL", 200, 1, 0, "2022-03-01T00:00:00Z"),
+	}
+
+	DescribeTable("matching lots",
+		func(method taxlots.Method, basis, gain float64, longTerm bool) {
+			engine, err := taxlots.NewEngine(method, staticPrices{})
+			Expect(err).To(BeNil())
+			Expect(engine.Process(ctx, fills...)).To(Succeed())
+
+			disposals := engine.Disposals()
+			Expect(disposals).To(HaveLen(1))
+			Expect(disposals[0].CostBasis).To(BeNumerically("~", basis))
+			Expect(disposals[0].Gain).To(BeNumerically("~", gain))
+			Expect(disposals[0].LongTerm).To(Equal(longTerm))
+			Expect(engine.OpenLots()).To(HaveLen(1))
+		},
+		Entry("FIFO", taxlots.FIFOMethod, 100.0, 100.0, true),
+		Entry("LIFO", taxlots.LIFOMethod, 300.0, -100.0, false),
+		Entry("HIFO", taxlots.HIFOMethod, 300.0, -100.0, false),
+	)
+
+	It("should value the quote leg of crypto to crypto trades in USD", func() {
+		engine, err := taxlots.NewEngine(taxlots.FIFOMethod, staticPrices{"BTC": 200})
+		Expect(err).To(BeNil())
+
+		Expect(engine.Process(ctx,
+			newFill("1", "BTC-USD", "BUY", 100, 1, 0, "2021-01-01T00:00:00Z"),
+			newFill("2", "YFI-BTC", "BUY", 0.25, 2, 0, "2021-02-01T00:00:00Z"),
+		)).To(Succeed())
+
+		disposals := engine.Disposals()
+		Expect(disposals).To(HaveLen(1))
+		Expect(disposals[0].Currency).To(Equal("BTC"))
+		Expect(disposals[0].Quantity).To(BeNumerically("~", 0.5))
+		Expect(disposals[0].Proceeds).To(BeNumerically("~", 100))
+		Expect(disposals[0].CostBasis).To(BeNumerically("~", 50))
+
+		lots := engine.OpenLots()
+		Expect(lots).To(HaveLen(2))
+		Expect(lots[1].Currency).To(Equal("YFI"))
+		Expect(lots[1].CostBasis).To(BeNumerically("~", 100))
+	})
+
+	It("should flag disposals without enough history", func() {
+		engine, err := taxlots.NewEngine(taxlots.FIFOMethod, staticPrices{})
+		Expect(err).To(BeNil())
+
+		Expect(engine.Process(ctx, newFill("1", "BTC-USD", "SELL", 200, 1, 1, "2022-03-01T00:00:00Z"))).To(Succeed())
+
+		disposals := engine.Disposals()
+		Expect(disposals).To(HaveLen(1))
+		Expect(disposals[0].MissingBasis).To(BeTrue())
+		Expect(disposals[0].Proceeds).To(BeNumerically("~", 199))
+
+		buf := &bytes.Buffer{}
+		err = taxlots.WriteDisposalsCSV(buf, disposals, false)
+		Expect(errors.Is(err, taxlots.ErrMissingBasis)).To(BeTrue())
+		Expect(buf.Len()).To(BeZero())
+
+		Expect(taxlots.WriteDisposalsCSV(buf, disposals, true)).To(Succeed())
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines[1]).To(Equal("1 BTC,MISSING BASIS,03/01/2022,199.00,MISSING BASIS,,Short"))
+	})
+
+	It("should export disposals in form 8949 format", func() {
+		engine, err := taxlots.NewEngine(taxlots.FIFOMethod, staticPrices{})
+		Expect(err).To(BeNil())
+		Expect(engine.Process(ctx, fills...)).To(Succeed())
+
+		buf := &bytes.Buffer{}
+		Expect(taxlots.WriteDisposalsCSV(buf, engine.Disposals(), false)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(Equal([]string{
+			"Description of property,Date acquired,Date sold or disposed of,Proceeds,Cost or other basis,Gain or (loss),Term",
+			"1 BTC,01/01/2021,03/01/2022,200.00,100.00,100.00,Long",
+		}))
+	})
+})
@@ -0,0 +1,61 @@
+package apiclient
+
+import (
+	"context"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/happilymarrieddad/coinbase-v3-apiclient"
+
+const (
+	productIDAttribute     = attribute.Key("coinbase.product_id")
+	sideAttribute          = attribute.Key("coinbase.side")
+	orderIDAttribute       = attribute.Key("coinbase.order_id")
+	clientOrderIDAttribute = attribute.Key("coinbase.client_order_id")
+	attemptAttribute       = attribute.Key("coinbase.attempt")
+	statusAttribute        = attribute.Key("coinbase.order_status")
+)
+
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(tracerName)
+}
+
+// startSpan names every span ApiClient.<method> so they group together no matter which exporter is used
+func (c *apiclient) startSpan(ctx context.Context, method string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "ApiClient."+method, trace.WithAttributes(attributes...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func setOrderAttributes(span trace.Span, order *cbadvmodel.Order) {
+	if order == nil {
+		return
+	}
+
+	// an order missing a field shouldn't wipe out what the span already knows
+	for _, kv := range []attribute.KeyValue{
+		orderIDAttribute.String(order.GetOrderId()),
+		clientOrderIDAttribute.String(order.GetClientOrderId()),
+		statusAttribute.String(string(order.GetStatus())),
+	} {
+		if kv.Value.AsString() != "" {
+			span.SetAttributes(kv)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	mock_client "github.com/happilymarrieddad/coinbase-go-client-v3/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		ctrl     *gomock.Controller
+		cbClient *mocks.MockCoinbaseClient
+		cont     ApiClient
+		recorder *tracetest.SpanRecorder
+		provider *sdktrace.TracerProvider
+	)
+
+	spanNamed := func(name string) sdktrace.ReadOnlySpan {
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		Fail("no span named " + name)
+		return nil
+	}
+
+	attributesOf := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attributes := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attributes[kv.Key] = kv.Value
+		}
+		return attributes
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cbClient = mocks.NewMockCoinbaseClient(ctrl)
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		var err error
+		cont, err = NewApiClient(cbClient, mock_client.NewMockClient(ctrl), false, WithTracerProvider(provider))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should nest every exchange interaction under the caller's trace", func() {
+		parentCtx, parent := provider.Tracer("test").Start(ctx, "test")
+
+		filled := openLimitOrder("order-1", 0.4, 2, 2)
+		status := model.FILLED
+		filled.Order.Status = &status
+
+		gomock.InOrder(
+			cbClient.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, req *model.CreateOrderRequest) (*model.CreateOrderResponse, error) {
+					Expect(trace.SpanContextFromContext(ctx).TraceID()).To(Equal(parent.SpanContext().TraceID()))
+					return &model.CreateOrderResponse{Success: utils.BoolToBoolPtr(true), OrderId: utils.StringToPtr("order-1")}, nil
+				},
+			),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(openLimitOrder("order-1", 0.4, 2, 0), nil),
+			cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(filled, nil),
+		)
+
+		_, err := cont.CreateOrderAndWaitForCompletion(parentCtx, &CreateLimitMarketOrderParams{
+			ID: "traced", BaseTicker: "YFI", QuoteTicker: "BTC", Price: 0.4, Quantity: 2, Side: BuySideType,
+		}, time.Now().Add(time.Minute))
+		Expect(err).To(BeNil())
+		parent.End()
+
+		root := spanNamed("ApiClient.CreateOrderAndWaitForCompletion")
+		create := spanNamed("ApiClient.CreateLimitMarketOrder")
+		get := spanNamed("ApiClient.GetOrder")
+		verify := spanNamed("ApiClient.VerifyMarketOrderCompletion")
+
+		Expect(root.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(create.Parent().SpanID()).To(Equal(root.SpanContext().SpanID()))
+		Expect(get.Parent().SpanID()).To(Equal(create.SpanContext().SpanID()))
+		Expect(verify.Parent().SpanID()).To(Equal(root.SpanContext().SpanID()))
+
+		attributes := attributesOf(create)
+		Expect(attributes["coinbase.product_id"].AsString()).To(Equal("YFI-BTC"))
+		Expect(attributes["coinbase.side"].AsString()).To(Equal("BUY"))
+		Expect(attributes["coinbase.attempt"].AsInt64()).To(Equal(int64(1)))
+		Expect(attributes["coinbase.client_order_id"].AsString()).To(HavePrefix("create-market-order-BUY-YFI-BTC-traced-"))
+		Expect(create.Events()).To(HaveLen(1))
+
+		Expect(attributesOf(verify)["coinbase.order_status"].AsString()).To(Equal("FILLED"))
+		Expect(attributesOf(root)["coinbase.order_status"].AsString()).To(Equal("FILLED"))
+	})
+
+	It("should record the error on the span", func() {
+		cbClient.EXPECT().GetOrder(gomock.Any(), "order-1").Return(nil, errors.New("boom"))
+
+		_, err := cont.GetOrder(ctx, "order-1")
+		Expect(err).NotTo(BeNil())
+
+		span := spanNamed("ApiClient.GetOrder")
+		Expect(span.Status().Code).To(Equal(codes.Error))
+		Expect(attributesOf(span)["coinbase.order_id"].AsString()).To(Equal("order-1"))
+	})
+})
@@ -0,0 +1,222 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type TrailingStopEventType string
+
+const (
+	// ActivatedTrailingStopEvent fires once the price reaches the activation price and the stop starts trailing
+	ActivatedTrailingStopEvent TrailingStopEventType = "ACTIVATED"
+	// MovedTrailingStopEvent fires every time a new high (or low) drags the stop price along
+	MovedTrailingStopEvent       TrailingStopEventType = "MOVED"
+	TriggeredTrailingStopEvent   TrailingStopEventType = "TRIGGERED"
+	OrderPlacedTrailingStopEvent TrailingStopEventType = "ORDER_PLACED"
+	OrderFailedTrailingStopEvent TrailingStopEventType = "ORDER_FAILED"
+)
+
+type TrailingStopEvent struct {
+	Type      TrailingStopEventType
+	Price     float64
+	Extreme   float64
+	StopPrice float64
+	OrderID   string
+	Err       error
+	Time      time.Time
+}
+
+type TrailingStopParams struct {
+	// ID is used for the order placed on trigger. A uuid is generated when it's empty
+	ID          string
+	BaseTicker  string  `validate:"required"`
+	QuoteTicker string  `validate:"required"`
+	Quantity    float64 `validate:"required"`
+	// Side is the side of the order placed on trigger. Sells trail the highest price and buys trail the lowest
+	Side sideType `validate:"required"`
+	// Only one of OffsetPercentage and OffsetAmount can be set. 1% will be 1.0
+	OffsetPercentage float64
+	OffsetAmount     float64
+	// ActivationPrice holds off trailing until a sell sees a price at or above it or a buy sees one at or below
+	// it. 0 activates right away
+	ActivationPrice float64
+	// SlippagePercentage is how far past the current price the triggered limit order is placed so it fills
+	SlippagePercentage float64
+	// PollInterval defaults to 5 seconds
+	PollInterval time.Duration
+	// OnEvent is called from Run's goroutine
+	OnEvent func(event TrailingStopEvent)
+}
+
+type TrailingStopStatus struct {
+	Active    bool
+	Triggered bool
+	Extreme   float64
+	StopPrice float64
+	OrderID   string
+}
+
+type TrailingStop struct {
+	client ApiClient
+	params TrailingStopParams
+	clock  Clock
+	mutex  *sync.RWMutex
+	status TrailingStopStatus
+}
+
+func NewTrailingStop(client ApiClient, params TrailingStopParams) (*TrailingStop, error) {
+	if err := utils.Validate(&params); err != nil {
+		return nil, err
+	}
+
+	if (params.OffsetPercentage > 0) == (params.OffsetAmount > 0) {
+		return nil, errors.New("exactly one of offset percentage or offset amount is required")
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+	if params.PollInterval <= 0 {
+		params.PollInterval = time.Second * 5
+	}
+
+	return &TrailingStop{client: client, params: params, clock: clockOf(client), mutex: &sync.RWMutex{}}, nil
+}
+
+func (t *TrailingStop) Status() TrailingStopStatus {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.status
+}
+
+// Run polls GetProductMarketData until the stop triggers and returns the order it placed
+func (t *TrailingStop) Run(ctx context.Context) (*cbadvmodel.Order, error) {
+	ticker := t.clock.NewTicker(t.params.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, _, price, _, err := t.client.GetProductMarketData(ctx, NewProductID(t.params.BaseTicker, t.params.QuoteTicker), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.observe(price) {
+			return t.trigger(ctx, price)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
+
+// observe returns true when price breaches the stop
+func (t *TrailingStop) observe(price float64) bool {
+	t.mutex.Lock()
+	sell := t.params.Side == SellSideType
+
+	if !t.status.Active {
+		if t.params.ActivationPrice > 0 && ((sell && price < t.params.ActivationPrice) || (!sell && price > t.params.ActivationPrice)) {
+			t.mutex.Unlock()
+			return false
+		}
+
+		t.status.Active = true
+		t.status.Extreme = price
+		t.status.StopPrice = t.stopPrice(price)
+		status := t.status
+		t.mutex.Unlock()
+
+		t.emit(TrailingStopEvent{Type: ActivatedTrailingStopEvent, Price: price, Extreme: status.Extreme, StopPrice: status.StopPrice})
+		return false
+	}
+
+	if (sell && price > t.status.Extreme) || (!sell && price < t.status.Extreme) {
+		t.status.Extreme = price
+		t.status.StopPrice = t.stopPrice(price)
+		status := t.status
+		t.mutex.Unlock()
+
+		t.emit(TrailingStopEvent{Type: MovedTrailingStopEvent, Price: price, Extreme: status.Extreme, StopPrice: status.StopPrice})
+		return false
+	}
+
+	breached := (sell && price <= t.status.StopPrice) || (!sell && price >= t.status.StopPrice)
+	if breached {
+		t.status.Triggered = true
+	}
+	status := t.status
+	t.mutex.Unlock()
+
+	if breached {
+		t.emit(TrailingStopEvent{Type: TriggeredTrailingStopEvent, Price: price, Extreme: status.Extreme, StopPrice: status.StopPrice})
+	}
+
+	return breached
+}
+
+func (t *TrailingStop) trigger(ctx context.Context, price float64) (*cbadvmodel.Order, error) {
+	limitPrice := price * (1 - t.params.SlippagePercentage/100)
+	if t.params.Side == BuySideType {
+		limitPrice = price * (1 + t.params.SlippagePercentage/100)
+	}
+
+	order, err := t.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          t.params.ID,
+		BaseTicker:  t.params.BaseTicker,
+		QuoteTicker: t.params.QuoteTicker,
+		Price:       limitPrice,
+		Quantity:    t.params.Quantity,
+		Side:        t.params.Side,
+	})
+
+	status := t.Status()
+	if err != nil {
+		t.emit(TrailingStopEvent{Type: OrderFailedTrailingStopEvent, Price: price, Extreme: status.Extreme, StopPrice: status.StopPrice, Err: err})
+		return nil, fmt.Errorf("trailing stop triggered at %f but the order failed: %w", price, err)
+	}
+
+	t.mutex.Lock()
+	t.status.OrderID = order.GetOrderId()
+	t.mutex.Unlock()
+
+	t.emit(TrailingStopEvent{
+		Type: OrderPlacedTrailingStopEvent, Price: price, Extreme: status.Extreme, StopPrice: status.StopPrice, OrderID: order.GetOrderId(),
+	})
+
+	return order, nil
+}
+
+func (t *TrailingStop) stopPrice(extreme float64) float64 {
+	offset := t.params.OffsetAmount
+	if t.params.OffsetPercentage > 0 {
+		offset = extreme * t.params.OffsetPercentage / 100
+	}
+
+	if t.params.Side == SellSideType {
+		return math.Max(extreme-offset, 0)
+	}
+
+	return extreme + offset
+}
+
+func (t *TrailingStop) emit(event TrailingStopEvent) {
+	if t.params.OnEvent == nil {
+		return
+	}
+
+	event.Time = t.clock.Now()
+	t.params.OnEvent(event)
+}
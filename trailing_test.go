@@ -0,0 +1,115 @@
+package apiclient_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrailingStop", func() {
+	var (
+		ctrl   *gomock.Controller
+		client *mocks.MockApiClient
+		events []TrailingStopEvent
+	)
+
+	expectPrices := func(prices ...float64) {
+		calls := []*gomock.Call{}
+		for _, price := range prices {
+			calls = append(calls, client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, price, 0.0, nil))
+		}
+		gomock.InOrder(calls...)
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(NewRealClock()).AnyTimes()
+		events = nil
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should require exactly one offset", func() {
+		_, err := NewTrailingStop(client, TrailingStopParams{
+			BaseTicker: "BTC", QuoteTicker: "USD", Quantity: 1, Side: SellSideType, OffsetPercentage: 1, OffsetAmount: 1,
+		})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should follow the high and sell when it drops by the percentage", func() {
+		expectPrices(100, 110, 105, 98)
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), &CreateLimitMarketOrderParams{
+			ID: "trail", BaseTicker: "BTC", QuoteTicker: "USD", Price: 98 * 0.99, Quantity: 1, Side: SellSideType,
+		}).Return(&model.Order{OrderId: utils.StringToPtr("order-1")}, nil)
+
+		stop, err := NewTrailingStop(client, TrailingStopParams{
+			ID: "trail", BaseTicker: "BTC", QuoteTicker: "USD", Quantity: 1, Side: SellSideType,
+			OffsetPercentage: 10, SlippagePercentage: 1, PollInterval: time.Millisecond,
+			OnEvent: func(event TrailingStopEvent) { events = append(events, event) },
+		})
+		Expect(err).To(BeNil())
+
+		order, err := stop.Run(ctx)
+		Expect(err).To(BeNil())
+		Expect(order.GetOrderId()).To(Equal("order-1"))
+
+		types := []TrailingStopEventType{}
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		Expect(types).To(Equal([]TrailingStopEventType{
+			ActivatedTrailingStopEvent, MovedTrailingStopEvent, TriggeredTrailingStopEvent, OrderPlacedTrailingStopEvent,
+		}))
+		Expect(events[2].Extreme).To(Equal(110.0))
+		Expect(events[2].StopPrice).To(BeNumerically("~", 99))
+
+		status := stop.Status()
+		Expect(status.Triggered).To(BeTrue())
+		Expect(status.OrderID).To(Equal("order-1"))
+	})
+
+	It("should wait for the activation price and trail the low for buys", func() {
+		expectPrices(100, 90, 85, 87, 90)
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), gomock.Any()).Return(&model.Order{OrderId: utils.StringToPtr("order-1")}, nil)
+
+		stop, err := NewTrailingStop(client, TrailingStopParams{
+			BaseTicker: "BTC", QuoteTicker: "USD", Quantity: 1, Side: BuySideType,
+			OffsetAmount: 5, ActivationPrice: 90, PollInterval: time.Millisecond,
+		})
+		Expect(err).To(BeNil())
+
+		_, err = stop.Run(ctx)
+		Expect(err).To(BeNil())
+		Expect(stop.Status().Extreme).To(Equal(85.0))
+		Expect(stop.Status().StopPrice).To(Equal(90.0))
+	})
+
+	It("should stop when the context is cancelled", func() {
+		cctx, cancel := context.WithCancel(ctx)
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).DoAndReturn(
+			func(context.Context, ProductID, *float64) (float64, float64, float64, float64, error) {
+				cancel()
+				return 0.0, 0.0, 100.0, 0.0, nil
+			},
+		)
+
+		stop, err := NewTrailingStop(client, TrailingStopParams{
+			BaseTicker: "BTC", QuoteTicker: "USD", Quantity: 1, Side: SellSideType, OffsetAmount: 5, PollInterval: time.Hour,
+		})
+		Expect(err).To(BeNil())
+
+		_, err = stop.Run(cctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,230 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/google/uuid"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+type TWAPParams struct {
+	// ID is the parent intent. Every child order uses ID-<slice> as its own intent. A uuid is generated when
+	// it's empty
+	ID          string
+	BaseTicker  string        `validate:"required"`
+	QuoteTicker string        `validate:"required"`
+	Quantity    float64       `validate:"required"`
+	Side        sideType      `validate:"required"`
+	Duration    time.Duration `validate:"required"`
+	Slices      int           `validate:"required"`
+	// PriceOffsetPercentage rests each child this far behind the current price so it adds liquidity instead
+	// of crossing the spread. 0.1% will be 0.1
+	PriceOffsetPercentage float64
+	// LimitPrice is never crossed. Buys won't be placed above it and sells won't be placed below it. 0 is no limit
+	LimitPrice float64
+	// BaseIncrement rounds every child quantity down. 0 leaves it alone
+	BaseIncrement float64
+}
+
+type TWAPChild struct {
+	Slice              int
+	OrderID            string
+	Price              float64
+	Quantity           float64
+	FilledQuantity     float64
+	AverageFilledPrice float64
+	Status             string
+}
+
+type TWAPResult struct {
+	ID                string
+	Children          []TWAPChild
+	FilledQuantity    float64
+	RemainingQuantity float64
+	// AveragePrice is the size weighted fill price of every child
+	AveragePrice float64
+	// 100% will be 100.0
+	CompletionPercentage float64
+}
+
+type TWAPExecutor struct {
+	client ApiClient
+}
+
+func NewTWAPExecutor(client ApiClient) *TWAPExecutor {
+	return &TWAPExecutor{client: client}
+}
+
+// Execute splits the quantity into equal slices over the duration. Each slice gets until the start of the next
+// one to fill and whatever is left is cancelled and carried into the next slice. The result is always returned
+// so a partial execution can be inspected when an error stops it early
+func (e *TWAPExecutor) Execute(ctx context.Context, params *TWAPParams) (*TWAPResult, error) {
+	if err := utils.Validate(params); err != nil {
+		return nil, err
+	}
+
+	if params.ID == "" {
+		params.ID = uuid.New().String()
+	}
+
+	clock := clockOf(e.client)
+	res := &TWAPResult{ID: params.ID, Children: []TWAPChild{}, RemainingQuantity: params.Quantity}
+	interval := params.Duration / time.Duration(params.Slices)
+	start := clock.Now()
+
+	for slice := 0; slice < params.Slices; slice++ {
+		sliceEnd := start.Add(interval * time.Duration(slice+1))
+
+		// carrying the remainder forward means later slices pick up whatever earlier ones missed
+		quantity := res.RemainingQuantity / float64(params.Slices-slice)
+		if slice == params.Slices-1 {
+			quantity = res.RemainingQuantity
+		}
+		if params.BaseIncrement > 0 {
+			quantity = utils.FloorToIncrement(quantity, params.BaseIncrement)
+		}
+
+		if quantity > 0 {
+			child, err := e.executeSlice(ctx, params, slice, quantity, sliceEnd)
+			if child != nil {
+				res.add(child)
+			}
+			if err != nil {
+				return res.finish(params.Quantity), err
+			}
+		}
+
+		if res.RemainingQuantity <= 0 {
+			break
+		}
+
+		if err := sleepUntil(ctx, clock, sliceEnd); err != nil {
+			return res.finish(params.Quantity), err
+		}
+	}
+
+	return res.finish(params.Quantity), nil
+}
+
+func (e *TWAPExecutor) executeSlice(
+	ctx context.Context, params *TWAPParams, slice int, quantity float64, sliceEnd time.Time,
+) (*TWAPChild, error) {
+	_, _, currentPrice, _, err := e.client.GetProductMarketData(ctx, NewProductID(params.BaseTicker, params.QuoteTicker), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	price := currentPrice
+	if params.Side == BuySideType {
+		price = currentPrice * (1 - params.PriceOffsetPercentage/100)
+		if params.LimitPrice > 0 && price > params.LimitPrice {
+			price = params.LimitPrice
+		}
+	} else {
+		price = currentPrice * (1 + params.PriceOffsetPercentage/100)
+		if params.LimitPrice > 0 && price < params.LimitPrice {
+			price = params.LimitPrice
+		}
+	}
+
+	order, err := e.client.CreateLimitMarketOrder(ctx, &CreateLimitMarketOrderParams{
+		ID:          fmt.Sprintf("%s-%d", params.ID, slice),
+		BaseTicker:  params.BaseTicker,
+		QuoteTicker: params.QuoteTicker,
+		Price:       price,
+		Quantity:    quantity,
+		Side:        params.Side,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	child := &TWAPChild{Slice: slice, OrderID: order.GetOrderId(), Price: price, Quantity: quantity}
+
+	// a timeout just means the slice is over so whatever filled is settled below
+	_ = e.client.VerifyMarketOrderCompletion(ctx, child.OrderID, sliceEnd)
+
+	order, err = settleOrder(ctx, e.client, child.OrderID)
+	if order != nil {
+		child.FilledQuantity = order.GetFilledSize()
+		child.AverageFilledPrice = order.GetAverageFilledPrice()
+		child.Status = string(order.GetStatus())
+	}
+
+	return child, err
+}
+
+func (r *TWAPResult) add(child *TWAPChild) {
+	if filled := r.FilledQuantity + child.FilledQuantity; filled > 0 {
+		r.AveragePrice = (r.AveragePrice*r.FilledQuantity + child.AverageFilledPrice*child.FilledQuantity) / filled
+	}
+
+	r.FilledQuantity += child.FilledQuantity
+	r.RemainingQuantity -= child.FilledQuantity
+	r.Children = append(r.Children, *child)
+}
+
+func (r *TWAPResult) finish(quantity float64) *TWAPResult {
+	if r.RemainingQuantity < 0 {
+		r.RemainingQuantity = 0
+	}
+	r.CompletionPercentage = r.FilledQuantity / quantity * 100
+
+	return r
+}
+
+// settleOrder cancels the order when it's still open and returns its final state
+func settleOrder(ctx context.Context, client ApiClient, orderID string) (*cbadvmodel.Order, error) {
+	// a cancelled caller still needs its resting order looked at and pulled
+	ctx = withoutCancel(ctx)
+
+	order, err := client.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.GetStatus() != cbadvmodel.OPEN {
+		return order, nil
+	}
+
+	cancelErr := client.CancelOrders(ctx, orderID)
+
+	order, err = client.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	} else if order.GetStatus() == cbadvmodel.OPEN {
+		if cancelErr == nil {
+			cancelErr = errors.New("order is still open")
+		}
+		return order, fmt.Errorf("unable to cancel order '%s': %w", orderID, cancelErr)
+	}
+
+	return order, nil
+}
+
+// detachedContext keeps its parent's values but is never cancelled and has no deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+func withoutCancel(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+func sleepUntil(ctx context.Context, clock Clock, tm time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(tm.Sub(clock.Now())):
+		return nil
+	}
+}
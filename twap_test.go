@@ -0,0 +1,129 @@
+package apiclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/happilymarrieddad/coinbase-v3-apiclient"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/mocks"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+
+	"github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func newOrder(orderID string, status model.OrderStatus, filledSize, averageFilledPrice float64) *model.Order {
+	return &model.Order{
+		OrderId:            utils.StringToPtr(orderID),
+		Status:             &status,
+		FilledSize:         utils.Float64ToFloat64Ptr(filledSize),
+		AverageFilledPrice: utils.Float64ToFloat64Ptr(averageFilledPrice),
+	}
+}
+
+var _ = Describe("TWAPExecutor", func() {
+	var (
+		ctrl     *gomock.Controller
+		client   *mocks.MockApiClient
+		executor *TWAPExecutor
+		params   *TWAPParams
+		placed   []*CreateLimitMarketOrderParams
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		client = mocks.NewMockApiClient(ctrl)
+		client.EXPECT().Clock().Return(NewRealClock()).AnyTimes()
+		executor = NewTWAPExecutor(client)
+		placed = nil
+
+		params = &TWAPParams{
+			ID:                    "parent",
+			BaseTicker:            "BTC",
+			QuoteTicker:           "USD",
+			Quantity:              3,
+			Side:                  BuySideType,
+			Duration:              30 * time.Millisecond,
+			Slices:                3,
+			PriceOffsetPercentage: 1,
+		}
+
+		client.EXPECT().GetProductMarketData(gomock.Any(), ProductID("BTC-USD"), nil).Return(0.0, 0.0, 100.0, 0.0, nil).AnyTimes()
+		client.EXPECT().CreateLimitMarketOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p *CreateLimitMarketOrderParams) (*model.Order, error) {
+				placed = append(placed, p)
+				return &model.Order{OrderId: utils.StringToPtr(p.ID)}, nil
+			},
+		).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should carry unfilled quantity into the next slice", func() {
+		client.EXPECT().VerifyMarketOrderCompletion(gomock.Any(), "parent-0", gomock.Any()).Return(nil)
+		client.EXPECT().GetOrder(gomock.Any(), "parent-0").Return(newOrder("parent-0", model.FILLED, 1, 99), nil)
+
+		client.EXPECT().VerifyMarketOrderCompletion(gomock.Any(), "parent-1", gomock.Any()).Return(errors.New("timed out"))
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "parent-1").Return(newOrder("parent-1", model.OPEN, 0.5, 99), nil),
+			client.EXPECT().CancelOrders(gomock.Any(), "parent-1").Return(nil),
+			client.EXPECT().GetOrder(gomock.Any(), "parent-1").Return(newOrder("parent-1", model.CANCELLED, 0.5, 99), nil),
+		)
+
+		client.EXPECT().VerifyMarketOrderCompletion(gomock.Any(), "parent-2", gomock.Any()).Return(nil)
+		client.EXPECT().GetOrder(gomock.Any(), "parent-2").Return(newOrder("parent-2", model.FILLED, 1.5, 96), nil)
+
+		res, err := executor.Execute(ctx, params)
+		Expect(err).To(BeNil())
+
+		Expect(placed).To(HaveLen(3))
+		Expect(placed[0].Price).To(BeNumerically("~", 99))
+		Expect(placed[0].Quantity).To(BeNumerically("~", 1))
+		Expect(placed[1].Quantity).To(BeNumerically("~", 1))
+		Expect(placed[2].Quantity).To(BeNumerically("~", 1.5))
+
+		Expect(res.Children).To(HaveLen(3))
+		Expect(res.FilledQuantity).To(BeNumerically("~", 3))
+		Expect(res.RemainingQuantity).To(BeNumerically("~", 0))
+		Expect(res.AveragePrice).To(BeNumerically("~", 97.5))
+		Expect(res.CompletionPercentage).To(BeNumerically("~", 100))
+	})
+
+	It("should report partial completion when the context is cancelled", func() {
+		cctx, cancel := context.WithCancel(ctx)
+
+		client.EXPECT().VerifyMarketOrderCompletion(gomock.Any(), "parent-0", gomock.Any()).DoAndReturn(
+			func(context.Context, string, time.Time) error {
+				cancel()
+				return context.Canceled
+			},
+		)
+		// the real client fails every request made with a cancelled context
+		getOrder := func(order *model.Order) func(context.Context, string) (*model.Order, error) {
+			return func(ctx context.Context, _ string) (*model.Order, error) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return order, nil
+			}
+		}
+		gomock.InOrder(
+			client.EXPECT().GetOrder(gomock.Any(), "parent-0").DoAndReturn(getOrder(newOrder("parent-0", model.OPEN, 0.25, 99))),
+			client.EXPECT().CancelOrders(gomock.Any(), "parent-0").DoAndReturn(func(ctx context.Context, _ ...string) error {
+				return ctx.Err()
+			}),
+			client.EXPECT().GetOrder(gomock.Any(), "parent-0").DoAndReturn(getOrder(newOrder("parent-0", model.CANCELLED, 0.25, 99))),
+		)
+
+		res, err := executor.Execute(cctx, params)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(res.FilledQuantity).To(BeNumerically("~", 0.25))
+		Expect(res.RemainingQuantity).To(BeNumerically("~", 2.75))
+		Expect(res.CompletionPercentage).To(BeNumerically("~", 25.0/3))
+	})
+})
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -35,3 +36,17 @@ func TrimFloatToRight(v float64, numToTrim int) float64 {
 func ConvertPercentageToDecimal(v float64) float64 {
 	return v / 100
 }
+
+// FloorToIncrement rounds v down to the nearest multiple of inc ex. a product's base increment
+func FloorToIncrement(v, inc float64) float64 {
+	if inc <= 0 {
+		return v
+	}
+
+	// the small nudge stops values like 0.3/0.1 = 2.9999999999999996 from losing an increment
+	steps := math.Floor(v/inc + 1e-9)
+	decimals := int(math.Max(0, math.Ceil(-math.Log10(inc))))
+
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(steps*inc, 'f', decimals, 64), 64)
+	return rounded
+}
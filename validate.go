@@ -0,0 +1,97 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	cbadvmodel "github.com/QuantFu-Inc/coinbase-adv/model"
+	"github.com/happilymarrieddad/coinbase-v3-apiclient/utils"
+)
+
+var ErrInvalidOrder = errors.New("order failed validation")
+
+// ValidateOrder runs every check an order goes through before it's sent to coinbase without sending it. That is
+// the params, the product status and increments, the available balance after fees and the risk policy
+func (c *apiclient) ValidateOrder(ctx context.Context, params *CreateLimitMarketOrderParams) (err error) {
+	if err := utils.Validate(params); err != nil {
+		return err
+	}
+
+	ctx, span := c.startSpan(ctx, "ValidateOrder",
+		productIDAttribute.String(params.ProductID().String()), sideAttribute.String(string(params.Side)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if err := c.checkRisk(ctx, params); err != nil {
+		return err
+	}
+
+	product, err := c.GetProduct(ctx, params.ProductID())
+	if err != nil {
+		return err
+	}
+	if err := validateAgainstProduct(params, product); err != nil {
+		return err
+	}
+
+	_, _, baseAmount, quoteAmount, err := c.GetCurrentWallentAmount(ctx, params.ProductID())
+	if err != nil {
+		return err
+	}
+
+	if params.Side == BuySideType {
+		feeTier, err := c.GetFeeTier(ctx)
+		if err != nil {
+			return err
+		}
+
+		// priced as a taker since a limit at or through the book fills right away
+		if cost := -feeTier.EstimateFee(params.Side, params.Price, params.Quantity, false).NetProceeds; quoteAmount < cost {
+			return fmt.Errorf("%w: insufficient %s. need %f with fees but only %f is available",
+				ErrInvalidOrder, params.QuoteTicker, cost, quoteAmount)
+		}
+	} else if params.Side == SellSideType && baseAmount < params.Quantity {
+		return fmt.Errorf("%w: insufficient %s. need %f but only %f is available",
+			ErrInvalidOrder, params.BaseTicker, params.Quantity, baseAmount)
+	}
+
+	return nil
+}
+
+func validateAgainstProduct(params *CreateLimitMarketOrderParams, product *cbadvmodel.GetProductResponse) error {
+	if product.GetTradingDisabled() || product.GetIsDisabled() {
+		return fmt.Errorf("%w: trading is disabled for '%s'", ErrInvalidOrder, product.GetProductId())
+	} else if product.GetCancelOnly() {
+		return fmt.Errorf("%w: '%s' is cancel only", ErrInvalidOrder, product.GetProductId())
+	} else if params.Side != BuySideType && params.Side != SellSideType {
+		return fmt.Errorf("%w: unknown side '%s'", ErrInvalidOrder, params.Side)
+	}
+
+	baseIncrement, quoteIncrement := product.GetBaseIncrement(), product.GetQuoteIncrement()
+	if !onIncrement(params.Price, quoteIncrement) {
+		return fmt.Errorf("%w: price %f is not a multiple of the quote increment %f", ErrInvalidOrder, params.Price, quoteIncrement)
+	} else if !onIncrement(params.Quantity, baseIncrement) {
+		return fmt.Errorf("%w: quantity %f is not a multiple of the base increment %f", ErrInvalidOrder, params.Quantity, baseIncrement)
+	}
+
+	if params.Quantity < product.GetBaseMinSize() {
+		return fmt.Errorf("%w: quantity %f is below the minimum %f", ErrInvalidOrder, params.Quantity, product.GetBaseMinSize())
+	} else if product.GetBaseMaxSize() > 0 && params.Quantity > product.GetBaseMaxSize() {
+		return fmt.Errorf("%w: quantity %f is over the maximum %f", ErrInvalidOrder, params.Quantity, product.GetBaseMaxSize())
+	} else if notional := params.Price * params.Quantity; notional < product.GetQuoteMinSize() {
+		return fmt.Errorf("%w: notional %f is below the minimum %f", ErrInvalidOrder, notional, product.GetQuoteMinSize())
+	}
+
+	return nil
+}
+
+func onIncrement(v, inc float64) bool {
+	if inc <= 0 {
+		return true
+	}
+
+	return math.Abs(utils.FloorToIncrement(v, inc)-v) < inc*1e-6
+}